@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// combinedTime is the time format of the Apache and nginx access logs
+const combinedTime = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLog logs the requests served by a handler, see AccessLogMiddleware
+type AccessLog struct {
+	// Combined receives a line per request in the Apache/nginx "combined" format when not nil, for
+	// the tools only reading the classic access logs
+	Combined io.Writer
+	// NoEntries disables the log entries of the requests, to only write the combined lines
+	NoEntries bool
+
+	mu sync.Mutex
+}
+
+// AccessLogMiddleware logs an entry for every request once it is served, with the request as the
+// Cloud Logging "httpRequest" and a message such as "GET /orders 200". The entry is written by the
+// logger of the request context, or the default logger, and has the ERROR severity for the 5xx
+// responses, WARN for the 4xx ones and INFO otherwise. Chained after RequestIDMiddleware, the entries have the requestId of the request:
+//
+//	http.Handle("/", logger.RequestIDMiddleware(logger.AccessLogMiddleware(handler)))
+//
+// See AccessLog to write the requests in the combined format as well, or instead
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return (&AccessLog{}).Middleware(next)
+}
+
+// Middleware logs the requests served by next
+func (a *AccessLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := FromContext(r.Context())
+		start := l.clock()
+		began := time.Now()
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+
+		if a.Combined != nil {
+			line := combinedLine(r, sw.status, sw.size, start)
+			a.mu.Lock()
+			if _, err := a.Combined.Write(line); err != nil {
+				reportError(err)
+			}
+			a.mu.Unlock()
+		}
+		if a.NoEntries {
+			return
+		}
+
+		req := NewHTTPRequest(r, sw.status, sw.size, time.Since(began))
+		msg := r.Method + " " + r.URL.RequestURI() + " " + strconv.Itoa(sw.status)
+		switch {
+		case sw.status >= 500:
+			l.WithHTTPRequest(req).Error(msg)
+		case sw.status >= 400:
+			l.WithHTTPRequest(req).Warn(msg)
+		default:
+			l.WithHTTPRequest(req).Info(msg)
+		}
+	})
+}
+
+// combinedLine formats a request in the combined format, e.g.
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+func combinedLine(r *http.Request, status int, size int64, t time.Time) []byte {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user, _, _ := r.BasicAuth()
+	if user == "" && r.URL.User != nil {
+		user = r.URL.User.Username()
+	}
+
+	buf := new(bytes.Buffer)
+	writeCombinedField(buf, host)
+	buf.WriteString(" - ")
+	writeCombinedField(buf, user)
+	buf.WriteString(" [")
+	buf.WriteString(t.Format(combinedTime))
+	buf.WriteString(`] "`)
+	writeCombinedEscaped(buf, r.Method+" "+r.URL.RequestURI()+" "+r.Proto)
+	buf.WriteString(`" `)
+	buf.WriteString(strconv.Itoa(status))
+	buf.WriteByte(' ')
+	if size > 0 {
+		buf.WriteString(strconv.FormatInt(size, 10))
+	} else {
+		buf.WriteByte('-')
+	}
+	buf.WriteString(` "`)
+	writeCombinedEscaped(buf, r.Referer())
+	buf.WriteString(`" "`)
+	writeCombinedEscaped(buf, r.UserAgent())
+	buf.WriteString("\"\n")
+	return buf.Bytes()
+}
+
+// writeCombinedField writes an unquoted field, "-" when empty
+func writeCombinedField(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	writeCombinedEscaped(buf, s)
+}
+
+// writeCombinedEscaped writes s escaped as Apache does, the quotes and backslashes with a
+// backslash and the control and non-ASCII characters as \xhh, so a client cannot forge lines
+func writeCombinedEscaped(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			buf.WriteString(`\x`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+// statusWriter records the status and size of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data to the client when the underlying writer supports it
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection when the underlying writer supports it, e.g.
+// for a WebSocket. The request is logged with the 101 status unless another one was written
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("logger: the response writer %T does not support hijacking", w.ResponseWriter)
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
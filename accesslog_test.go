@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	SetDefault(New(WithWriter(buf)))
+	defer SetDefault(nil)
+
+	handler := RequestIDMiddleware(AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			http.Error(w, "failure", http.StatusInternalServerError)
+			return
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("hello"))
+	})))
+
+	req := httptest.NewRequest("GET", "/orders?id=1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var p struct {
+		Payload
+		Context struct {
+			Data Fields `json:"data"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("cannot decode the entry %s: %s", buf.String(), err.Error())
+	}
+	if p.Severity != "INFO" || p.Message != "GET /orders?id=1 200" {
+		t.Errorf("unexpected %s entry %q", p.Severity, p.Message)
+	}
+	if r := p.HTTPRequest; r == nil || r.Status != 200 || r.ResponseSize != 5 || r.RequestURL != "/orders?id=1" {
+		t.Errorf("unexpected httpRequest %+v", r)
+	}
+	if id := p.Context.Data["requestId"]; id != "abc-123" {
+		t.Errorf("requestId is %v, expected abc-123", id)
+	}
+
+	buf.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/fail", nil))
+	if got := buf.String(); !strings.Contains(got, `"severity":"ERROR"`) || !strings.Contains(got, `"message":"POST /fail 500"`) {
+		t.Errorf("unexpected entry for a failed request %s", got)
+	}
+
+	buf.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	if got := buf.String(); !strings.Contains(got, `"severity":"WARN"`) || !strings.Contains(got, `"message":"GET /missing 404"`) {
+		t.Errorf("unexpected entry for a client error %s", got)
+	}
+}
+
+func TestAccessLogHijack(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	SetDefault(New(WithWriter(buf)))
+	defer SetDefault(nil)
+
+	handler := AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("cannot hijack the connection: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	}))
+
+	// The server does not wait for the hijacked connections when closed
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		close(done)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("cannot send the request: %s", err.Error())
+	}
+	resp.Body.Close()
+	<-done
+
+	if got := buf.String(); !strings.Contains(got, `"message":"GET /ws 101"`) {
+		t.Errorf("unexpected entry for a hijacked connection %s", got)
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	SetDefault(New(WithWriter(buf), WithClock(testClock)))
+	defer SetDefault(nil)
+
+	combined := new(bytes.Buffer)
+	a := &AccessLog{Combined: combined, NoEntries: true}
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest("DELETE", "/orders/1", nil)
+	req.RemoteAddr = "10.0.0.1:4242"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://www.example.com/start.html")
+	req.Header.Set("User-Agent", "Mozilla/4.08 \"quoted\"\n")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := `10.0.0.1 - frank [` + testTime.Format(combinedTime) + `] "DELETE /orders/1 HTTP/1.1" 204 - "http://www.example.com/start.html" "Mozilla/4.08 \"quoted\"\x0a"` + "\n"
+	if got := combined.String(); got != expected {
+		t.Errorf("combined line %s does not match expected line %s", got, expected)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("entry %s written with NoEntries", buf.String())
+	}
+}
@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when writing to a sink that has already been closed
+var ErrClosed = errors.New("logger: write to closed writer")
+
+// AsyncWriter queues the log entries on a bounded channel and writes them to the
+// underlying writer from a background goroutine, so slow writers do not block the callers.
+// When the queue is full the callers block until there is room for the entry, unless the
+// writer spools them to disk, see NewSpoolingAsyncWriter.
+// Flush or Close must be called before the program exits to avoid losing entries.
+type AsyncWriter struct {
+	w       io.Writer
+	entries chan asyncEntry
+	done    chan struct{}
+	// spool holds the entries overflowing the queue, nil when they block the callers
+	spool *spool
+
+	mu     sync.RWMutex
+	closed bool
+	err    error
+}
+
+type asyncEntry struct {
+	data []byte
+	// level and time are the severity and time of the entry, only set when leveled
+	level   severity
+	time    time.Time
+	leveled bool
+	// flushed is set on the markers pushed by Flush, and closed once every previous entry is written
+	flushed chan error
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w with a queue of size entries
+func NewAsyncWriter(w io.Writer, size int) *AsyncWriter {
+	a := &AsyncWriter{
+		w:       w,
+		entries: make(chan asyncEntry, size),
+		done:    make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	var err error
+	for {
+		e, ok := a.next()
+		if !ok {
+			return
+		}
+
+		if e.flushed != nil {
+			if a.spool != nil {
+				if serr := a.spool.replayAll(a.write); serr != nil && err == nil {
+					err = serr
+				}
+			}
+			e.flushed <- err
+			err = nil
+			continue
+		}
+
+		if werr := a.write(e); werr != nil {
+			// The caller of Write already returned, count the entry as dropped
+			dropEntry(werr)
+			if err == nil {
+				err = werr
+			}
+		}
+	}
+}
+
+// next returns the next queued entry, replaying the spooled entries while the queue is empty
+func (a *AsyncWriter) next() (asyncEntry, bool) {
+	for a.spool != nil && a.spool.pending() {
+		select {
+		case e, ok := <-a.entries:
+			return e, ok
+		default:
+		}
+
+		if err := a.spool.replay(a.write); err != nil {
+			// Retry later, unless a new entry or a flush comes first
+			timer := time.NewTimer(spoolRetryDelay)
+			select {
+			case e, ok := <-a.entries:
+				timer.Stop()
+				return e, ok
+			case <-timer.C:
+			}
+		}
+	}
+
+	e, ok := <-a.entries
+	return e, ok
+}
+
+// write writes an entry to the underlying writer, with its severity when it uses it
+func (a *AsyncWriter) write(e asyncEntry) error {
+	var err error
+	if lw, ok := a.w.(levelWriter); ok && e.leveled {
+		_, err = lw.writeLevel(e.level, e.time, e.data)
+	} else {
+		_, err = a.w.Write(e.data)
+	}
+	return err
+}
+
+// Write queues a copy of p to be written by the background goroutine
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	return a.enqueue(p, asyncEntry{})
+}
+
+// writeLevel is Write passing the severity and time along to the writers that use them
+func (a *AsyncWriter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	return a.enqueue(p, asyncEntry{level: s, time: t, leveled: true})
+}
+
+// enqueue queues e with a copy of p
+func (a *AsyncWriter) enqueue(p []byte, e asyncEntry) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return 0, ErrClosed
+	}
+
+	e.data = make([]byte, len(p))
+	copy(e.data, p)
+	if a.spool != nil {
+		a.spool.enqueue(a.entries, e)
+		return len(p), nil
+	}
+	a.entries <- e
+	return len(p), nil
+}
+
+// Flush blocks until all the queued entries have been written. It returns the first
+// error returned by the underlying writer since the previous Flush
+func (a *AsyncWriter) Flush() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return a.err
+	}
+
+	flushed := make(chan error, 1)
+	a.entries <- asyncEntry{flushed: flushed}
+	return <-flushed
+}
+
+// Close drains the queue and stops the background goroutine. The underlying writer is not closed,
+// the spool file is, with the entries that could not be written left in it
+func (a *AsyncWriter) Close() error {
+	err := a.Flush()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return err
+	}
+	a.closed = true
+	a.err = err
+	close(a.entries)
+	<-a.done
+	if a.spool != nil {
+		if cerr := a.spool.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter simulates a sink that takes a while to accept each write
+type slowWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriterFlush(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	sink := &slowWriter{}
+	async := NewAsyncWriter(sink, 100)
+	log := New().WithOutput(async)
+
+	for i := 0; i < 10; i++ {
+		log.Infof("INFO message %d", i)
+	}
+
+	if err := async.Flush(); err != nil {
+		t.Errorf("unexpected flush error: %s", err.Error())
+	}
+
+	lines := strings.Split(strings.TrimRight(sink.String(), "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 entries after Flush; got %d", len(lines))
+	}
+
+	// Entries must keep the order in which they were logged
+	for i, line := range lines {
+		if !strings.Contains(line, fmt.Sprintf(`"message":"INFO message %d"`, i)) {
+			t.Errorf("entry %d out of order: %s", i, line)
+		}
+	}
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	sink := &slowWriter{}
+	async := NewAsyncWriter(sink, 1)
+	log := New().WithOutput(async)
+
+	log.Info("INFO message")
+	log.Warn("WARN message")
+
+	if err := async.Close(); err != nil {
+		t.Errorf("unexpected close error: %s", err.Error())
+	}
+
+	if got := strings.Count(sink.String(), "\n"); got != 2 {
+		t.Errorf("expected 2 entries after Close; got %d", got)
+	}
+
+	if _, err := async.Write([]byte("late entry\n")); err != ErrClosed {
+		t.Errorf("expected ErrClosed writing after Close; got %v", err)
+	}
+
+	// Closing twice is harmless
+	if err := async.Close(); err != nil {
+		t.Errorf("unexpected error closing twice: %s", err.Error())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAsyncWriterFlushReportsWriteErrors(t *testing.T) {
+	async := NewAsyncWriter(failingWriter{}, 10)
+	defer async.Close()
+
+	async.Write([]byte("entry\n"))
+	if err := async.Flush(); err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the writer error from Flush; got %v", err)
+	}
+
+	// The error is reported only once
+	if err := async.Flush(); err != nil {
+		t.Errorf("expected no error on the second Flush; got %v", err)
+	}
+}
+
+func TestAsyncWriterRoutesLevels(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	out, errb := new(bytes.Buffer), new(bytes.Buffer)
+	async := NewAsyncWriter(NewLevelRouter(out).Route(ERROR, CRITICAL, errb), 10)
+	log := New(WithWriter(async))
+	log.Info("INFO message")
+	log.Error("ERROR message")
+	log.Error("second ERROR message")
+	if err := async.Close(); err != nil {
+		t.Fatalf("expected no error; got %s", err.Error())
+	}
+
+	if got := strings.Count(out.String(), "\n"); got != 1 || !strings.Contains(out.String(), "INFO message") {
+		t.Errorf("expected the INFO entry only; got %s", out.String())
+	}
+	if got := strings.Count(errb.String(), "\n"); got != 2 {
+		t.Errorf("expected the ERROR entries; got %s", errb.String())
+	}
+}
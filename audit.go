@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+// auditField starts the audit object appended to the entries. The quote cannot appear unescaped
+// in a JSON string, so it is only found where AuditWriter put it
+const auditField = `,"audit":{"prev":`
+
+// ErrAuditTampered is returned by VerifyAuditLog when an entry was altered, removed or reordered
+var ErrAuditTampered = errors.New("logger: audit log was tampered with")
+
+// AuditWriter is an io.Writer chaining the JSON log entries for the audit logs. Each entry gets
+// an "audit" object with the hash of the previous entry and its own hash, computed over the
+// previous hash and the entry, so altering, removing or reordering the entries breaks the chain.
+// When a key is set the hashes are HMAC-SHA256 signatures, which cannot be recomputed without
+// the key; otherwise they are SHA-256 digests.
+// VerifyAuditLog checks an exported log. The truncation of its end is only detected by comparing
+// its last hash with the one recorded by the application, see Head.
+type AuditWriter struct {
+	w   io.Writer
+	key []byte
+
+	mu   sync.Mutex
+	head string
+}
+
+// NewAuditWriter returns an AuditWriter writing to w, signing the entries with key when not empty.
+// prev is the last hash of the chain to resume, e.g. after a restart, empty to start a new chain
+func NewAuditWriter(w io.Writer, key []byte, prev string) *AuditWriter {
+	return &AuditWriter{
+		w:    w,
+		key:  key,
+		head: prev,
+	}
+}
+
+// WithAudit chains the entries written to the output of the logger, see AuditWriter. It wraps the
+// current output so it must come after the output option
+func WithAudit(key []byte) Option {
+	return func(l *Log) {
+		l.writer = NewAuditWriter(l.writer, key, "")
+	}
+}
+
+// Write chains each JSON entry of p and writes them out in a single call
+func (a *AuditWriter) Write(p []byte) (int, error) {
+	return a.write(p, a.w.Write)
+}
+
+// writeLevel is Write passing the severity and time along to the writers that use them
+func (a *AuditWriter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	if lw, ok := a.w.(levelWriter); ok {
+		return a.write(p, func(b []byte) (int, error) { return lw.writeLevel(s, t, b) })
+	}
+	return a.Write(p)
+}
+
+func (a *AuditWriter) write(p []byte, write func([]byte) (int, error)) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]byte, 0, len(p)+200)
+	head := a.head
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		line = bytes.TrimRight(line, " \r")
+		if len(line) < 2 || line[0] != '{' || line[len(line)-1] != '}' {
+			return 0, errors.New("logger: the audit log entries must be JSON objects")
+		}
+
+		next := auditHash(a.key, head, line)
+		out = append(out, line[:len(line)-1]...)
+		out = append(out, fmt.Sprintf(`%s"%s","hash":"%s"}}`+"\n", auditField, head, next)...)
+		head = next
+	}
+
+	if _, err := write(out); err != nil {
+		return 0, err
+	}
+	// The chain only moves forward once the entries are written, so a failed write can be retried
+	a.head = head
+	return len(p), nil
+}
+
+// Head returns the hash of the last entry written, to be recorded outside of the log so the
+// truncation of its end can be detected
+func (a *AuditWriter) Head() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.head
+}
+
+// Sync flushes the underlying writer when it buffers its output
+func (a *AuditWriter) Sync() error {
+	return syncWriter(a.w)
+}
+
+// Close flushes then closes the underlying writer
+func (a *AuditWriter) Close() error {
+	return closeWriter(a.w)
+}
+
+// auditHash returns the hash of an entry chained to the previous hash
+func auditHash(key []byte, prev string, entry []byte) string {
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(prev))
+	h.Write([]byte{'\n'})
+	h.Write(entry)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyAuditLog checks the chain of the entries written by an AuditWriter with the same key,
+// starting from prev, and returns the hash of the last entry. It returns ErrAuditTampered, with the
+// line number, at the first entry that does not match
+func VerifyAuditLog(r io.Reader, key []byte, prev string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	head := prev
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		i := bytes.LastIndex(line, []byte(auditField))
+		if i < 0 {
+			return head, fmt.Errorf("%w: line %d has no audit hash", ErrAuditTampered, n)
+		}
+		entry := append(line[:i:i], '}')
+
+		next := auditHash(key, head, entry)
+		if string(line[i:]) != fmt.Sprintf(`%s"%s","hash":"%s"}}`, auditField, head, next) {
+			return head, fmt.Errorf("%w: line %d does not match the chain", ErrAuditTampered, n)
+		}
+		head = next
+	}
+	return head, scanner.Err()
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAuditWriter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	for _, key := range [][]byte{nil, []byte("secret")} {
+		buf := new(bytes.Buffer)
+		log := New(WithWriter(buf), WithAudit(key))
+		log.Info("user created")
+		log.With(Fields{"user": "+1234567890"}).WithOutput(log.writer).Warn("role changed")
+		log.Info("user deleted")
+
+		lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		if len(lines) != 3 || !strings.Contains(lines[0], `"message":"user created","serviceContext":{"service":"my-app","version":"1.0"},"audit":{"prev":"","hash":"`) {
+			t.Fatalf("unexpected entries %s", buf.String())
+		}
+
+		head, err := VerifyAuditLog(strings.NewReader(buf.String()), key, "")
+		if err != nil {
+			t.Errorf("unexpected error %s", err.Error())
+		}
+		if head != log.writer.(*AuditWriter).Head() {
+			t.Errorf("expected the head %s; got %s", log.writer.(*AuditWriter).Head(), head)
+		}
+
+		tampered := []string{
+			strings.Replace(buf.String(), "role changed", "role kept", 1),
+			lines[0] + "\n" + lines[2] + "\n",
+			lines[1] + "\n" + lines[0] + "\n" + lines[2] + "\n",
+			"{}\n" + buf.String(),
+		}
+		for _, s := range tampered {
+			if _, err := VerifyAuditLog(strings.NewReader(s), key, ""); !errors.Is(err, ErrAuditTampered) {
+				t.Errorf("expected the tampering to be detected in %s; got %v", s, err)
+			}
+		}
+	}
+}
+
+func TestAuditWriterKey(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewAuditWriter(buf, []byte("secret"), "")
+	w.Write([]byte(`{"message":"user created"}` + "\n"))
+
+	if _, err := VerifyAuditLog(strings.NewReader(buf.String()), []byte("other"), ""); !errors.Is(err, ErrAuditTampered) {
+		t.Errorf("expected the signature not to match another key; got %v", err)
+	}
+	if _, err := VerifyAuditLog(strings.NewReader(buf.String()), nil, ""); !errors.Is(err, ErrAuditTampered) {
+		t.Errorf("expected the signature not to match a digest; got %v", err)
+	}
+
+	// Resuming the chain from the head
+	head := w.Head()
+	buf.Reset()
+	NewAuditWriter(buf, []byte("secret"), head).Write([]byte(`{"message":"user deleted"}` + "\n"))
+	if _, err := VerifyAuditLog(strings.NewReader(buf.String()), []byte("secret"), head); err != nil {
+		t.Errorf("unexpected error %s", err.Error())
+	}
+}
+
+func TestAuditWriterErrors(t *testing.T) {
+	w := NewAuditWriter(failingWriter{}, nil, "")
+	if _, err := w.Write([]byte("{}\n")); err == nil || w.Head() != "" {
+		t.Errorf("expected the chain not to move on a failed write")
+	}
+
+	w = NewAuditWriter(new(bytes.Buffer), nil, "")
+	if _, err := w.Write([]byte("plain text\n")); err == nil {
+		t.Errorf("expected an error for a non JSON entry")
+	}
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Default bounds of the batches of a BatchWriter
+const (
+	defaultBatchSize     = 1 << 20
+	defaultBatchInterval = time.Second
+)
+
+// BatchWriter coalesces the log entries into batches written to the underlying writer at once,
+// when they reach a size or when the oldest entry has waited for an interval, reducing the
+// syscalls of a file or the requests of an HTTP sink such as a CloudLoggingWriter.
+// The entries of a batch that fails are counted by DroppedEntries.
+// Flush or Close must be called before the program exits to avoid losing entries.
+type BatchWriter struct {
+	w        io.Writer
+	size     int
+	interval time.Duration
+
+	mu      sync.Mutex
+	buf     []byte
+	entries int
+	timer   *time.Timer
+	closed  bool
+	err     error
+}
+
+// NewBatchWriter returns a BatchWriter writing to w batches of up to size bytes, at least every
+// interval. The defaults of 1MB and 1s are used when they are zero
+func NewBatchWriter(w io.Writer, size int, interval time.Duration) *BatchWriter {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultBatchInterval
+	}
+	return &BatchWriter{
+		w:        w,
+		size:     size,
+		interval: interval,
+	}
+}
+
+// WithBatching writes the log entries through a BatchWriter wrapping the current output, so it
+// must come after the option setting the output
+func WithBatching(size int, interval time.Duration) Option {
+	return func(l *Log) {
+		l.writer = NewBatchWriter(l.writer, size, interval)
+	}
+}
+
+// Write adds p to the current batch, writing the batch when it is full
+func (b *BatchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, ErrClosed
+	}
+
+	// Do not let the entry exceed the size, unless it is alone in the batch
+	if len(b.buf) > 0 && len(b.buf)+len(p) > b.size {
+		b.write()
+	}
+
+	b.buf = append(b.buf, p...)
+	b.entries++
+	if len(b.buf) >= b.size {
+		b.write()
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flushTimer)
+	}
+	return len(p), nil
+}
+
+func (b *BatchWriter) flushTimer() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.write()
+}
+
+// write sends the current batch to the underlying writer, the lock must be held
+func (b *BatchWriter) write() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.buf) == 0 {
+		return
+	}
+
+	if _, err := b.w.Write(b.buf); err != nil {
+		// The callers of Write already returned, count their entries as dropped
+		dropEntries(b.entries, err)
+		if b.err == nil {
+			b.err = err
+		}
+	}
+	b.buf = b.buf[:0]
+	b.entries = 0
+}
+
+// Flush writes the current batch. It returns the first error returned by the underlying writer
+// since the previous Flush
+func (b *BatchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.write()
+	err := b.err
+	b.err = nil
+	return err
+}
+
+// Close writes the current batch, the following writes fail. The underlying writer is not closed
+func (b *BatchWriter) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.write()
+	b.closed = true
+	err := b.err
+	b.err = nil
+	return err
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordWriter records each write separately
+type recordWriter struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *recordWriter) get() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.writes...)
+}
+
+func TestBatchWriterSize(t *testing.T) {
+	w := &recordWriter{}
+	b := NewBatchWriter(w, 10, time.Hour)
+
+	b.Write([]byte("first\n"))
+	b.Write([]byte("second\n"))
+	b.Write([]byte("3\n"))
+	b.Write([]byte("a very long entry\n"))
+
+	writes := w.get()
+	if len(writes) != 3 || writes[0] != "first\n" || writes[1] != "second\n3\n" || writes[2] != "a very long entry\n" {
+		t.Errorf("unexpected writes %q", writes)
+	}
+
+	b.Flush()
+	if len(w.get()) != 3 {
+		t.Errorf("expected no write of an empty batch; got %q", w.get())
+	}
+}
+
+func TestBatchWriterInterval(t *testing.T) {
+	w := &recordWriter{}
+	b := NewBatchWriter(w, 0, 10*time.Millisecond)
+	defer b.Close()
+
+	b.Write([]byte("first\n"))
+	b.Write([]byte("second\n"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(w.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if writes := w.get(); len(writes) != 1 || writes[0] != "first\nsecond\n" {
+		t.Errorf("unexpected writes %q", writes)
+	}
+}
+
+func TestBatchWriterErrors(t *testing.T) {
+	OnError(func(error) {})
+	defer OnError(nil)
+
+	dropped := DroppedEntries()
+	b := NewBatchWriter(failingWriter{}, 0, time.Hour)
+	b.Write([]byte("first\n"))
+	b.Write([]byte("second\n"))
+
+	if err := b.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the writer error; got %v", err)
+	}
+	if got := DroppedEntries() - dropped; got != 2 {
+		t.Errorf("expected 2 dropped entries; got %d", got)
+	}
+	if _, err := b.Write([]byte("third\n")); err != ErrClosed {
+		t.Errorf("expected ErrClosed; got %v", err)
+	}
+}
+
+func TestLoggerWithBatching(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithBatching(0, time.Hour))
+	log.Info("INFO message")
+	log.Info("INFO message")
+
+	if buf.Len() != 0 {
+		t.Errorf("expected the entries to be batched; got %s", buf.String())
+	}
+	log.Sync()
+	if n := bytes.Count(buf.Bytes(), []byte(`"message":"INFO message"`)); n != 2 {
+		t.Errorf("expected 2 entries; got %d in %s", n, buf.String())
+	}
+}
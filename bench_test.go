@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestDisabledLevelDoesNotAllocate(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	log := New().With(Fields{"key": "value"}).WithOutput(ioutil.Discard)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Debug("DEBUG message")
+		log.Debugf("DEBUG message %s", "with param")
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations for a disabled level; got %v", allocs)
+	}
+}
+
+func TestInfoWithFieldsAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the pooled payloads and buffers are dropped at random with the race detector")
+	}
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New().With(Fields{
+		"user":    "+1234567890",
+		"action":  "create-account",
+		"attempt": 3,
+	}).WithOutput(ioutil.Discard)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Info("INFO message")
+	})
+	if allocs > 1 {
+		t.Errorf("expected at most one allocation for an entry with fields; got %v", allocs)
+	}
+}
+
+func BenchmarkDisabledLevel(b *testing.B) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	log := New().With(Fields{"key": "value"}).WithOutput(ioutil.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Debugf("DEBUG message %s", "with param")
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New().WithOutput(ioutil.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("INFO message")
+	}
+}
+
+func BenchmarkInfoWithFields(b *testing.B) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New().With(Fields{
+		"user":    "+1234567890",
+		"action":  "create-account",
+		"attempt": 3,
+	}).WithOutput(ioutil.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("INFO message")
+	}
+}
+
+func BenchmarkInfoWithFieldsMsgpack(b *testing.B) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New(WithMsgpackOutput()).With(Fields{
+		"user":    "+1234567890",
+		"action":  "create-account",
+		"attempt": 3,
+	}).WithOutput(ioutil.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("INFO message")
+	}
+}
+
+func BenchmarkError(b *testing.B) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New().With(Fields{"key": "value"}).WithOutput(ioutil.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Error("ERROR message")
+	}
+}
+
+func BenchmarkNop(b *testing.B) {
+	log := Nop().With(Fields{"key": "value"})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log.Infof("INFO message %s", "with param")
+	}
+}
+
+func BenchmarkWithChain(b *testing.B) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New().WithOutput(ioutil.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := log
+		for j := 0; j < 10; j++ {
+			l = l.With(Fields{"key": j})
+		}
+		l.Info("INFO message")
+	}
+}
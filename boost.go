@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// boostLevel and boostUntil, in Unix nanoseconds, hold the level of the current boost. They
+	// are only accessed atomically, boostUntil is zero when there is no boost
+	boostLevel int32
+	boostUntil int64
+
+	// boostMu serializes the boosts, boostID identifies the current one
+	boostMu sync.Mutex
+	boostID uint64
+)
+
+// BoostLevel lowers the global log level to s for the duration d, e.g. BoostLevel(DEBUG,
+// 5*time.Minute) to debug a running process without forgetting to turn the verbose logging off.
+// The level set with SetLevel applies again once the boost expires or the returned function is
+// called, whichever comes first. A new boost replaces the current one. The loggers with their
+// own level, set with WithLevel or SetNamedLevels, are not affected
+func BoostLevel(s severity, d time.Duration) (stop func()) {
+	boostMu.Lock()
+	defer boostMu.Unlock()
+
+	boostID++
+	id := boostID
+	atomic.StoreInt32(&boostLevel, int32(s))
+	atomic.StoreInt64(&boostUntil, time.Now().Add(d).UnixNano())
+
+	return func() {
+		boostMu.Lock()
+		defer boostMu.Unlock()
+		if boostID == id {
+			atomic.StoreInt64(&boostUntil, 0)
+		}
+	}
+}
+
+// boostedLevel returns the level of the current boost, ok is false when there is none
+func boostedLevel() (s severity, ok bool) {
+	until := atomic.LoadInt64(&boostUntil)
+	if until == 0 || time.Now().UnixNano() >= until {
+		return 0, false
+	}
+	return severity(atomic.LoadInt32(&boostLevel)), true
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBoostLevel(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf))
+
+	stop := BoostLevel(DEBUG, time.Hour)
+	defer stop()
+	log.Debug("boosted")
+	if GetLevel() != DEBUG || !strings.Contains(buf.String(), "boosted") {
+		t.Errorf("expected the DEBUG entries during the boost; got level %s and %s", GetLevel(), buf)
+	}
+
+	// A level set during the boost applies once it ends
+	SetLevel(WARN)
+	if GetLevel() != DEBUG {
+		t.Errorf("expected the boost to take precedence; got %s", GetLevel())
+	}
+	stop()
+	if GetLevel() != WARN {
+		t.Errorf("expected WARN after the boost; got %s", GetLevel())
+	}
+
+	// A boost does not raise the level
+	SetLevel(TRACE)
+	BoostLevel(INFO, time.Hour)()
+	if GetLevel() != TRACE {
+		t.Errorf("expected TRACE; got %s", GetLevel())
+	}
+}
+
+func TestBoostLevelExpires(t *testing.T) {
+	initConfig(WARN, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	BoostLevel(DEBUG, 20*time.Millisecond)
+	if GetLevel() != DEBUG {
+		t.Errorf("expected DEBUG during the boost; got %s", GetLevel())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if GetLevel() != WARN {
+		t.Errorf("expected WARN once the boost expired; got %s", GetLevel())
+	}
+}
+
+func TestBoostLevelStopOnlyItsOwn(t *testing.T) {
+	initConfig(WARN, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	stop := BoostLevel(INFO, time.Hour)
+	stopDebug := BoostLevel(DEBUG, time.Hour)
+	defer stopDebug()
+
+	stop()
+	if GetLevel() != DEBUG {
+		t.Errorf("expected the replacing boost to be kept; got %s", GetLevel())
+	}
+}
+
+func TestLevelHandlerBoost(t *testing.T) {
+	initConfig(WARN, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	tests := []struct {
+		body     string
+		status   int
+		expected string
+	}{
+		{`{"level":"DEBUG","duration":"1h"}`, http.StatusOK, `{"level":"DEBUG","duration":"1h"}`},
+		{`{"level":"DEBUG","duration":"soon"}`, http.StatusBadRequest, `{"error":"duration must be a positive duration such as 5m, for the global level only"}`},
+		{`{"module":"storage","level":"DEBUG","duration":"1h"}`, http.StatusBadRequest, `{"error":"duration must be a positive duration such as 5m, for the global level only"}`},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		LevelHandler{}.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(test.body)))
+		if rec.Code != test.status || strings.TrimSpace(rec.Body.String()) != test.expected {
+			t.Errorf("%s: unexpected response %d %s", test.body, rec.Code, rec.Body)
+		}
+	}
+
+	defer atomic.StoreInt64(&boostUntil, 0)
+	if GetLevel() != DEBUG {
+		t.Errorf("expected the boosted DEBUG level; got %s", GetLevel())
+	}
+}
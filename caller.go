@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// SourceLocation is the location in the source code of the logging call, which Cloud Logging
+// shows next to the entry
+type SourceLocation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,string"`
+	Function string `json:"function"`
+}
+
+// AddCaller records the location of the logging call in every entry, as the "caller" field and
+// the Cloud Logging source location. The skip is the number of extra frames to skip, for the
+// calls made through wrappers of the logger
+func AddCaller(skip int) Option {
+	return func(l *Log) {
+		l.addCaller = true
+		l.callerSkip = skip
+	}
+}
+
+// WithCallerSkip skips n extra frames when looking for the location of the logging call, in the
+// report location of the ERROR and CRITICAL entries and in the caller, so the calls made through
+// wrappers of the logger point to the code calling the wrappers
+func WithCallerSkip(n int) Option {
+	return func(l *Log) {
+		l.callerSkip = n
+	}
+}
+
+// AddCallerSkip creates a copy of a Log skipping n more frames than it when looking for the
+// location of the logging call
+func (l *Log) AddCallerSkip(n int) *Log {
+	c := l.With(Fields{})
+	c.callerSkip += n
+	return c
+}
+
+// CallerFormat formats the "caller" field of the entries from the location of the logging call
+type CallerFormat func(loc SourceLocation) string
+
+// WithCallerFormat sets the format of the "caller" field recorded by AddCaller, ShortCaller by default
+func WithCallerFormat(f CallerFormat) Option {
+	return func(l *Log) {
+		l.callerFormat = f
+	}
+}
+
+// ShortCaller formats the caller as the file with its parent directory only, followed by the
+// line, e.g. "logger/logger.go:42"
+func ShortCaller(loc SourceLocation) string {
+	return shortCaller(loc.File, loc.Line)
+}
+
+// FullCaller formats the caller as the path of the file followed by the line
+func FullCaller(loc SourceLocation) string {
+	return loc.File + ":" + strconv.Itoa(loc.Line)
+}
+
+// FunctionCaller formats the caller as the function without its package path, followed by the
+// line, e.g. "logger.(*Log).Info:42"
+func FunctionCaller(loc SourceLocation) string {
+	_, function := path.Split(loc.Function)
+	return function + ":" + strconv.Itoa(loc.Line)
+}
+
+// setCaller sets the caller and the source location of the payload
+func (l *Log) setCaller(p *Payload, fpc uintptr, file string, line int) {
+	function := "unknown"
+	if fun := runtime.FuncForPC(fpc); fun != nil {
+		function = fun.Name()
+		if l.trimPaths {
+			file = trimPath(file, function)
+		}
+	}
+
+	p.SourceLocation = &SourceLocation{
+		File:     file,
+		Line:     line,
+		Function: function,
+	}
+	if l.callerFormat != nil {
+		p.Caller = l.callerFormat(*p.SourceLocation)
+	} else {
+		p.Caller = shortCaller(file, line)
+	}
+}
+
+// shortCaller returns the file with its parent directory only, followed by the line, e.g. "logger/logger.go:42"
+func shortCaller(file string, line int) string {
+	dir, name := filepath.Split(file)
+	return filepath.Join(filepath.Base(dir), name) + ":" + strconv.Itoa(line)
+}
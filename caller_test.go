@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// logWarn logs through a wrapper, as the helper packages wrapping the logger do
+func logWarn(log *Log, message string) {
+	log.Warn(message)
+}
+
+func TestAddCaller(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(AddCaller(0)).WithOutput(buf)
+	_, file, line, _ := runtime.Caller(0)
+	log.Info("INFO message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	expected := shortCaller(file, line+1)
+	if !strings.HasSuffix(expected, "/caller_test.go:"+strconv.Itoa(line+1)) || p.Caller != expected {
+		t.Errorf("caller %s does not match %s", p.Caller, expected)
+	}
+	loc := p.SourceLocation
+	if loc == nil || loc.File != file || loc.Line != line+1 || loc.Function != "github.com/teltech/logger.TestAddCaller" {
+		t.Errorf("unexpected source location %+v", loc)
+	}
+}
+
+func TestAddCallerSkip(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(AddCaller(1)).WithOutput(buf)
+	_, _, line, _ := runtime.Caller(0)
+	logWarn(log, "WARN message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	if p.SourceLocation == nil || p.SourceLocation.Line != line+1 {
+		t.Errorf("source location %+v does not point to the wrapper call", p.SourceLocation)
+	}
+}
+
+func TestAddCallerOnErrors(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(AddCaller(0)).WithOutput(buf).Error("ERROR message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	loc := p.Context.ReportLocation
+	if p.Caller != shortCaller(loc.FilePath, loc.LineNumber) || p.SourceLocation.Line != loc.LineNumber {
+		t.Errorf("caller %s does not match the report location %+v", p.Caller, loc)
+	}
+}
+
+func TestCallerIsOffByDefault(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New().WithOutput(buf).Info("INFO message")
+	if strings.Contains(buf.String(), "caller") || strings.Contains(buf.String(), "sourceLocation") {
+		t.Errorf("output %s contains the caller", buf.String())
+	}
+}
+
+// logError logs through a wrapper, as the helper packages wrapping the logger do
+func logError(log *Log, message string) {
+	log.Error(message)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithCallerSkip(1)).WithOutput(buf)
+	_, _, line, _ := runtime.Caller(0)
+	logError(log, "ERROR message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	loc := p.Context.ReportLocation
+	if loc.FunctionName != "logger.TestWithCallerSkip" || loc.LineNumber != line+1 {
+		t.Errorf("report location %+v does not point to the wrapper call", loc)
+	}
+}
+
+func TestAddCallerSkipOnDerivedLogger(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	base := New(AddCaller(0)).WithOutput(buf)
+	log := base.AddCallerSkip(1)
+	_, _, line, _ := runtime.Caller(0)
+	logWarn(log, "WARN message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if p.SourceLocation == nil || p.SourceLocation.Line != line+1 {
+		t.Errorf("source location %+v does not point to the wrapper call", p.SourceLocation)
+	}
+
+	// The original logger is not modified
+	buf.Reset()
+	_, _, line, _ = runtime.Caller(0)
+	base.Warn("WARN message")
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if p.SourceLocation.Line != line+1 {
+		t.Errorf("source location %+v does not point to the direct call", p.SourceLocation)
+	}
+}
+
+func TestWithCallerFormat(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	_, file, _, _ := runtime.Caller(0)
+	tests := []struct {
+		format   CallerFormat
+		expected string
+	}{
+		{ShortCaller, filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)},
+		{FullCaller, file},
+		{FunctionCaller, "logger.TestWithCallerFormat"},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		log := New(WithWriter(buf), AddCaller(0), WithCallerFormat(test.format))
+		_, _, line, _ := runtime.Caller(0)
+		log.Debug("DEBUG message")
+
+		p := Payload{}
+		if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+			t.Fatalf("failed to unmarshal payload: %s", err.Error())
+		}
+		if expected := test.expected + ":" + strconv.Itoa(line+1); p.Caller != expected {
+			t.Errorf("expected the caller %s; got %s", expected, p.Caller)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"reflect"
+)
+
+// Clone creates an independent copy of a Log. Unlike the loggers derived with With, the copy has
+// its own fields, labels, hooks, filters and transformers: adding some to the copy, or to l, does
+// not affect the other one. The maps and slices nested in the fields are copied as well. The copy keeps the writer of l, and writes to it serialized with l,
+// as well as its sequence numbers, sampling and deduplication
+func (l *Log) Clone() *Log {
+	n := l.With(Fields{})
+	n.added = nil
+
+	p := *n.payload
+	p.Context = nil
+	if c := l.context(); c != nil {
+		cc := *c
+		cc.Data = copyFields(c.Data, 0)
+		p.Context = &cc
+	}
+	if p.Labels != nil {
+		labels := make(map[string]string, len(p.Labels))
+		for k, v := range p.Labels {
+			labels[k] = v
+		}
+		p.Labels = labels
+	}
+	n.payload = &p
+
+	n.hooks = l.hooks.clone()
+	if l.level != nil {
+		lvl := *l.level
+		n.level = &lvl
+	}
+	n.errStack = append([]uintptr(nil), l.errStack...)
+	return n
+}
+
+// clone returns a copy of the hook set
+func (hs *hookSet) clone() *hookSet {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	c := &hookSet{
+		filters:      append([]Filter(nil), hs.filters...),
+		transformers: append([]Transformer(nil), hs.transformers...),
+	}
+	if hs.hooks != nil {
+		c.hooks = make(map[severity][]Hook, len(hs.hooks))
+		for lvl, hooks := range hs.hooks {
+			c.hooks[lvl] = append([]Hook(nil), hooks...)
+		}
+	}
+	return c
+}
+
+// copyFields returns a copy of the fields and of the maps and slices nested in them
+func copyFields(f Fields, depth int) Fields {
+	c := make(Fields, len(f))
+	for k, v := range f {
+		c[k] = copyValue(v, depth+1)
+	}
+	return c
+}
+
+// copyValue returns a copy of v when it is a map or a slice, its elements copied the same way.
+// The values nested deeper than maxJSONDepth, e.g. in a map containing itself, are not copied
+func copyValue(v interface{}, depth int) interface{} {
+	if depth > maxJSONDepth {
+		return v
+	}
+
+	switch val := v.(type) {
+	case Fields:
+		if val == nil {
+			return val
+		}
+		return copyFields(val, depth)
+	case map[string]interface{}:
+		if val == nil {
+			return val
+		}
+		return map[string]interface{}(copyFields(val, depth))
+	case []interface{}:
+		if val == nil {
+			return val
+		}
+		c := make([]interface{}, len(val))
+		for i, e := range val {
+			c[i] = copyValue(e, depth+1)
+		}
+		return c
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyElem(iter.Value(), depth+1))
+		}
+		return c.Interface()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			c.Index(i).Set(copyElem(rv.Index(i), depth+1))
+		}
+		return c.Interface()
+	}
+	return v
+}
+
+// copyElem returns a copy of the element of a map or a slice, see copyValue
+func copyElem(e reflect.Value, depth int) reflect.Value {
+	if e.Kind() == reflect.Interface && e.IsNil() {
+		return e
+	}
+	return reflect.ValueOf(copyValue(e.Interface(), depth)).Convert(e.Type())
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf)).With(Fields{"user": "alice"}).WithLabels(map[string]string{"env": "test"})
+	var fired int
+	log.AddHook(HookFunc(func(p *Payload) error {
+		fired++
+		return nil
+	}))
+
+	clone := log.Clone()
+	clone.AddFilter(func(p *Payload) bool { return p.Message != "dropped" })
+	clone.AddHook(HookFunc(func(p *Payload) error {
+		p.Context.Data["cloned"] = true
+		return nil
+	}))
+	clone.payload.Labels["env"] = "changed"
+	clone.payload.Context.Data["user"] = "bob"
+
+	log.Info("dropped")
+	var p Payload
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Context.Data["user"] != "alice" || p.Context.Data["cloned"] != nil || p.Labels["env"] != "test" {
+		t.Errorf("the clone changed the original logger: %s", buf)
+	}
+
+	buf.Reset()
+	clone.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("expected the filter of the clone to drop the entry; got %s", buf)
+	}
+	clone.Info("kept")
+	if got := buf.String(); !strings.Contains(got, `"user":"bob"`) || !strings.Contains(got, `"cloned":true`) || !strings.Contains(got, `"env":"changed"`) {
+		t.Errorf("unexpected clone output %s", got)
+	}
+
+	if fired != 2 {
+		t.Errorf("expected the hook of the original logger to be copied; fired %d times", fired)
+	}
+}
+
+func TestCloneWithOutput(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	orig, other := new(bytes.Buffer), new(bytes.Buffer)
+	log := New(WithWriter(orig), WithLevel(WARN))
+	clone := log.Clone().WithOutput(other)
+
+	log.Warn("original")
+	clone.Warn("clone")
+	clone.Info("filtered")
+	if !strings.Contains(orig.String(), "original") || strings.Contains(orig.String(), "clone") {
+		t.Errorf("unexpected original output %s", orig)
+	}
+	if !strings.Contains(other.String(), "clone") || strings.Contains(other.String(), "filtered") {
+		t.Errorf("unexpected clone output %s", other)
+	}
+}
+
+func TestCloneCopiesNestedFields(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	request := map[string]interface{}{"path": "/orders"}
+	tags := []string{"a", "b"}
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf)).With(Fields{
+		"request": request,
+		"user":    Fields{"roles": []interface{}{"admin"}},
+		"tags":    tags,
+		"counts":  map[string]int{"orders": 1},
+	})
+
+	clone := log.Clone()
+	data := clone.payload.Context.Data
+	data["request"].(map[string]interface{})["path"] = "/changed"
+	data["user"].(Fields)["roles"].([]interface{})[0] = "guest"
+	data["tags"].([]string)[0] = "changed"
+	data["counts"].(map[string]int)["orders"] = 2
+
+	log.Info("INFO message")
+	expected := `"data":{"counts":{"orders":1},"request":{"path":"/orders"},"tags":["a","b"],"user":{"roles":["admin"]}}`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+	if request["path"] != "/orders" || tags[0] != "a" {
+		t.Errorf("the clone changed the values given to With")
+	}
+}
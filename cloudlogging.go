@@ -0,0 +1,380 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCloudLoggingEndpoint is the base URL of the Cloud Logging API
+const defaultCloudLoggingEndpoint = "https://logging.googleapis.com"
+
+// MonitoredResource identifies the resource that produced the log entries in Cloud Logging,
+// see https://cloud.google.com/logging/docs/api/v2/resource-list
+type MonitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CloudLoggingWriter is an io.Writer shipping each log entry directly to the Cloud Logging API
+// (entries.write) instead of relying on the logging agent scraping stdout. JSON entries are sent
+// as jsonPayload, with their severity, eventTime, insertId, labels, operation and httpRequest promoted to the LogEntry; any other encoding
+// is sent as textPayload.
+// Each Write performs an HTTP request, wrap it with NewAsyncWriter to keep it off the hot path.
+// The newline separated entries of a single Write, e.g. from a BatchWriter, are sent in one request.
+type CloudLoggingWriter struct {
+	// ProjectID is the project the entries are written to. It is detected from the
+	// GOOGLE_CLOUD_PROJECT environment variable or the metadata server when empty
+	ProjectID string
+	// LogName is the name of the log, e.g. "app", the value of SERVICE by default
+	LogName string
+	// Resource is the monitored resource of the entries, detected from the environment when nil
+	Resource *MonitoredResource
+	// Client is the HTTP client used to call the API. It must add the credentials to the requests,
+	// e.g. a client from golang.org/x/oauth2/google.DefaultClient. When nil, the access token of
+	// the default service account is requested from the metadata server
+	Client *http.Client
+	// Endpoint is the base URL of the API, https://logging.googleapis.com by default
+	Endpoint string
+
+	mu       sync.Mutex
+	detected bool
+	token    metadataToken
+}
+
+// WithCloudLogging writes the log entries to the Cloud Logging API under the given project and log name
+func WithCloudLogging(projectID, logName string) Option {
+	return func(l *Log) {
+		l.writer = &CloudLoggingWriter{
+			ProjectID: projectID,
+			LogName:   logName,
+		}
+	}
+}
+
+type cloudLogEntry struct {
+	LogName     string             `json:"logName"`
+	Resource    *MonitoredResource `json:"resource"`
+	Severity    string             `json:"severity,omitempty"`
+	Timestamp   string             `json:"timestamp,omitempty"`
+	InsertID    string             `json:"insertId,omitempty"`
+	Labels      map[string]string  `json:"labels,omitempty"`
+	Operation   *Operation         `json:"operation,omitempty"`
+	HTTPRequest *HTTPRequest       `json:"httpRequest,omitempty"`
+	JSONPayload json.RawMessage    `json:"jsonPayload,omitempty"`
+	TextPayload string             `json:"textPayload,omitempty"`
+}
+
+type cloudWriteRequest struct {
+	Entries []*cloudLogEntry `json:"entries"`
+}
+
+// Write sends each line of p as a log entry
+func (w *CloudLoggingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.detect(); err != nil {
+		return 0, err
+	}
+
+	var entries []*cloudLogEntry
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(line) > 0 {
+			entries = append(entries, w.entry(line))
+		}
+	}
+	if len(entries) == 0 {
+		return len(p), nil
+	}
+
+	body, err := json.Marshal(cloudWriteRequest{Entries: entries})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.post(body); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// entry builds the API log entry from an encoded payload
+func (w *CloudLoggingWriter) entry(p []byte) *cloudLogEntry {
+	e := &cloudLogEntry{
+		LogName:  fmt.Sprintf("projects/%s/logs/%s", w.ProjectID, url.PathEscape(w.LogName)),
+		Resource: w.Resource,
+	}
+
+	p = bytes.TrimRight(p, "\n")
+	payload := Payload{}
+	if err := json.Unmarshal(p, &payload); err != nil {
+		e.TextPayload = string(p)
+		return e
+	}
+
+	e.JSONPayload = json.RawMessage(p)
+	e.Severity = cloudSeverity(payload.Severity)
+	e.Timestamp = payload.EventTime
+	e.InsertID = payload.InsertID
+	e.Labels = payload.Labels
+	e.Operation = payload.Operation
+	e.HTTPRequest = payload.HTTPRequest
+	return e
+}
+
+// cloudSeverity returns the Cloud Logging name of a severity, which calls WARN as WARNING
+// and has no TRACE
+func cloudSeverity(s string) string {
+	switch s {
+	case TRACE.String():
+		return "DEBUG"
+	case WARN.String():
+		return "WARNING"
+	}
+	if _, ok := logLevelValue[s]; !ok {
+		return ""
+	}
+	return s
+}
+
+// detect fills the project, log name and resource not set by the user
+func (w *CloudLoggingWriter) detect() error {
+	if w.detected {
+		return nil
+	}
+
+	if w.ProjectID == "" {
+		id, err := detectProjectID()
+		if err != nil {
+			return err
+		}
+		w.ProjectID = id
+	}
+
+	if w.LogName == "" {
+		configured()
+		w.LogName = service
+	}
+	if w.LogName == "" {
+		w.LogName = "app"
+	}
+
+	if w.Resource == nil {
+		w.Resource = detectResource(os.Getenv, metadataValue)
+	} else {
+		// The resource of the user may be shared, add the project to a copy
+		r := *w.Resource
+		w.Resource = &r
+	}
+	w.Resource.Labels = withProjectLabel(w.Resource.Labels, w.ProjectID)
+
+	w.detected = true
+	return nil
+}
+
+func (w *CloudLoggingWriter) post(body []byte) error {
+	endpoint := w.Endpoint
+	if endpoint == "" {
+		endpoint = defaultCloudLoggingEndpoint
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(endpoint, "/")+"/v2/entries:write", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		token, err := w.token.get()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		client = apiClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("logger: cloud logging API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// detectProjectID returns the project of the GOOGLE_CLOUD_PROJECT environment variable, or the
+// one of the metadata server
+func detectProjectID() (string, error) {
+	if id := os.Getenv("GOOGLE_CLOUD_PROJECT"); id != "" {
+		return id, nil
+	}
+
+	id, err := metadataValue("project/project-id")
+	if err != nil {
+		return "", fmt.Errorf("logger: cannot detect the GCP project: %s", err.Error())
+	}
+	return id, nil
+}
+
+// metadataToken caches the access token of the default service account. It is not safe for
+// concurrent use
+type metadataToken struct {
+	token  string
+	expiry time.Time
+}
+
+// get returns the cached access token, requesting a new one from the metadata server when it expired
+func (t *metadataToken) get() (string, error) {
+	if t.token != "" && time.Now().Before(t.expiry) {
+		return t.token, nil
+	}
+
+	value, err := metadataValue("instance/service-accounts/default/token")
+	if err != nil {
+		return "", fmt.Errorf("logger: cannot get an access token: %s", err.Error())
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(value), &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("logger: empty access token from the metadata server")
+	}
+
+	// Renew the token a minute before it expires
+	t.token = token.AccessToken
+	t.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return t.token, nil
+}
+
+// withProjectLabel returns a copy of the labels with the project_id label, unless already set
+func withProjectLabel(labels map[string]string, projectID string) map[string]string {
+	l := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		l[k] = v
+	}
+	if l["project_id"] == "" {
+		l["project_id"] = projectID
+	}
+	return l
+}
+
+// detectResource guesses the monitored resource from the environment variables set by the GCP runtimes
+func detectResource(getenv func(string) string, metadata func(string) (string, error)) *MonitoredResource {
+	region := func() string {
+		// projects/123/regions/us-central1
+		r, _ := metadata("instance/region")
+		return r[strings.LastIndex(r, "/")+1:]
+	}
+	zone := func() string {
+		// projects/123/zones/us-central1-a
+		z, _ := metadata("instance/zone")
+		return z[strings.LastIndex(z, "/")+1:]
+	}
+
+	switch {
+	case getenv("FUNCTION_TARGET") != "" && getenv("K_SERVICE") != "":
+		return &MonitoredResource{
+			Type: "cloud_function",
+			Labels: map[string]string{
+				"function_name": getenv("K_SERVICE"),
+				"region":        region(),
+			},
+		}
+	case getenv("K_SERVICE") != "":
+		return &MonitoredResource{
+			Type: "cloud_run_revision",
+			Labels: map[string]string{
+				"service_name":       getenv("K_SERVICE"),
+				"revision_name":      getenv("K_REVISION"),
+				"configuration_name": getenv("K_CONFIGURATION"),
+				"location":           region(),
+			},
+		}
+	case getenv("GAE_SERVICE") != "":
+		return &MonitoredResource{
+			Type: "gae_app",
+			Labels: map[string]string{
+				"module_id":  getenv("GAE_SERVICE"),
+				"version_id": getenv("GAE_VERSION"),
+			},
+		}
+	case getenv("KUBERNETES_SERVICE_HOST") != "":
+		cluster, _ := metadata("instance/attributes/cluster-name")
+		location, _ := metadata("instance/attributes/cluster-location")
+		hostname, _ := os.Hostname()
+		return &MonitoredResource{
+			Type: "k8s_container",
+			Labels: map[string]string{
+				"cluster_name":   cluster,
+				"location":       location,
+				"namespace_name": getenv("NAMESPACE"),
+				"pod_name":       hostname,
+				"container_name": getenv("CONTAINER_NAME"),
+			},
+		}
+	}
+
+	if id, err := metadata("instance/id"); err == nil {
+		return &MonitoredResource{
+			Type: "gce_instance",
+			Labels: map[string]string{
+				"instance_id": id,
+				"zone":        zone(),
+			},
+		}
+	}
+	return &MonitoredResource{Type: "global"}
+}
+
+// metadataClient has a short timeout so the detection does not hang outside GCP
+var metadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// apiClient calls the Google APIs when no client is set. The writers hold their lock during the
+// calls, so a hung connection must not block the logging calls for long
+var apiClient = &http.Client{Timeout: 10 * time.Second}
+
+// metadataValue queries the GCE metadata server, e.g. metadataValue("project/project-id")
+func metadataValue(path string) (string, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %s for %s", resp.Status, path)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	return strings.TrimSpace(string(b)), err
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCloudLoggingWriter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	var got cloudWriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/entries:write" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("cannot decode the request: %s", err.Error())
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	// The resource may be shared with other writers, it must not be modified
+	resource := &MonitoredResource{Type: "global", Labels: map[string]string{"zone": "us-east1-b"}}
+
+	insertID := func() string { return "my-id" }
+	log := New(WithInsertID(insertID)).WithOutput(&CloudLoggingWriter{
+		ProjectID: "my-project",
+		LogName:   "my/log",
+		Resource:  resource,
+		Client:    server.Client(),
+		Endpoint:  server.URL,
+	})
+	log.Warn("WARN message")
+
+	if len(got.Entries) != 1 {
+		t.Fatalf("expected 1 entry; got %d", len(got.Entries))
+	}
+
+	e := got.Entries[0]
+	if e.LogName != "projects/my-project/logs/my%2Flog" {
+		t.Errorf("unexpected log name %s", e.LogName)
+	}
+	if e.Severity != "WARNING" {
+		t.Errorf("unexpected severity %s", e.Severity)
+	}
+	if e.InsertID != "my-id" {
+		t.Errorf("unexpected insertId %s", e.InsertID)
+	}
+	if e.Resource.Type != "global" || e.Resource.Labels["project_id"] != "my-project" || e.Resource.Labels["zone"] != "us-east1-b" {
+		t.Errorf("unexpected resource %+v", e.Resource)
+	}
+	if _, ok := resource.Labels["project_id"]; ok {
+		t.Errorf("the labels of the resource were modified: %v", resource.Labels)
+	}
+
+	p := Payload{}
+	if err := json.Unmarshal(e.JSONPayload, &p); err != nil {
+		t.Fatalf("jsonPayload cannot be unmarshalled: %s", err.Error())
+	}
+	if p.Message != "WARN message" || e.Timestamp != p.EventTime {
+		t.Errorf("unexpected payload %s", e.JSONPayload)
+	}
+}
+
+func TestCloudLoggingWriterBatch(t *testing.T) {
+	var got cloudWriteRequest
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	w := &CloudLoggingWriter{
+		ProjectID: "my-project",
+		LogName:   "app",
+		Resource:  &MonitoredResource{Type: "global"},
+		Client:    server.Client(),
+		Endpoint:  server.URL,
+	}
+	w.Write([]byte("{\"severity\":\"INFO\",\"message\":\"first\"}\n{\"severity\":\"WARN\",\"message\":\"second\"}\n"))
+
+	if requests != 1 || len(got.Entries) != 2 {
+		t.Fatalf("expected 2 entries in 1 request; got %d entries in %d requests", len(got.Entries), requests)
+	}
+	if got.Entries[0].Severity != "INFO" || got.Entries[1].Severity != "WARNING" {
+		t.Errorf("unexpected severities %s and %s", got.Entries[0].Severity, got.Entries[1].Severity)
+	}
+}
+
+func TestCloudLoggingWriterAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	w := &CloudLoggingWriter{
+		ProjectID: "my-project",
+		Resource:  &MonitoredResource{Type: "global"},
+		Client:    server.Client(),
+		Endpoint:  server.URL,
+	}
+	if _, err := w.Write([]byte("text entry\n")); err == nil {
+		t.Errorf("expected an error when the API fails")
+	}
+}
+
+func TestDetectResource(t *testing.T) {
+	metadata := func(path string) (string, error) {
+		switch path {
+		case "instance/region":
+			return "projects/123/regions/us-central1", nil
+		case "instance/zone":
+			return "projects/123/zones/us-central1-a", nil
+		case "instance/id":
+			return "42", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	env := map[string]string{"K_SERVICE": "api", "K_REVISION": "api-00001", "K_CONFIGURATION": "api"}
+	r := detectResource(func(k string) string { return env[k] }, metadata)
+	if r.Type != "cloud_run_revision" || r.Labels["service_name"] != "api" || r.Labels["location"] != "us-central1" {
+		t.Errorf("unexpected Cloud Run resource %+v", r)
+	}
+
+	r = detectResource(func(string) string { return "" }, metadata)
+	if r.Type != "gce_instance" || r.Labels["instance_id"] != "42" || r.Labels["zone"] != "us-central1-a" {
+		t.Errorf("unexpected GCE resource %+v", r)
+	}
+
+	r = detectResource(func(string) string { return "" }, func(string) (string, error) { return "", errors.New("not on GCP") })
+	if r.Type != "global" {
+		t.Errorf("unexpected resource outside GCP %+v", r)
+	}
+}
@@ -0,0 +1,164 @@
+// Command logpretty renders the JSON output of the logger as colored, aligned lines for local
+// development:
+//
+//	go run ./cmd/server | logpretty -level WARN -field user=+1234567890
+//	logpretty -no-color app.log
+//
+// The lines that are not log entries are printed unchanged. The colors are disabled by -no-color
+// or the NO_COLOR environment variable.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/teltech/logger"
+)
+
+// fieldFilters is the repeatable -field flag
+type fieldFilters map[string]string
+
+func (f fieldFilters) String() string {
+	var s []string
+	for k, v := range f {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f fieldFilters) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	f[s[:i]] = s[i+1:]
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run is the command with its arguments and streams, returning the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fields := fieldFilters{}
+	flags := flag.NewFlagSet("logpretty", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	level := flags.String("level", "TRACE", "minimum severity of the entries shown")
+	noColor := flags.Bool("no-color", os.Getenv("NO_COLOR") != "", "disable the colors")
+	noStack := flags.Bool("no-stack", false, "hide the stacktraces")
+	flags.Var(fields, "field", "show only the entries with the context field key=value, can be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: logpretty [flags] [file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := logger.ParseLevel(*level); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	in := stdin
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	enc := logger.ConsoleEncoder{NoColor: *noColor}
+
+	r := bufio.NewReader(in)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			render(out, enc, line, *level, fields, !*noStack)
+		}
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+}
+
+// render prints a line as a console entry when it is a log entry matching the filters, and
+// unchanged when it is not a log entry
+func render(out io.Writer, enc logger.ConsoleEncoder, line []byte, min string, fields fieldFilters, stack bool) {
+	p, ok := parse(line)
+	if !ok {
+		out.Write(line)
+		if line[len(line)-1] != '\n' {
+			out.Write([]byte{'\n'})
+		}
+		return
+	}
+
+	if !matches(p, min, fields) {
+		return
+	}
+
+	b, err := enc.Encode(p)
+	if err != nil {
+		out.Write(line)
+		return
+	}
+	out.Write(bytes.TrimRight(b, "\n"))
+	out.Write([]byte{'\n'})
+	if stack && p.Stacktrace != "" {
+		for _, l := range strings.Split(strings.TrimRight(p.Stacktrace, "\n"), "\n") {
+			fmt.Fprintf(out, "    %s\n", l)
+		}
+	}
+}
+
+// parse decodes a log entry, reporting whether the line is one
+func parse(line []byte) (*logger.Payload, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	p := &logger.Payload{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(p); err != nil || p.Severity == "" {
+		return nil, false
+	}
+	return p, true
+}
+
+// matches tells whether an entry has at least the minimum severity and all the filtered fields
+func matches(p *logger.Payload, min string, fields fieldFilters) bool {
+	m, _ := logger.ParseLevel(min)
+	if lvl, err := logger.ParseLevel(p.Severity); err == nil && lvl < m {
+		return false
+	}
+
+	for k, want := range fields {
+		if p.Context == nil {
+			return false
+		}
+		v, ok := p.Context.Data[k]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/teltech/logger"
+)
+
+func TestRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log := logger.New(logger.WithLevel(logger.TRACE), logger.WithWriter(buf))
+	log.With(logger.Fields{"user": "alice"}).WithOutput(buf).Debug("debug message")
+	log.With(logger.Fields{"user": "bob", "attempt": 2}).WithOutput(buf).Warn("warn message")
+	buf.WriteString("not a log entry\n")
+	log.With(logger.Fields{"user": "alice", "attempt": 2}).WithOutput(buf).Error("error message")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "all",
+			args: []string{"-no-color"},
+			want: []string{"debug message", "warn message", "not a log entry", "error message", "\n    "},
+		},
+		{
+			name:    "level",
+			args:    []string{"-no-color", "-level", "warn", "-no-stack"},
+			want:    []string{"warn message", "error message"},
+			notWant: []string{"debug message", "\n    "},
+		},
+		{
+			name:    "fields",
+			args:    []string{"-no-color", "-field", "user=alice", "-field", "attempt=2"},
+			want:    []string{"error message"},
+			notWant: []string{"debug message", "warn message"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+			if code := run(tt.args, bytes.NewReader(buf.Bytes()), out, errOut); code != 0 {
+				t.Fatalf("exit code %d: %s", code, errOut)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("output %q does not contain %q", out, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out.String(), s) {
+					t.Errorf("output %q contains %q", out, s)
+				}
+			}
+			if strings.Contains(out.String(), "\x1b[") {
+				t.Errorf("output %q is colored", out)
+			}
+		})
+	}
+}
+
+func TestRunInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"-level", "LOUD"}, {"-field", "user"}} {
+		if code := run(args, strings.NewReader(""), new(bytes.Buffer), new(bytes.Buffer)); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Command logquery filters the JSON log files written by the logger by severity, time range,
+// message and context fields, and prints the matching entries as JSON lines, as a table or as
+// counts per minute and severity:
+//
+//	logquery -level ERROR -since 2h -grep 'timeout|refused' app.log
+//	logquery -field user=+1234567890 -since 2024-05-01T10:00:00Z -until 2024-05-01T11:00:00Z -format table app.log
+//	logquery -format stats app.log app.log.1
+//
+// The files are read from the arguments, or stdin when there is none. The lines that are not
+// log entries are skipped.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/teltech/logger"
+)
+
+// levels are the severities in increasing order, the columns of the stats
+var levels = []string{
+	logger.TRACE.String(),
+	logger.DEBUG.String(),
+	logger.INFO.String(),
+	logger.WARN.String(),
+	logger.ERROR.String(),
+	logger.CRITICAL.String(),
+}
+
+// fieldFilters is the repeatable -field flag
+type fieldFilters map[string]string
+
+func (f fieldFilters) String() string {
+	var s []string
+	for k, v := range f {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f fieldFilters) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	f[s[:i]] = s[i+1:]
+	return nil
+}
+
+// query is the filter of the entries
+type query struct {
+	level  string
+	since  time.Time
+	until  time.Time
+	grep   *regexp.Regexp
+	fields fieldFilters
+}
+
+// output prints the matching entries
+type output interface {
+	add(line []byte, p *logger.Payload)
+	flush(w io.Writer) error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, time.Now()))
+}
+
+// run is the command with its arguments, streams and current time, returning the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, now time.Time) int {
+	q := query{fields: fieldFilters{}}
+	flags := flag.NewFlagSet("logquery", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&q.level, "level", "TRACE", "minimum severity of the entries")
+	since := flags.String("since", "", "entries at or after a RFC 3339 time, or a duration before now such as 2h")
+	until := flags.String("until", "", "entries before a RFC 3339 time, or a duration before now such as 30m")
+	grep := flags.String("grep", "", "entries with a message matching the regular expression")
+	format := flags.String("format", "json", "output format: json, table or stats")
+	flags.Var(q.fields, "field", "entries with the context field key=value, can be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: logquery [flags] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	if _, err = logger.ParseLevel(q.level); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if q.since, err = parseTime(*since, now); err != nil {
+		fmt.Fprintln(stderr, "-since:", err)
+		return 2
+	}
+	if q.until, err = parseTime(*until, now); err != nil {
+		fmt.Fprintln(stderr, "-until:", err)
+		return 2
+	}
+	if *grep != "" {
+		if q.grep, err = regexp.Compile(*grep); err != nil {
+			fmt.Fprintln(stderr, "-grep:", err)
+			return 2
+		}
+	}
+
+	var out output
+	switch *format {
+	case "json":
+		out = &jsonOutput{w: bufio.NewWriter(stdout)}
+	case "table":
+		out = &tableOutput{}
+	case "stats":
+		out = &statsOutput{counts: make(map[string]map[string]int)}
+	default:
+		fmt.Fprintf(stderr, "-format: unknown format %q\n", *format)
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		if err := scan(name, stdin, q, out); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	if err := out.flush(stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// parseTime parses a RFC 3339 time or a duration before now, the zero time when s is empty
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// scan adds the entries of a file matching the query to the output, "-" being stdin
+func scan(name string, stdin io.Reader, q query, out output) error {
+	in := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r := bufio.NewReader(in)
+	for {
+		line, err := r.ReadBytes('\n')
+		if p, ok := parse(line); ok && q.matches(p) {
+			out.add(bytes.TrimRight(line, "\r\n"), p)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+// parse decodes a log entry, reporting whether the line is one
+func parse(line []byte) (*logger.Payload, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	p := &logger.Payload{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(p); err != nil || p.Severity == "" {
+		return nil, false
+	}
+	return p, true
+}
+
+// matches tells whether an entry matches all the conditions of the query
+func (q query) matches(p *logger.Payload) bool {
+	min, _ := logger.ParseLevel(q.level)
+	if lvl, err := logger.ParseLevel(p.Severity); err == nil && lvl < min {
+		return false
+	}
+
+	if !q.since.IsZero() || !q.until.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, p.EventTime)
+		if err != nil {
+			return false
+		}
+		if !q.since.IsZero() && t.Before(q.since) {
+			return false
+		}
+		if !q.until.IsZero() && !t.Before(q.until) {
+			return false
+		}
+	}
+
+	if q.grep != nil && !q.grep.MatchString(p.Message) {
+		return false
+	}
+
+	for k, want := range q.fields {
+		if p.Context == nil {
+			return false
+		}
+		v, ok := p.Context.Data[k]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonOutput prints the matching lines unchanged
+type jsonOutput struct {
+	w *bufio.Writer
+}
+
+func (o *jsonOutput) add(line []byte, _ *logger.Payload) {
+	o.w.Write(line)
+	o.w.WriteByte('\n')
+}
+
+func (o *jsonOutput) flush(io.Writer) error {
+	return o.w.Flush()
+}
+
+// tableOutput prints the time, severity, message and context fields of the entries in aligned columns
+type tableOutput struct {
+	rows []*logger.Payload
+}
+
+func (o *tableOutput) add(_ []byte, p *logger.Payload) {
+	o.rows = append(o.rows, p)
+}
+
+func (o *tableOutput) flush(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tSEVERITY\tMESSAGE\tFIELDS")
+	for _, p := range o.rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.EventTime, p.Severity, oneLine(p.Message), fields(p))
+	}
+	return tw.Flush()
+}
+
+// fields formats the context fields of an entry as sorted key=value pairs
+func fields(p *logger.Payload) string {
+	if p.Context == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(p.Context.Data))
+	for k := range p.Context.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + oneLine(fmt.Sprint(p.Context.Data[k]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// oneLine keeps the table rows on a single line
+func oneLine(s string) string {
+	return strings.NewReplacer("\n", `\n`, "\t", `\t`).Replace(s)
+}
+
+// statsOutput counts the entries per minute and severity
+type statsOutput struct {
+	counts map[string]map[string]int
+}
+
+func (o *statsOutput) add(_ []byte, p *logger.Payload) {
+	minute := "unknown"
+	if t, err := time.Parse(time.RFC3339Nano, p.EventTime); err == nil {
+		minute = t.UTC().Truncate(time.Minute).Format("2006-01-02T15:04Z")
+	}
+	if o.counts[minute] == nil {
+		o.counts[minute] = make(map[string]int)
+	}
+	o.counts[minute][p.Severity]++
+}
+
+func (o *statsOutput) flush(w io.Writer) error {
+	minutes := make([]string, 0, len(o.counts))
+	for m := range o.counts {
+		minutes = append(minutes, m)
+	}
+	sort.Strings(minutes)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "MINUTE\t%s\tTOTAL\t\n", strings.Join(levels, "\t"))
+	totals := make(map[string]int)
+	for _, m := range minutes {
+		fmt.Fprintf(tw, "%s\t%s\n", m, counts(o.counts[m], totals))
+	}
+	fmt.Fprintf(tw, "TOTAL\t%s\n", counts(totals, nil))
+	return tw.Flush()
+}
+
+// counts formats the counts per severity of a minute followed by their sum, adding them to the totals
+func counts(c map[string]int, totals map[string]int) string {
+	var b strings.Builder
+	sum := 0
+	for _, lvl := range levels {
+		fmt.Fprintf(&b, "%d\t", c[lvl])
+		sum += c[lvl]
+		if totals != nil {
+			totals[lvl] += c[lvl]
+		}
+	}
+	fmt.Fprintf(&b, "%d\t", sum)
+	return b.String()
+}
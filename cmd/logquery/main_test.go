@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLogs = `{"severity":"INFO","eventTime":"2024-05-01T10:00:10Z","message":"request served","context":{"data":{"user":"alice","status":200}}}
+{"severity":"WARN","eventTime":"2024-05-01T10:00:40Z","message":"retrying","context":{"data":{"user":"bob","attempt":2}}}
+not a log entry
+{"severity":"ERROR","eventTime":"2024-05-01T10:01:05Z","message":"connection refused","context":{"data":{"user":"alice","attempt":3}}}
+{"severity":"ERROR","eventTime":"2024-05-01T10:02:30Z","message":"request timeout","context":{"data":{"user":"bob"}}}
+`
+
+var testNow = time.Date(2024, 5, 1, 10, 3, 0, 0, time.UTC)
+
+func runQuery(t *testing.T, args ...string) string {
+	t.Helper()
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	if code := run(args, strings.NewReader(testLogs), out, errOut, testNow); code != 0 {
+		t.Fatalf("exit code %d: %s", code, errOut)
+	}
+	return out.String()
+}
+
+func messages(out string) []string {
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if p, ok := parse([]byte(line)); ok {
+			msgs = append(msgs, p.Message)
+		}
+	}
+	return msgs
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"all", nil, []string{"request served", "retrying", "connection refused", "request timeout"}},
+		{"level", []string{"-level", "warn"}, []string{"retrying", "connection refused", "request timeout"}},
+		{"since", []string{"-since", "2024-05-01T10:00:40Z"}, []string{"retrying", "connection refused", "request timeout"}},
+		{"until", []string{"-until", "2024-05-01T10:01:05Z"}, []string{"request served", "retrying"}},
+		{"relative", []string{"-since", "2m30s", "-until", "1m"}, []string{"retrying", "connection refused"}},
+		{"grep", []string{"-grep", "timeout|refused"}, []string{"connection refused", "request timeout"}},
+		{"fields", []string{"-field", "user=alice", "-field", "attempt=3"}, []string{"connection refused"}},
+		{"none", []string{"-field", "user=carol"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messages(runQuery(t, tt.args...))
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONOutputKeepsLines(t *testing.T) {
+	out := runQuery(t, "-grep", "retrying")
+	want := strings.Split(testLogs, "\n")[1] + "\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestTableOutput(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(runQuery(t, "-format", "table", "-level", "ERROR")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), lines)
+	}
+	if !strings.HasPrefix(lines[0], "TIME") || !strings.Contains(lines[0], "SEVERITY  MESSAGE") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "ERROR     connection refused  attempt=3 user=alice") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+}
+
+func TestStatsOutput(t *testing.T) {
+	out := runQuery(t, "-format", "stats")
+	fields := func(line string) string { return strings.Join(strings.Fields(line), " ") }
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{
+		"MINUTE TRACE DEBUG INFO WARN ERROR CRITICAL TOTAL",
+		"2024-05-01T10:00Z 0 0 1 1 0 0 2",
+		"2024-05-01T10:01Z 0 0 0 0 1 0 1",
+		"2024-05-01T10:02Z 0 0 0 0 1 0 1",
+		"TOTAL 0 0 1 1 2 0 4",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q, want %q", lines, want)
+	}
+	for i := range want {
+		if fields(lines[i]) != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, fields(lines[i]), want[i])
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	if err := os.WriteFile(a, []byte(testLogs), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(testLogs), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := messages(runQuery(t, "-level", "ERROR", a, b)); len(got) != 4 {
+		t.Errorf("got %q, want the errors of both files", got)
+	}
+
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	if code := run([]string{filepath.Join(dir, "missing.log")}, nil, out, errOut, testNow); code != 1 {
+		t.Errorf("exit code %d for a missing file, want 1", code)
+	}
+}
+
+func TestInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-level", "LOUD"},
+		{"-since", "yesterday"},
+		{"-grep", "("},
+		{"-format", "xml"},
+		{"-field", "user"},
+	} {
+		if code := run(args, strings.NewReader(""), new(bytes.Buffer), new(bytes.Buffer), testNow); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Config is the logging configuration of a JSON or YAML file read by LoadConfig, e.g.
+//
+//	{
+//		"level": "INFO",
+//		"modules": {"db": "DEBUG", "http": "WARN"},
+//		"encoding": "json",
+//		"outputs": ["stdout", "/var/log/app.log"],
+//		"sampling": {"tick": "1s", "first": 100, "thereafter": 100},
+//		"redaction": {"keys": ["password"], "patterns": ["(?i)token$"]}
+//	}
+//
+// or the same configuration in YAML:
+//
+//	level: INFO
+//	modules: {db: DEBUG, http: WARN}
+//	encoding: json
+//	outputs:
+//	  - stdout
+//	  - /var/log/app.log
+//	sampling: {tick: 1s, first: 100, thereafter: 100}
+//	redaction:
+//	  keys: [password]
+//	  patterns: ["(?i)token$"]
+//
+// The YAML files are read without a dependency, so only the block and single line flow collections,
+// the scalars and the comments are supported, not the block scalars, anchors, aliases and tags.
+type Config struct {
+	// Level is the global level, e.g. "INFO"
+	Level string `json:"level,omitempty"`
+	// Modules are the levels of the named loggers, see SetNamedLevels
+	Modules map[string]string `json:"modules,omitempty"`
+	// Encoding is the format of the entries: "json" (the default), "console" or "logfmt"
+	Encoding string `json:"encoding,omitempty"`
+	// Outputs are "stdout" (the default), "stderr" or the paths of files the entries are appended to
+	Outputs []string `json:"outputs,omitempty"`
+	// Sampling configures WithSampling, disabled when nil
+	Sampling *SamplingConfig `json:"sampling,omitempty"`
+	// Redaction configures WithRedactor, disabled when nil
+	Redaction *RedactionConfig `json:"redaction,omitempty"`
+}
+
+// SamplingConfig is the sampling of a Config, see WithSampling
+type SamplingConfig struct {
+	// Tick is a duration such as "1s"
+	Tick       string `json:"tick"`
+	First      int    `json:"first"`
+	Thereafter int    `json:"thereafter"`
+}
+
+// RedactionConfig is the redaction of a Config, see Redactor
+type RedactionConfig struct {
+	Keys []string `json:"keys,omitempty"`
+	// Patterns are regular expressions matching the sensitive field names
+	Patterns []string `json:"patterns,omitempty"`
+	Hash     bool     `json:"hash,omitempty"`
+}
+
+// LoadConfig reads a configuration file, in YAML when its extension is .yaml or .yml and in JSON
+// otherwise
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		if b, err = yamlToJSON(b); err != nil {
+			return nil, fmt.Errorf("logger: invalid configuration %s: %s", path, err.Error())
+		}
+	}
+
+	c := &Config{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("logger: invalid configuration %s: %s", path, err.Error())
+	}
+	return c, nil
+}
+
+// Apply sets the global level and the levels of the named loggers, which take effect immediately
+// on all the loggers
+func (c *Config) Apply() error {
+	if c.Level != "" {
+		lvl, err := ParseLevel(c.Level)
+		if err != nil {
+			return err
+		}
+		SetLevel(lvl)
+	}
+
+	modules := make([]string, 0, len(c.Modules))
+	for name, level := range c.Modules {
+		modules = append(modules, name+"="+level)
+	}
+	return SetNamedLevels(strings.Join(modules, ","))
+}
+
+// Options returns the options creating a logger with the encoding, outputs, sampling and redaction
+// of the configuration. The output files are opened, Close the logger to close them
+func (c *Config) Options() ([]Option, error) {
+	var opts []Option
+
+	switch c.Encoding {
+	case "", "json":
+	case "console":
+		opts = append(opts, WithConsoleOutput())
+	case "logfmt":
+		opts = append(opts, WithLogfmtOutput())
+	default:
+		return nil, fmt.Errorf("logger: unknown encoding %q", c.Encoding)
+	}
+
+	if len(c.Outputs) > 0 {
+		w, err := openOutputs(c.Outputs)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithWriter(w))
+	}
+
+	if s := c.Sampling; s != nil {
+		tick, err := time.ParseDuration(s.Tick)
+		if err != nil {
+			return nil, fmt.Errorf("logger: invalid sampling tick: %s", err.Error())
+		}
+		opts = append(opts, WithSampling(tick, s.First, s.Thereafter))
+	}
+
+	if r := c.Redaction; r != nil {
+		redactor := &Redactor{Keys: r.Keys, Hash: r.Hash}
+		for _, p := range r.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("logger: invalid redaction pattern: %s", err.Error())
+			}
+			redactor.Patterns = append(redactor.Patterns, re)
+		}
+		opts = append(opts, WithRedactor(redactor))
+	}
+
+	return opts, nil
+}
+
+// openOutputs returns a writer to the outputs of a Config
+func openOutputs(outputs []string) (io.Writer, error) {
+	writers := make([]io.Writer, 0, len(outputs))
+	for _, o := range outputs {
+		switch o {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		default:
+			f, err := os.OpenFile(o, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			if err != nil {
+				for _, w := range writers {
+					closeWriter(w)
+				}
+				return nil, err
+			}
+			writers = append(writers, f)
+		}
+	}
+
+	if len(writers) == 1 {
+		return writers[0], nil
+	}
+	return NewMultiWriter(writers...), nil
+}
+
+// WatchConfig reloads the configuration file when the process receives SIGHUP, and when the file
+// is modified if interval is positive, checking its modification time at every interval. Only the
+// levels of a reloaded configuration take effect on the existing loggers, they are applied before
+// onReload is called with the configuration, or with the error that prevented the reload. The
+// encoding, outputs, sampling and redaction are fixed when a logger is created, they only take
+// effect on the loggers onReload creates with the new Options. The returned function stops watching
+func WatchConfig(path string, interval time.Duration, onReload func(c *Config, err error)) (stop func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	modTime := func() time.Time {
+		if fi, err := os.Stat(path); err == nil {
+			return fi.ModTime()
+		}
+		return time.Time{}
+	}
+	reload := func() {
+		c, err := LoadConfig(path)
+		if err == nil {
+			err = c.Apply()
+		}
+		if onReload != nil {
+			onReload(c, err)
+		}
+	}
+
+	done := make(chan struct{})
+	last := modTime()
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				last = modTime()
+				reload()
+			case <-tick:
+				if m := modTime(); !m.Equal(last) {
+					last = m
+					reload()
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(hup)
+			if ticker != nil {
+				ticker.Stop()
+			}
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+	defer SetNamedLevels("")
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "app.log")
+	path := filepath.Join(dir, "logger.json")
+	config := `{
+		"level": "warn",
+		"modules": {"db": "DEBUG"},
+		"encoding": "logfmt",
+		"outputs": ["` + filepath.ToSlash(out) + `"],
+		"sampling": {"tick": "1s", "first": 1, "thereafter": 0},
+		"redaction": {"keys": ["password"], "patterns": ["(?i)token$"]}
+	}`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("cannot write the configuration: %s", err.Error())
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("cannot load the configuration: %s", err.Error())
+	}
+	if err := c.Apply(); err != nil {
+		t.Fatalf("cannot apply the configuration: %s", err.Error())
+	}
+	opts, err := c.Options()
+	if err != nil {
+		t.Fatalf("cannot build the options: %s", err.Error())
+	}
+
+	log := New(opts...)
+	log.Info("INFO message")
+	log.With(Fields{"password": "secret", "authToken": "abc"}).WithOutput(log.writer).Warn("WARN message")
+	log.Named("db").Debug("DEBUG message")
+	log.Named("db").Debug("DEBUG message")
+	if err := log.Close(); err != nil {
+		t.Fatalf("cannot close the logger: %s", err.Error())
+	}
+
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("cannot read the output: %s", err.Error())
+	}
+	got := string(b)
+	if strings.Contains(got, "INFO message") || strings.Count(got, "DEBUG message") != 1 {
+		t.Errorf("output %s does not respect the levels and the sampling", got)
+	}
+	if !strings.Contains(got, `level=WARN msg="WARN message" authToken=[REDACTED] password=[REDACTED]`) {
+		t.Errorf("output %s does not contain the redacted logfmt entry", got)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+	config := `# The logging configuration
+level: warn
+modules: {db: DEBUG}
+outputs:
+  - stderr
+sampling:
+  tick: 1s
+  first: 1
+  thereafter: 0
+redaction:
+  keys: [password]
+  patterns: ["(?i)token$"]
+  hash: true
+`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("cannot write the configuration: %s", err.Error())
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("cannot load the configuration: %s", err.Error())
+	}
+	if c.Level != "warn" || c.Modules["db"] != "DEBUG" || len(c.Outputs) != 1 || c.Outputs[0] != "stderr" {
+		t.Errorf("unexpected configuration %+v", c)
+	}
+	if s := c.Sampling; s == nil || s.Tick != "1s" || s.First != 1 || s.Thereafter != 0 {
+		t.Errorf("unexpected sampling %+v", s)
+	}
+	if r := c.Redaction; r == nil || len(r.Keys) != 1 || len(r.Patterns) != 1 || r.Patterns[0] != "(?i)token$" || !r.Hash {
+		t.Errorf("unexpected redaction %+v", r)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("level: [INFO"), 0644); err != nil {
+		t.Fatalf("cannot write the configuration: %s", err.Error())
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Errorf("expected an error for an invalid YAML configuration")
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	configs := []*Config{
+		{Encoding: "xml"},
+		{Sampling: &SamplingConfig{Tick: "often"}},
+		{Redaction: &RedactionConfig{Patterns: []string{"("}}},
+		{Outputs: []string{filepath.Join(t.TempDir(), "missing", "app.log")}},
+	}
+	for _, c := range configs {
+		if _, err := c.Options(); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+
+	if err := (&Config{Level: "verbose"}).Apply(); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	path := filepath.Join(t.TempDir(), "logger.json")
+	if err := ioutil.WriteFile(path, []byte(`{"level":"INFO"}`), 0644); err != nil {
+		t.Fatalf("cannot write the configuration: %s", err.Error())
+	}
+
+	reloaded := make(chan *Config, 1)
+	stop := WatchConfig(path, 10*time.Millisecond, func(c *Config, err error) {
+		if err != nil {
+			t.Errorf("cannot reload the configuration: %s", err.Error())
+		}
+		reloaded <- c
+	})
+	defer stop()
+
+	// Make sure the modification time changes
+	future := time.Now().Add(time.Minute)
+	if err := ioutil.WriteFile(path, []byte(`{"level":"ERROR"}`), 0644); err != nil {
+		t.Fatalf("cannot write the configuration: %s", err.Error())
+	}
+	os.Chtimes(path, future, future)
+
+	select {
+	case c := <-reloaded:
+		if c.Level != "ERROR" || GetLevel() != ERROR {
+			t.Errorf("expected the level ERROR; got %s", GetLevel())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the configuration was not reloaded")
+	}
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences used to color the severity in the console output
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorGray    = "\x1b[90m"
+)
+
+var consoleColor = map[string]string{
+	"TRACE":    colorGray,
+	"DEBUG":    colorBlue,
+	"INFO":     colorGreen,
+	"WARN":     colorYellow,
+	"ERROR":    colorRed,
+	"CRITICAL": colorMagenta,
+}
+
+// ConsoleEncoder encodes a payload as a human readable single line, meant for local development:
+// timestamp, severity, message and the context data as key=value pairs
+type ConsoleEncoder struct {
+	// NoColor disables the ANSI colors on the severity
+	NoColor bool
+}
+
+// Encode formats the payload as a single aligned line
+func (e ConsoleEncoder) Encode(p *Payload) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := e.encodeTo(buf, p)
+	return buf.Bytes(), err
+}
+
+func (e ConsoleEncoder) encodeTo(buf *bytes.Buffer, p *Payload) error {
+	buf.WriteString(p.EventTime)
+	buf.WriteByte(' ')
+
+	// Pad the severity to the longest level name so the messages are aligned
+	severity := fmt.Sprintf("%-8s", p.Severity)
+	if color, ok := consoleColor[p.Severity]; ok && !e.NoColor {
+		severity = color + severity + colorReset
+	}
+	buf.WriteString(severity)
+	buf.WriteByte(' ')
+	if p.Caller != "" {
+		buf.WriteString(p.Caller)
+		buf.WriteByte(' ')
+	}
+	writeConsoleMessage(buf, p.Message)
+
+	if p.Context != nil {
+		for _, k := range sortedKeys(p.Context.Data) {
+			buf.WriteByte(' ')
+			buf.WriteString(k)
+			buf.WriteByte('=')
+			buf.WriteString(formatValue(p.Context.Data[k]))
+		}
+
+		if loc := p.Context.ReportLocation; loc != nil {
+			fmt.Fprintf(buf, " location=%s:%d", loc.FilePath, loc.LineNumber)
+		}
+	}
+
+	return nil
+}
+
+// WithConsoleOutput sets a colored ConsoleEncoder as the log entries format
+func WithConsoleOutput() Option {
+	return WithEncoder(ConsoleEncoder{})
+}
+
+// formatValue renders a field value as a single token, quoting it when needed.
+// Composite values are rendered as compact JSON and are not quoted
+func formatValue(v interface{}) string {
+	s, composite := valueString(v)
+	if composite {
+		return s
+	}
+	return quoteValue(s)
+}
+
+// valueString returns the textual representation of a field value and whether it is a composite value
+func valueString(v interface{}) (string, bool) {
+	if conv, ok := convertFieldValue(v); ok {
+		v = conv
+	}
+
+	switch val := v.(type) {
+	case string:
+		return val, false
+	case error:
+		return val.Error(), false
+	case fmt.Stringer:
+		return val.String(), false
+	case nil:
+		return "null", false
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(val), false
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeJSONValue(buf, v); err == nil {
+		return buf.String(), true
+	}
+	return fmt.Sprintf("%+v", v), false
+}
+
+// writeConsoleMessage writes the message with its control characters escaped as in the quoted
+// field values, e.g. "\n", so an entry stays on a single line
+func writeConsoleMessage(buf *bytes.Buffer, msg string) {
+	if strings.IndexFunc(msg, isControl) == -1 {
+		buf.WriteString(msg)
+		return
+	}
+	for _, r := range msg {
+		if isControl(r) {
+			q := strconv.QuoteRune(r)
+			buf.WriteString(q[1 : len(q)-1])
+		} else {
+			buf.WriteRune(r)
+		}
+	}
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+// quoteValue quotes a string if it is empty or contains spaces, quotes, equal signs or control characters
+func quoteValue(s string) string {
+	if s == "" || strings.IndexFunc(s, needsQuoting) != -1 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuoting(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleEncoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New(WithEncoder(ConsoleEncoder{NoColor: true}), WithClock(testClock)).With(Fields{
+		"key":   "value",
+		"names": []string{"Mauricio", "Manuel"},
+		"text":  "with spaces",
+	}).WithOutput(buf)
+
+	log.Info("INFO message")
+	expected := fmt.Sprintf(`%s INFO     INFO message key=value names=["Mauricio","Manuel"] text="with spaces"`, testTime.Format(time.RFC3339Nano))
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestConsoleEncoderMultilineMessage(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithEncoder(ConsoleEncoder{NoColor: true}), WithClock(testClock)).WithOutput(buf)
+
+	log.Info("first line\n\tsecond line\x1b[31m")
+	expected := fmt.Sprintf(`%s INFO     first line\n\tsecond line\x1b[31m`, testTime.Format(time.RFC3339Nano)) + "\n"
+	if got := buf.String(); expected != got {
+		t.Errorf("output %q does not match expected string %q", got, expected)
+	}
+}
+
+func TestConsoleEncoderColors(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New(WithConsoleOutput()).WithOutput(buf)
+
+	log.Warn("WARN message")
+	expected := colorYellow + "WARN    " + colorReset + " WARN message"
+	got := strings.TrimRight(buf.String(), "\n")
+	if !strings.HasSuffix(got, expected) {
+		t.Errorf("output %q does not end with %q", got, expected)
+	}
+
+	// Clean-up the buffer in preparation for new assertions
+	buf.Reset()
+
+	log.Error("ERROR message")
+	got = strings.TrimRight(buf.String(), "\n")
+	if !strings.Contains(got, "location=") {
+		t.Errorf("output %s does not contain the report location", got)
+	}
+	if strings.Contains(got, "\n") {
+		t.Errorf("output %s spans more than one line", got)
+	}
+}
+
+func TestConsoleEncoderNestedValues(t *testing.T) {
+	p := &Payload{
+		Severity:  "INFO",
+		EventTime: "2017-04-26T02:29:33-04:00",
+		Message:   "INFO message",
+		Context: &Context{Data: Fields{
+			"request": map[string]interface{}{"path": "/users", "err": errors.New("timeout")},
+		}},
+	}
+
+	got, err := ConsoleEncoder{NoColor: true}.Encode(p)
+	if err != nil {
+		t.Fatalf("cannot encode payload: %s", err.Error())
+	}
+	if !strings.Contains(string(got), `request={"err":"timeout","path":"/users"}`) {
+		t.Errorf("output %s does not contain the nested value", got)
+	}
+}
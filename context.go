@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey struct{}
+
+var (
+	defaultMu  sync.RWMutex
+	defaultLog *Log
+)
+
+// NewContext returns a copy of ctx carrying the given Log, so request scoped loggers can be
+// passed down the call chain
+func NewContext(ctx context.Context, l *Log) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Log carried by ctx, or the default logger when there is none
+func FromContext(ctx context.Context) *Log {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Log); ok && l != nil {
+			return l
+		}
+	}
+	return Default()
+}
+
+// Default returns the package default logger, created with New on its first use
+func Default() *Log {
+	defaultMu.RLock()
+	l := defaultLog
+	defaultMu.RUnlock()
+	if l != nil {
+		return l
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultLog == nil {
+		defaultLog = New()
+	}
+	return defaultLog
+}
+
+// SetDefault replaces the package default logger returned by Default and FromContext
+func SetDefault(l *Log) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLog = l
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewContext(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New().With(Fields{"request": "abc"}).WithOutput(buf)
+
+	ctx := NewContext(context.Background(), log)
+	FromContext(ctx).Info("INFO message")
+
+	if !strings.Contains(buf.String(), `"context":{"data":{"request":"abc"}}`) {
+		t.Errorf("output %s does not contain the request context", buf.String())
+	}
+}
+
+func TestFromContextFallsBackToDefault(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	SetDefault(New().WithOutput(buf))
+	defer SetDefault(nil)
+
+	FromContext(context.Background()).Info("INFO message")
+	if !strings.Contains(buf.String(), `"message":"INFO message"`) {
+		t.Errorf("output %s does not contain the entry", buf.String())
+	}
+
+	if FromContext(nil) != Default() {
+		t.Errorf("expected the default logger for a nil context")
+	}
+}
+
+func TestDefaultIsCreatedOnFirstUse(t *testing.T) {
+	SetDefault(nil)
+
+	l := Default()
+	if l == nil {
+		t.Fatalf("expected a default logger")
+	}
+	if Default() != l {
+		t.Errorf("expected the same default logger on every call")
+	}
+}
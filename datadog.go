@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The limits of a request to the Datadog logs intake API
+const (
+	datadogMaxBody    = 5 << 20
+	datadogMaxEntries = 1000
+)
+
+// DatadogWriter is an io.Writer posting the log entries to the Datadog logs intake API. The
+// newline separated entries of a single Write, e.g. from a BatchWriter, are posted together, in
+// as many requests as needed to keep each one under the limits of the API: 5 MB and 1000 entries.
+// The JSON entries keep their fields as attributes, with the service, the status and the
+// ddsource, ddtags and hostname reserved attributes added; the version and the labels become
+// tags. Any other encoding is sent as the message.
+// The requests failing with a 429 or 5xx status, or a network error, are retried with an
+// exponential backoff, wrap it with NewAsyncWriter to keep it off the hot path.
+type DatadogWriter struct {
+	// APIKey is the Datadog API key, the DD_API_KEY environment variable by default
+	APIKey string
+	// Site is the Datadog site, e.g. "datadoghq.eu", the DD_SITE environment variable or
+	// "datadoghq.com" by default
+	Site string
+	// Source is the ddsource attribute, "go" by default
+	Source string
+	// Tags are added to the ddtags attribute of every entry, e.g. "env:prod"
+	Tags []string
+	// Hostname is the hostname attribute, os.Hostname() by default
+	Hostname string
+	// Client is the HTTP client used to call the API, http.DefaultClient by default
+	Client *http.Client
+	// Endpoint is the URL of the intake API, derived from the site by default
+	Endpoint string
+	// Attempts is the number of tries of a request, 3 by default
+	Attempts int
+	// Backoff is the delay before the first retry, doubled on each attempt, 1 second by default
+	Backoff time.Duration
+
+	once sync.Once
+}
+
+// WithDatadog posts the log entries to the Datadog logs intake API with the given API key
+func WithDatadog(apiKey string) Option {
+	return func(l *Log) {
+		l.writer = &DatadogWriter{APIKey: apiKey}
+	}
+}
+
+// Write posts each line of p as a log entry. When a request fails, the returned count is the
+// number of bytes of the lines posted by the previous requests
+func (w *DatadogWriter) Write(p []byte) (int, error) {
+	w.once.Do(w.init)
+
+	body := getBuffer()
+	defer putBuffer(body)
+
+	// posted is the end of the lines already posted, and end the end of the lines in body
+	posted, end, count := 0, 0, 0
+	flush := func() error {
+		if count == 0 {
+			return nil
+		}
+		body.WriteByte(']')
+		b := body.Bytes()
+		if err := retryPost(w.Attempts, w.Backoff, func() (bool, error) { return w.post(b) }); err != nil {
+			return err
+		}
+		body.Reset()
+		posted, count = end, 0
+		return nil
+	}
+
+	for start := 0; start < len(p); {
+		next := len(p)
+		if i := bytes.IndexByte(p[start:], '\n'); i >= 0 {
+			next = start + i + 1
+		}
+		line := bytes.TrimSuffix(p[start:next], []byte("\n"))
+		start = next
+		if len(line) == 0 {
+			continue
+		}
+
+		entry := w.entry(line)
+		// The size of the body with the entry, its separator and the closing bracket
+		if count == datadogMaxEntries || count > 0 && body.Len()+len(entry)+2 > datadogMaxBody {
+			if err := flush(); err != nil {
+				return posted, err
+			}
+		}
+		if count == 0 {
+			body.WriteByte('[')
+		} else {
+			body.WriteByte(',')
+		}
+		body.Write(entry)
+		count++
+		end = next
+	}
+
+	if err := flush(); err != nil {
+		return posted, err
+	}
+	return len(p), nil
+}
+
+// init fills the settings not set by the user
+func (w *DatadogWriter) init() {
+	if w.APIKey == "" {
+		w.APIKey = os.Getenv("DD_API_KEY")
+	}
+	if w.Site == "" {
+		w.Site = os.Getenv("DD_SITE")
+	}
+	if w.Site == "" {
+		w.Site = "datadoghq.com"
+	}
+	if w.Endpoint == "" {
+		w.Endpoint = "https://http-intake.logs." + w.Site + "/api/v2/logs"
+	}
+	if w.Source == "" {
+		w.Source = "go"
+	}
+	if w.Hostname == "" {
+		w.Hostname, _ = os.Hostname()
+	}
+	if w.Client == nil {
+		w.Client = http.DefaultClient
+	}
+	if w.Attempts <= 0 {
+		w.Attempts = 3
+	}
+	if w.Backoff <= 0 {
+		w.Backoff = time.Second
+	}
+}
+
+// entry builds the intake entry of an encoded payload
+func (w *DatadogWriter) entry(p []byte) json.RawMessage {
+	attrs := map[string]interface{}{}
+	payload := Payload{}
+	if err := json.Unmarshal(p, &attrs); err != nil || json.Unmarshal(p, &payload) != nil {
+		attrs = map[string]interface{}{"message": string(p)}
+	}
+
+	tags := append([]string(nil), w.Tags...)
+	if payload.ServiceContext != nil {
+		attrs["service"] = payload.ServiceContext.Service
+		if payload.ServiceContext.Version != "" {
+			tags = append(tags, "version:"+payload.ServiceContext.Version)
+		}
+	}
+	labels := make([]string, 0, len(payload.Labels))
+	for k, v := range payload.Labels {
+		labels = append(labels, k+":"+v)
+	}
+	sort.Strings(labels)
+	tags = append(tags, labels...)
+
+	if status := datadogStatus(payload.Severity); status != "" {
+		attrs["status"] = status
+	}
+	attrs["ddsource"] = w.Source
+	attrs["hostname"] = w.Hostname
+	if len(tags) > 0 {
+		attrs["ddtags"] = strings.Join(tags, ",")
+	}
+
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"message": string(p)})
+	}
+	return b
+}
+
+// datadogStatus returns the Datadog status of a severity, which has no TRACE and calls WARN as warning
+func datadogStatus(s string) string {
+	switch s {
+	case TRACE.String():
+		return "debug"
+	case WARN.String():
+		return "warning"
+	}
+	if _, ok := logLevelValue[s]; !ok {
+		return ""
+	}
+	return strings.ToLower(s)
+}
+
+// post sends a request, reporting whether it can be retried when it fails
+func (w *DatadogWriter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", w.APIKey)
+
+	return doPost(w.Client, req)
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDatadogWriter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	var calls int
+	var body []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		if r.Header.Get("DD-API-KEY") != "secret" {
+			t.Errorf("unexpected API key %q", r.Header.Get("DD-API-KEY"))
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("invalid body %s: %s", b, err.Error())
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	w := &DatadogWriter{
+		APIKey:   "secret",
+		Endpoint: server.URL,
+		Hostname: "web-1",
+		Tags:     []string{"env:prod"},
+		Backoff:  time.Millisecond,
+	}
+	log := New(WithWriter(w)).WithLabels(map[string]string{"team": "billing"})
+	log.Warn("WARN message")
+
+	if calls != 2 {
+		t.Fatalf("expected the 429 to be retried; got %d calls", calls)
+	}
+	if len(body) != 1 {
+		t.Fatalf("expected 1 entry; got %d", len(body))
+	}
+	expected := map[string]interface{}{
+		"message":  "WARN message",
+		"service":  "my-app",
+		"status":   "warning",
+		"ddsource": "go",
+		"hostname": "web-1",
+		"ddtags":   "env:prod,version:1.0,team:billing",
+	}
+	for k, v := range expected {
+		if body[0][k] != v {
+			t.Errorf("expected %s %v; got %v", k, v, body[0][k])
+		}
+	}
+}
+
+func TestDatadogWriterClientError(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "invalid API key", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	w := &DatadogWriter{APIKey: "bad", Endpoint: server.URL, Backoff: time.Millisecond}
+	if _, err := w.Write([]byte("plain text\n")); err == nil {
+		t.Errorf("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry on a 403; got %d calls", calls)
+	}
+}
+
+func TestDatadogWriterSplitsRequests(t *testing.T) {
+	var sizes, counts []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		var body []json.RawMessage
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("invalid body: %s", err.Error())
+		}
+		sizes = append(sizes, len(b))
+		counts = append(counts, len(body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	w := &DatadogWriter{APIKey: "secret", Endpoint: server.URL, Hostname: "web-1"}
+
+	// Too many entries for a single request
+	p := bytes.Repeat([]byte("plain text\n"), 2500)
+	if n, err := w.Write(p); err != nil || n != len(p) {
+		t.Fatalf("cannot write the entries: %d %v", n, err)
+	}
+	if len(counts) != 3 || counts[0] != 1000 || counts[1] != 1000 || counts[2] != 500 {
+		t.Errorf("expected requests of 1000, 1000 and 500 entries; got %v", counts)
+	}
+
+	// Too large entries for a single request
+	sizes, counts = nil, nil
+	line := append(bytes.Repeat([]byte("x"), 1<<20), '\n')
+	if _, err := w.Write(bytes.Repeat(line, 6)); err != nil {
+		t.Fatalf("cannot write the entries: %s", err.Error())
+	}
+	if len(counts) != 2 || counts[0]+counts[1] != 6 {
+		t.Errorf("expected the 6 entries in 2 requests; got %v", counts)
+	}
+	for _, size := range sizes {
+		if size > 5<<20 {
+			t.Errorf("request of %d bytes is over the limit", size)
+		}
+	}
+}
+
+func TestDatadogWriterPartialFailure(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	w := &DatadogWriter{APIKey: "secret", Endpoint: server.URL, Hostname: "web-1"}
+	p := bytes.Repeat([]byte("plain text\n"), 1500)
+	n, err := w.Write(p)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if expected := 1000 * len("plain text\n"); n != expected {
+		t.Errorf("expected %d bytes written by the first request; got %d", expected, n)
+	}
+}
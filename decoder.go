@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Decoder reads back the entries of the JSON output of the logger, one per line, e.g. for the
+// tailers, the test assertions or the migration scripts. The fields unknown to Payload are
+// ignored. The indented entries of WithPrettyJSON are not supported
+type Decoder struct {
+	r         *bufio.Reader
+	line      int
+	useNumber bool
+}
+
+// NewDecoder returns a Decoder reading the entries from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// UseNumber decodes the numbers of the context data as json.Number instead of float64
+func (d *Decoder) UseNumber() {
+	d.useNumber = true
+}
+
+// Decode reads the next entry into p, skipping the blank lines. It returns io.EOF when there are
+// no more entries. A line that is not a valid entry returns a *DecodeError, the next call
+// decoding the following line
+func (d *Decoder) Decode(p *Payload) error {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return err
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		d.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		*p = Payload{}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		if d.useNumber {
+			dec.UseNumber()
+		}
+		if err := dec.Decode(p); err != nil {
+			return &DecodeError{Line: d.line, Err: err}
+		}
+		return nil
+	}
+}
+
+// DecodeError is returned by Decoder.Decode for a line that is not a valid entry
+type DecodeError struct {
+	// Line is the number of the line, starting at 1
+	Line int
+	// Err is the error of encoding/json
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("logger: invalid entry on line %d: %s", e.Line, e.Err.Error())
+}
+
+// Unwrap returns the error of encoding/json
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
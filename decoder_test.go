@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf))
+	log.With(Fields{"attempt": 2}).WithOutput(buf).Info("INFO message")
+	buf.WriteString("\nnot json\n")
+	buf.WriteString(`{"severity":"WARN","message":"from another version","unknown":{"a":1}}` + "\n")
+	log.Error("ERROR message")
+
+	dec := NewDecoder(buf)
+	dec.UseNumber()
+
+	var p Payload
+	if err := dec.Decode(&p); err != nil || p.Severity != "INFO" || p.Message != "INFO message" || p.Context.Data["attempt"] != json.Number("2") {
+		t.Errorf("unexpected entry %+v, %v", p, err)
+	}
+
+	var decErr *DecodeError
+	if err := dec.Decode(&p); !errors.As(err, &decErr) || decErr.Line != 3 {
+		t.Errorf("expected a decode error on line 3; got %v", err)
+	}
+
+	if err := dec.Decode(&p); err != nil || p.Severity != "WARN" || p.Message != "from another version" || p.ServiceContext != nil {
+		t.Errorf("unexpected entry %+v, %v", p, err)
+	}
+	if err := dec.Decode(&p); err != nil || p.Severity != "ERROR" || !strings.HasPrefix(p.Stacktrace, "goroutine ") {
+		t.Errorf("unexpected entry %+v, %v", p, err)
+	}
+	if err := dec.Decode(&p); err != io.EOF {
+		t.Errorf("expected io.EOF; got %v", err)
+	}
+}
+
+func TestDecoderLastLineWithoutNewline(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"severity":"INFO","message":"last"}`))
+
+	var p Payload
+	if err := dec.Decode(&p); err != nil || p.Message != "last" {
+		t.Errorf("unexpected entry %+v, %v", p, err)
+	}
+	if err := dec.Decode(&p); err != io.EOF {
+		t.Errorf("expected io.EOF; got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// deduper collapses identical consecutive entries, see WithDeduplication
+type deduper struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	key   dedupKey
+	last  *Payload
+	log   *Log
+	count int
+	// started is the time the current run started, and sweeping tells whether the goroutine
+	// ending the runs at the end of their window is running
+	started  time.Time
+	sweeping bool
+}
+
+// dedupKey identifies the entries considered identical: same severity and message, logged with
+// the same context data
+type dedupKey struct {
+	severity string
+	message  string
+	// data is the hash of the encoded context data
+	data uint64
+}
+
+// WithDeduplication collapses identical consecutive entries written within window of the first
+// one. The first entry is written right away, the repeated ones are counted and written as a
+// single entry with a repeat_count context field once the window is over, or as soon as a
+// different entry is logged. Entries are identical when they have the same severity, message and
+// context data. Hooks do not run for the collapsed entries.
+func WithDeduplication(window time.Duration) Option {
+	return func(l *Log) {
+		l.dedup = &deduper{window: window}
+	}
+}
+
+func keyOf(p *Payload) dedupKey {
+	k := dedupKey{severity: p.Severity, message: p.Message}
+	if p.Context != nil && len(p.Context.Data) > 0 {
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		p.keys = appendSortedKeys(p.keys[:0], p.Context.Data)
+		if err := writeJSONSortedFields(buf, p.Context.Data, p.keys); err == nil {
+			k.data = fnv64a(buf.Bytes())
+		} else {
+			// The entry fails to encode anyway, it is never collapsed
+			k.data = uint64(time.Now().UnixNano())
+		}
+	}
+	return k
+}
+
+// fnv64a hashes b without allocating
+func fnv64a(b []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// suppress tells whether the entry repeats the previous one and must not be written
+func (d *deduper) suppress(l *Log, p *Payload) bool {
+	key := keyOf(p)
+
+	d.mu.Lock()
+	if d.last != nil && d.key == key {
+		d.count++
+		d.mu.Unlock()
+		return true
+	}
+
+	// A different entry ends the previous run
+	summary, summaryLog := d.summary()
+
+	// Keep copies, the payload is recycled and l may be the copy made by a value receiver
+	cp := *p
+	cp.keys = nil
+	lc := *l
+	d.key = key
+	d.last = &cp
+	d.log = &lc
+	d.started = time.Now()
+	if !d.sweeping {
+		d.sweeping = true
+		go d.sweep()
+	}
+	d.mu.Unlock()
+
+	if summary != nil {
+		summaryLog.emit(summary)
+	}
+	return false
+}
+
+// flush writes the summary of the current run without waiting for the end of its window
+func (d *deduper) flush() {
+	d.mu.Lock()
+	summary, summaryLog := d.summary()
+	d.mu.Unlock()
+
+	if summary != nil {
+		summaryLog.emit(summary)
+	}
+}
+
+// sweep ends the runs once their window is over, checking every half window. It returns when
+// there is no run left, suppress starting it again with the next one
+func (d *deduper) sweep() {
+	tick := d.window / 2
+	if tick < time.Millisecond {
+		tick = time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		d.mu.Lock()
+		if d.last == nil {
+			d.sweeping = false
+			d.mu.Unlock()
+			return
+		}
+		var summary *Payload
+		var summaryLog *Log
+		if now.Sub(d.started) >= d.window {
+			summary, summaryLog = d.summary()
+		}
+		d.mu.Unlock()
+
+		if summary != nil {
+			summaryLog.emit(summary)
+		}
+	}
+}
+
+// summary ends the current run, returning the entry to write when it had repetitions.
+// It must be called with the lock held
+func (d *deduper) summary() (*Payload, *Log) {
+	last, log, count := d.last, d.log, d.count
+	d.last, d.log, d.count = nil, nil, 0
+	d.key = dedupKey{}
+	if last == nil || count == 0 {
+		return nil, nil
+	}
+
+	data := make(Fields)
+	if last.Context != nil {
+		for k, v := range last.Context.Data {
+			data[k] = v
+		}
+	}
+	data["repeat_count"] = count
+
+	summary := *last
+	summary.EventTime = ""
+	summary.eventTime = log.clock()
+	summary.timeFormat = log.timeFormat
+	summary.Context = &Context{Data: data}
+	if last.Context != nil {
+		summary.Context.ReportLocation = last.Context.ReportLocation
+	}
+	return &summary, log
+}
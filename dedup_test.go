@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to use from the goroutines writing the delayed entries
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerWithDeduplication(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := &syncBuffer{}
+	log := New(WithDeduplication(time.Hour)).With(Fields{"key": "value"}).WithOutput(buf)
+
+	for i := 0; i < 5; i++ {
+		log.Warn("retrying")
+	}
+	log.Info("INFO message")
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 entries; got %d: %s", len(lines), buf.String())
+	}
+
+	if strings.Contains(lines[0], "repeat_count") {
+		t.Errorf("the first entry %s must be written as is", lines[0])
+	}
+	if !strings.Contains(lines[1], `"message":"retrying"`) || !strings.Contains(lines[1], `"data":{"key":"value","repeat_count":4}`) {
+		t.Errorf("entry %s does not carry the repeat count", lines[1])
+	}
+	if !strings.Contains(lines[2], `"message":"INFO message"`) {
+		t.Errorf("unexpected last entry %s", lines[2])
+	}
+}
+
+func TestLoggerDeduplicationWindow(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := &syncBuffer{}
+	log := New(WithDeduplication(20 * time.Millisecond)).With(Fields{}).WithOutput(buf)
+
+	log.Error("ERROR message")
+	log.Error("ERROR message")
+	log.Error("ERROR message")
+
+	// The summary is written once the window is over, without waiting for another entry
+	time.Sleep(100 * time.Millisecond)
+	if !strings.Contains(buf.String(), `"repeat_count":2`) {
+		t.Errorf("output %s does not contain the repeat count", buf.String())
+	}
+
+	// After the window the same entry is written again
+	log.Error("ERROR message")
+	if got := strings.Count(buf.String(), "\n"); got != 3 {
+		t.Errorf("expected 3 entries; got %d", got)
+	}
+}
+
+func TestLoggerDeduplicationDifferentContext(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := &syncBuffer{}
+	log := New(WithDeduplication(time.Hour)).WithOutput(buf)
+
+	log.With(Fields{"user": "a"}).WithOutput(buf).Info("INFO message")
+	log.With(Fields{"user": "b"}).WithOutput(buf).Info("INFO message")
+
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Errorf("expected 2 entries with different contexts; got %d", got)
+	}
+}
+
+func TestLoggerDeduplicationSameFields(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := &syncBuffer{}
+	log := New(WithDeduplication(time.Hour)).WithOutput(buf)
+
+	// Each call builds a new context with the same fields
+	for i := 0; i < 3; i++ {
+		log.With(Fields{"user": "a"}).Info("INFO message")
+	}
+	log.Info("other message")
+
+	if !strings.Contains(buf.String(), `"data":{"repeat_count":2,"user":"a"}`) {
+		t.Errorf("output %s does not collapse the entries with the same fields", buf.String())
+	}
+}
+
+func TestLoggerDeduplicationSweepStops(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New(WithDeduplication(10 * time.Millisecond)).WithOutput(&syncBuffer{})
+	log.Info("INFO message")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		log.dedup.mu.Lock()
+		sweeping := log.dedup.sweeping
+		log.dedup.mu.Unlock()
+		if !sweeping {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the sweep goroutine is still running without a run to end")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity above which the buffers are not returned to the pool,
+// so a few huge entries do not keep memory allocated forever
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// Encoder serializes a log entry payload into the bytes written to the output
+type Encoder interface {
+	Encode(p *Payload) ([]byte, error)
+}
+
+// bufferEncoder is implemented by the encoders able to write an entry directly into a pooled
+// buffer, saving the allocation of the slice returned by Encode
+type bufferEncoder interface {
+	encodeTo(buf *bytes.Buffer, p *Payload) error
+}
+
+// binaryEncoder is implemented by the encoders whose entries are not lines, such as MsgpackEncoder
+type binaryEncoder interface {
+	binary()
+}
+
+// encode appends the encoded payload to buf, followed by a newline unless the encoder is binary
+func encode(e Encoder, buf *bytes.Buffer, p *Payload) error {
+	if err := encodeEntry(e, buf, p); err != nil {
+		return err
+	}
+
+	if _, ok := e.(binaryEncoder); ok {
+		return nil
+	}
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// encodeEntry appends the encoded payload to buf
+func encodeEntry(e Encoder, buf *bytes.Buffer, p *Payload) error {
+	// The JSON encoder writes the event time straight into the buffer
+	if _, ok := e.(JSONEncoder); !ok {
+		p.formatEventTime()
+	}
+	if be, ok := e.(bufferEncoder); ok {
+		return be.encodeTo(buf, p)
+	}
+
+	b, err := e.Encode(p)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// JSONEncoder encodes a payload using the Stackdriver JSON format. It is the default Encoder
+type JSONEncoder struct {
+	// Indent spreads each entry across multiple lines, indented with the string, e.g. for local
+	// debugging or human reviewed exports. The entries are then no longer read by the line based
+	// writers and collectors, so it is empty by default for compact single line entries
+	Indent string
+}
+
+// Encode marshals the payload to a single line of JSON, the same json.Marshal would produce, or to
+// the indented lines json.MarshalIndent would produce when Indent is set
+func (e JSONEncoder) Encode(p *Payload) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := e.encodeTo(buf, p)
+	return buf.Bytes(), err
+}
+
+// WithPrettyJSON sets a JSONEncoder indenting the entries with two spaces
+func WithPrettyJSON() Option {
+	return WithEncoder(JSONEncoder{Indent: "  "})
+}
+
+// WithEncoder sets the Encoder used to format the log entries
+func WithEncoder(e Encoder) Option {
+	return func(l *Log) {
+		l.encoder = e
+	}
+}
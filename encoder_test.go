@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type messageEncoder struct{}
+
+func (messageEncoder) Encode(p *Payload) ([]byte, error) {
+	return []byte(fmt.Sprintf("%s %s", p.Severity, p.Message)), nil
+}
+
+func TestLoggerWithCustomEncoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New(WithEncoder(messageEncoder{})).WithOutput(buf)
+
+	log.Info("INFO message")
+	expected := "INFO INFO message"
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+
+	// Clean-up the buffer in preparation for new assertions
+	buf.Reset()
+
+	// The encoder must survive the creation of derived loggers
+	log.With(Fields{"key": "value"}).WithOutput(buf).Warn("WARN message")
+	expected = "WARN WARN message"
+	got = strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestLoggerWithOptionsEncoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New().WithOutput(buf)
+	log.WithOptions(WithEncoder(messageEncoder{})).Info("INFO message")
+	expected := "INFO INFO message"
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+
+	// Clean-up the buffer in preparation for new assertions
+	buf.Reset()
+
+	// The original logger keeps using the default JSON encoder
+	log.Info("INFO message")
+	if !strings.HasPrefix(buf.String(), `{"severity":"INFO"`) {
+		t.Errorf("output %s is not JSON encoded", buf.String())
+	}
+}
+
+func TestEncodersWriteSingleLine(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	encoders := []Encoder{JSONEncoder{}, ConsoleEncoder{}, LogfmtEncoder{}, messageEncoder{}}
+	for _, e := range encoders {
+		w := &countingWriter{}
+		log := New(WithEncoder(e)).WithOutput(w)
+
+		log.Info("INFO message")
+		log.Error("ERROR message")
+
+		if w.writes != 2 {
+			t.Errorf("%T: expected 2 writes; got %d", e, w.writes)
+		}
+		if len(w.last) == 0 || w.last[len(w.last)-1] != '\n' || bytes.Count(w.last, []byte("\n")) != 1 {
+			t.Errorf("%T: expected a single line ending with a newline; got %q", e, w.last)
+		}
+	}
+}
+
+// countingWriter records the number of writes and the last one
+type countingWriter struct {
+	writes int
+	last   []byte
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	w.last = append(w.last[:0], p...)
+	return len(p), nil
+}
+
+func TestPrettyJSON(t *testing.T) {
+	p := &Payload{
+		Severity:  "INFO",
+		EventTime: "2017-04-26T02:29:33-04:00",
+		Message:   "INFO message",
+		Context:   &Context{Data: Fields{"key": "value"}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encode(JSONEncoder{Indent: "  "}, buf, p); err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	expected, _ := json.MarshalIndent(p, "", "  ")
+	if buf.String() != string(expected)+"\n" {
+		t.Errorf("output\n%s\ndoes not match expected\n%s", buf.String(), expected)
+	}
+}
+
+func TestWithPrettyJSON(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithWriter(buf), WithPrettyJSON()).Info("INFO message")
+	if !strings.HasPrefix(buf.String(), "{\n  \"severity\": \"INFO\",\n") {
+		t.Errorf("output %s is not indented", buf.String())
+	}
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// maxErrorCauses is the maximum number of causes logged for an error
+const maxErrorCauses = 32
+
+// WithError creates a copy of a Log carrying the error message and type in the "error" context
+// data, along with the message and type of the errors it wraps, with fmt.Errorf("%w") or
+// errors.Join, as "causes" from the outermost to the root causes. When the error, or one it
+// wraps, carries the stack where it was created (as the errors of github.com/pkg/errors do), that
+// stack is used as the stacktrace of the ERROR and CRITICAL entries, and its first frame as their
+// report location, instead of the ones of the logging call
+func (l *Log) WithError(err error) *Log {
+	if err == nil {
+		return l
+	}
+
+	data := Fields{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+	if causes := errorCauses(err); len(causes) > 0 {
+		data["causes"] = causes
+	}
+	n := l.With(Fields{"error": data})
+
+	if pcs := errorStack(err); len(pcs) > 0 {
+		n.errStack = pcs
+	}
+	return n
+}
+
+// ErrorE prints out the error with ERROR severity level. The message is the error message,
+// prefixed with message when it is not empty, and the error is added to the context data along
+// with its stack as with WithError
+func (l Log) ErrorE(err error, message string) {
+	l.WithError(err).error(ERROR.String(), errorMessage(err, message))
+}
+
+// FatalE is equivalent to ErrorE(err, "") followed by a call to os.Exit(1), or to the function
+// set with SetExitFunc and the code set with WithExitCode.
+// It prints out the error with CRITICAL severity level
+func (l Log) FatalE(err error) {
+	l.WithError(err).error(CRITICAL.String(), errorMessage(err, ""))
+	exit(l.exitCode)
+}
+
+// errorMessage returns the message of an entry logging err, e.g. "saving the order: connection refused"
+func errorMessage(err error, message string) string {
+	switch {
+	case err == nil && message == "":
+		return fmt.Sprint(err)
+	case err == nil:
+		return message
+	case message == "":
+		return err.Error()
+	}
+	return message + ": " + err.Error()
+}
+
+// errorCauses returns the message and type of the errors wrapped by err, depth first
+func errorCauses(err error) []Fields {
+	var causes []Fields
+	var walk func(e error)
+	walk = func(e error) {
+		for _, cause := range unwrapAll(e) {
+			if len(causes) == maxErrorCauses {
+				return
+			}
+			causes = append(causes, Fields{
+				"message": cause.Error(),
+				"type":    fmt.Sprintf("%T", cause),
+			})
+			walk(cause)
+		}
+	}
+	walk(err)
+	return causes
+}
+
+// unwrapAll returns the errors wrapped by err, either one with Unwrap() error or several with
+// Unwrap() []error as errors.Join does
+func unwrapAll(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, cause := range e.Unwrap() {
+			if cause != nil {
+				errs = append(errs, cause)
+			}
+		}
+		return errs
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	}
+	return nil
+}
+
+// stackMethods are the methods of the errors returning the program counters of their stack:
+// StackTrace for the pkg/errors StackTracer, Callers for the go-errors errors
+var stackMethods = []string{"StackTrace", "Callers"}
+
+// errorStack returns the program counters of the deepest stack found in the error chain. Any
+// error with a StackTrace or Callers method returning a slice of program counters is supported,
+// which includes the pkg/errors StackTracer without depending on it
+func errorStack(err error) []uintptr {
+	var pcs []uintptr
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		m := stackMethod(e)
+		if !m.IsValid() {
+			continue
+		}
+
+		t := m.Type()
+		if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice || t.Out(0).Elem().Kind() != reflect.Uintptr {
+			continue
+		}
+
+		frames := m.Call(nil)[0]
+		if frames.Len() == 0 {
+			continue
+		}
+		pcs = make([]uintptr, frames.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(frames.Index(i).Uint())
+		}
+	}
+	return pcs
+}
+
+// stackMethod returns the method of an error returning its stack, which is not valid when it has none
+func stackMethod(err error) reflect.Value {
+	v := reflect.ValueOf(err)
+	for _, name := range stackMethods {
+		if m := v.MethodByName(name); m.IsValid() {
+			return m
+		}
+	}
+	return reflect.Value{}
+}
+
+// errorOrigin returns the location where an error was created, the first frame of its stack
+func errorOrigin(pcs []uintptr) (function, file string, line int) {
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return frame.Function, frame.File, frame.Line
+}
@@ -0,0 +1,219 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stackError mimics the errors of github.com/pkg/errors, which record the stack on creation
+type stackError struct {
+	msg   string
+	stack []frame
+}
+
+type frame uintptr
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+
+	e := &stackError{msg: msg}
+	for _, pc := range pcs[:n] {
+		e.stack = append(e.stack, frame(pc))
+	}
+	return e
+}
+
+func (e *stackError) Error() string       { return e.msg }
+func (e *stackError) StackTrace() []frame { return e.stack }
+
+func createStackError() error {
+	return newStackError("connection refused")
+}
+
+func TestLoggerWithError(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New().WithOutput(buf)
+
+	log.WithError(errors.New("connection refused")).Warn("WARN message")
+	expected := `"context":{"data":{"error":{"message":"connection refused","type":"*errors.errorString"}}}`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+
+	if log.WithError(nil) != log {
+		t.Errorf("expected the same logger for a nil error")
+	}
+}
+
+func TestLoggerWithErrorStack(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	err := fmt.Errorf("save failed: %w", createStackError())
+	New().WithOutput(buf).WithError(err).Error("ERROR message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	// The stacktrace is the one of the error origin, not the logging call site
+	lines := strings.Split(p.Stacktrace, "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[1], "logger.createStackError()") {
+		t.Errorf("stacktrace %s does not start at the error origin", p.Stacktrace)
+	}
+
+	data := p.Context.Data["error"].(map[string]interface{})
+	if data["message"] != "save failed: connection refused" || data["type"] != "*fmt.wrapError" {
+		t.Errorf("unexpected error data %v", data)
+	}
+}
+
+func TestLoggerErrorE(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf))
+	log.ErrorE(fmt.Errorf("save failed: %w", createStackError()), "saving the order")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if p.Severity != "ERROR" || p.Message != "saving the order: save failed: connection refused" {
+		t.Errorf("unexpected severity %s and message %s", p.Severity, p.Message)
+	}
+	if data, _ := p.Context.Data["error"].(map[string]interface{}); data["type"] != "*fmt.wrapError" {
+		t.Errorf("unexpected error data %v", p.Context.Data)
+	}
+	if lines := strings.Split(p.Stacktrace, "\n"); len(lines) < 2 || !strings.HasSuffix(lines[1], "logger.createStackError()") {
+		t.Errorf("stacktrace %s does not start at the error origin", p.Stacktrace)
+	}
+	if !strings.HasSuffix(p.Context.ReportLocation.FilePath, "error_test.go") {
+		t.Errorf("unexpected report location %+v", p.Context.ReportLocation)
+	}
+}
+
+func TestLoggerFatalE(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	var codes []int
+	SetExitFunc(func(code int) {
+		codes = append(codes, code)
+	})
+	defer SetExitFunc(nil)
+
+	buf := new(bytes.Buffer)
+	New(WithWriter(buf)).FatalE(errors.New("connection refused"))
+
+	if len(codes) != 1 || codes[0] != 1 {
+		t.Errorf("expected the exit code 1; got %v", codes)
+	}
+	expected := `"severity":"CRITICAL","eventTime":`
+	if !strings.Contains(buf.String(), expected) || !strings.Contains(buf.String(), `"message":"connection refused"`) {
+		t.Errorf("output %s does not contain the CRITICAL entry", buf.String())
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := errors.New("connection refused")
+	tests := []struct {
+		err      error
+		message  string
+		expected string
+	}{
+		{err, "", "connection refused"},
+		{err, "saving the order", "saving the order: connection refused"},
+		{nil, "saving the order", "saving the order"},
+		{nil, "", "<nil>"},
+	}
+	for _, tt := range tests {
+		if got := errorMessage(tt.err, tt.message); got != tt.expected {
+			t.Errorf("errorMessage(%v, %q) = %q, want %q", tt.err, tt.message, got, tt.expected)
+		}
+	}
+}
+
+// joinedError mimics the errors returned by errors.Join
+type joinedError []error
+
+func (e joinedError) Error() string   { return "several errors" }
+func (e joinedError) Unwrap() []error { return e }
+
+func TestLoggerWithErrorCauses(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	root := errors.New("connection refused")
+	err := fmt.Errorf("saving the order: %w", joinedError{
+		fmt.Errorf("primary: %w", root),
+		errors.New("replica timeout"),
+	})
+	New(WithWriter(buf)).WithError(err).Warn("WARN message")
+
+	expected := `"error":{"causes":[` +
+		`{"message":"several errors","type":"logger.joinedError"},` +
+		`{"message":"primary: connection refused","type":"*fmt.wrapError"},` +
+		`{"message":"connection refused","type":"*errors.errorString"},` +
+		`{"message":"replica timeout","type":"*errors.errorString"}],` +
+		`"message":"saving the order: several errors","type":"*fmt.wrapError"}`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	New(WithWriter(buf)).WithError(root).Warn("WARN message")
+	if strings.Contains(buf.String(), "causes") {
+		t.Errorf("output %s contains causes for an error wrapping none", buf.String())
+	}
+}
+
+// callersError mimics the errors of github.com/go-errors/errors, which expose their stack with Callers
+type callersError struct {
+	pcs []uintptr
+}
+
+func (e *callersError) Error() string      { return "connection reset" }
+func (e *callersError) Callers() []uintptr { return e.pcs }
+
+func createCallersError() error {
+	pcs := make([]uintptr, 32)
+	return &callersError{pcs: pcs[:runtime.Callers(1, pcs)]}
+}
+
+func TestLoggerWithErrorReportLocation(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	tests := []struct {
+		err      error
+		function string
+	}{
+		{fmt.Errorf("save failed: %w", createStackError()), "logger.createStackError"},
+		{createCallersError(), "logger.createCallersError"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		New(WithWriter(buf)).WithError(tt.err).Error("ERROR message")
+
+		p := Payload{}
+		if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+			t.Fatalf("failed to unmarshal payload: %s", err.Error())
+		}
+
+		// Error Reporting groups by where the error was created, not where it was logged
+		if loc := p.Context.ReportLocation; loc.FunctionName != tt.function || !strings.HasSuffix(loc.FilePath, "error_test.go") {
+			t.Errorf("unexpected report location %+v, want %s", loc, tt.function)
+		}
+		if lines := strings.Split(p.Stacktrace, "\n"); len(lines) < 2 || !strings.HasSuffix(lines[1], tt.function+"()") {
+			t.Errorf("stacktrace %s does not start at %s", p.Stacktrace, tt.function)
+		}
+	}
+}
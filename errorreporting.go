@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultErrorReportingEndpoint is the base URL of the Error Reporting API
+const defaultErrorReportingEndpoint = "https://clouderrorreporting.googleapis.com"
+
+// ErrorReporter is a Hook reporting the entries to the Error Reporting API (events.report), so
+// the errors are grouped and notified without relying on their extraction from the logs. The
+// report location, the stacktrace, the request of WithHTTPRequest and the affected user are sent
+// along with the message.
+// Each report performs an HTTP request within the logging call, add it for ERROR and CRITICAL only.
+type ErrorReporter struct {
+	// ProjectID is the project the errors are reported to. It is detected from the
+	// GOOGLE_CLOUD_PROJECT environment variable or the metadata server when empty
+	ProjectID string
+	// UserKey is the context data key of the user affected by the error, "user" by default
+	UserKey string
+	// Client is the HTTP client used to call the API. It must add the credentials to the requests.
+	// When nil, the access token of the default service account is requested from the metadata server
+	Client *http.Client
+	// Endpoint is the base URL of the API, https://clouderrorreporting.googleapis.com by default
+	Endpoint string
+
+	mu    sync.Mutex
+	token metadataToken
+}
+
+// WithErrorReporting reports the ERROR and CRITICAL entries to the Error Reporting API of the project
+func WithErrorReporting(projectID string) Option {
+	return func(l *Log) {
+		l.AddHook(&ErrorReporter{ProjectID: projectID}, ERROR, CRITICAL)
+	}
+}
+
+type errorEvent struct {
+	EventTime      string          `json:"eventTime,omitempty"`
+	ServiceContext *ServiceContext `json:"serviceContext"`
+	Message        string          `json:"message"`
+	Context        *errorContext   `json:"context,omitempty"`
+}
+
+type errorContext struct {
+	HTTPRequest    *errorHTTPRequest `json:"httpRequest,omitempty"`
+	User           string            `json:"user,omitempty"`
+	ReportLocation *ReportLocation   `json:"reportLocation,omitempty"`
+}
+
+type errorHTTPRequest struct {
+	Method             string `json:"method,omitempty"`
+	URL                string `json:"url,omitempty"`
+	UserAgent          string `json:"userAgent,omitempty"`
+	Referrer           string `json:"referrer,omitempty"`
+	ResponseStatusCode int    `json:"responseStatusCode,omitempty"`
+	RemoteIP           string `json:"remoteIp,omitempty"`
+}
+
+// Fire reports the entry as an error event
+func (r *ErrorReporter) Fire(p *Payload) error {
+	body, err := json.Marshal(r.event(p))
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ProjectID == "" {
+		id, err := detectProjectID()
+		if err != nil {
+			return err
+		}
+		r.ProjectID = id
+	}
+	return r.post(body)
+}
+
+// event builds the error event of an entry
+func (r *ErrorReporter) event(p *Payload) *errorEvent {
+	e := &errorEvent{
+		ServiceContext: p.ServiceContext,
+		Message:        p.Message,
+	}
+	if _, err := time.Parse(time.RFC3339Nano, p.EventTime); err == nil {
+		e.EventTime = p.EventTime
+	}
+	if e.ServiceContext == nil || e.ServiceContext.Service == "" {
+		// The service is mandatory
+		e.ServiceContext = &ServiceContext{Service: "app"}
+	}
+	if p.Stacktrace != "" {
+		// Error Reporting extracts the stack from the message
+		e.Message = p.Message + "\n\n" + p.Stacktrace
+	}
+
+	c := &errorContext{}
+	if p.Context != nil {
+		c.ReportLocation = p.Context.ReportLocation
+
+		userKey := r.UserKey
+		if userKey == "" {
+			userKey = "user"
+		}
+		if user, ok := p.Context.Data[userKey]; ok {
+			c.User = fmt.Sprint(user)
+		}
+	}
+	if req := p.HTTPRequest; req != nil {
+		c.HTTPRequest = &errorHTTPRequest{
+			Method:             req.RequestMethod,
+			URL:                req.RequestURL,
+			UserAgent:          req.UserAgent,
+			Referrer:           req.Referer,
+			ResponseStatusCode: req.Status,
+			RemoteIP:           req.RemoteIP,
+		}
+	}
+	if *c != (errorContext{}) {
+		e.Context = c
+	}
+	return e
+}
+
+func (r *ErrorReporter) post(body []byte) error {
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = defaultErrorReportingEndpoint
+	}
+
+	u := strings.TrimRight(endpoint, "/") + "/v1beta1/projects/" + url.PathEscape(r.ProjectID) + "/events:report"
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.Client
+	if client == nil {
+		token, err := r.token.get()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		client = apiClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("logger: error reporting API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
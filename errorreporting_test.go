@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorReporter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	var events []errorEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta1/projects/my-project/events:report" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var e errorEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("cannot decode the request: %s", err.Error())
+		}
+		events = append(events, e)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	log := New().WithOutput(buf)
+	log.AddHook(&ErrorReporter{
+		ProjectID: "my-project",
+		Client:    server.Client(),
+		Endpoint:  server.URL,
+	}, ERROR, CRITICAL)
+
+	log.Warn("WARN message")
+	log.With(Fields{"user": "+1234567890"}).WithOutput(buf).
+		WithHTTPRequest(&HTTPRequest{RequestMethod: "GET", RequestURL: "/users", Status: 500}).
+		Error("ERROR message")
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event; got %d", len(events))
+	}
+
+	e := events[0]
+	if !strings.HasPrefix(e.Message, "ERROR message\n\ngoroutine ") {
+		t.Errorf("message %q does not contain the stacktrace", e.Message)
+	}
+	if e.ServiceContext.Service != "my-app" || e.ServiceContext.Version != "1.0" {
+		t.Errorf("unexpected service context %+v", e.ServiceContext)
+	}
+	c := e.Context
+	if c == nil || c.User != "+1234567890" || c.ReportLocation.FunctionName != "logger.TestErrorReporter" {
+		t.Fatalf("unexpected context %+v", c)
+	}
+	if c.HTTPRequest == nil || c.HTTPRequest.Method != "GET" || c.HTTPRequest.ResponseStatusCode != 500 {
+		t.Errorf("unexpected request %+v", c.HTTPRequest)
+	}
+	if !strings.Contains(buf.String(), "ERROR message") {
+		t.Errorf("the entry was not logged: %s", buf.String())
+	}
+}
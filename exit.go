@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// exitFunc holds the function called by Fatal and Fatalf, os.Exit unless set with SetExitFunc
+var exitFunc atomic.Value
+
+// SetExitFunc changes the function called by Fatal and Fatalf to terminate the program, e.g. to
+// run some cleanup first or to intercept the exit in tests. A nil function restores os.Exit
+func SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		fn = os.Exit
+	}
+	exitFunc.Store(fn)
+}
+
+// WithExitCode sets the code passed to the exit function by Fatal and Fatalf, 1 by default
+func WithExitCode(code int) Option {
+	return func(l *Log) {
+		l.exitCode = code
+	}
+}
+
+// exit terminates the program with the exit function
+func exit(code int) {
+	fn, _ := exitFunc.Load().(func(int))
+	if fn == nil {
+		fn = os.Exit
+	}
+	fn(code)
+}
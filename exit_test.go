@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFatalCallsExitFunc(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	var codes []int
+	SetExitFunc(func(code int) {
+		codes = append(codes, code)
+	})
+	defer SetExitFunc(nil)
+
+	buf := new(bytes.Buffer)
+	log := New().WithOutput(buf)
+	log.Fatal("CRITICAL message")
+	log.WithOptions(WithExitCode(3)).Fatalf("CRITICAL message %s", "with param")
+
+	if len(codes) != 2 || codes[0] != 1 || codes[1] != 3 {
+		t.Errorf("expected the exit codes 1 and 3; got %v", codes)
+	}
+	if n := strings.Count(buf.String(), `"severity":"CRITICAL"`); n != 2 {
+		t.Errorf("expected 2 CRITICAL entries; got %d: %s", n, buf.String())
+	}
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// defaultFallbackNotice is the minimum interval between two notices of a FallbackWriter
+const defaultFallbackNotice = time.Minute
+
+// FallbackWriter is an io.Writer sending the log entries to a primary writer, and to a fallback
+// writer such as os.Stderr when the primary one fails, so an outage of a network sink does not
+// silently lose the entries. The fallback also receives a notice with the number of failed
+// entries, at most once per interval.
+type FallbackWriter struct {
+	primary  io.Writer
+	fallback io.Writer
+	interval time.Duration
+
+	mu       sync.Mutex
+	failed   int
+	lastErr  error
+	noticeAt time.Time
+}
+
+// NewFallbackWriter returns a FallbackWriter writing to primary, and to fallback when primary fails.
+// The failures are noticed on the fallback at most once per interval, once per minute when it is zero
+func NewFallbackWriter(primary, fallback io.Writer, interval time.Duration) *FallbackWriter {
+	if interval <= 0 {
+		interval = defaultFallbackNotice
+	}
+	return &FallbackWriter{
+		primary:  primary,
+		fallback: fallback,
+		interval: interval,
+	}
+}
+
+// WithFallback creates a copy of a Log writing to w the entries its current output fails to write
+func (l *Log) WithFallback(w io.Writer) *Log {
+	return l.WithOutput(NewFallbackWriter(l.writer, w, 0))
+}
+
+// Write writes p to the primary writer, or to the fallback writer when it fails
+func (f *FallbackWriter) Write(p []byte) (int, error) {
+	_, err := f.primary.Write(p)
+	if err == nil {
+		return len(p), nil
+	}
+	return f.fail(err, f.fallback.Write, p)
+}
+
+// writeLevel is Write passing the severity and time along to the writers that use them
+func (f *FallbackWriter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	write := func(w io.Writer, p []byte) (int, error) {
+		if lw, ok := w.(levelWriter); ok {
+			return lw.writeLevel(s, t, p)
+		}
+		return w.Write(p)
+	}
+
+	_, err := write(f.primary, p)
+	if err == nil {
+		return len(p), nil
+	}
+	return f.fail(err, func(p []byte) (int, error) { return write(f.fallback, p) }, p)
+}
+
+// fail writes p with the fallback write function after the primary writer returned err
+func (f *FallbackWriter) fail(err error, write func([]byte) (int, error), p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failed++
+	f.lastErr = err
+	if _, ferr := write(p); ferr != nil {
+		return 0, fmt.Errorf("logger: primary writer failed: %s, fallback writer failed: %s", err.Error(), ferr.Error())
+	}
+
+	if now := time.Now(); !now.Before(f.noticeAt) {
+		f.notice()
+		f.noticeAt = now.Add(f.interval)
+	}
+	return len(p), nil
+}
+
+// notice writes the number of entries that failed to the primary writer since the previous notice
+func (f *FallbackWriter) notice() {
+	if f.failed == 0 {
+		return
+	}
+	fmt.Fprintf(f.fallback, "logger WARN: %d entries failed to primary sink: %s\n", f.failed, f.lastErr.Error())
+	f.failed = 0
+}
+
+// Sync writes the pending notice, then flushes both writers
+func (f *FallbackWriter) Sync() error {
+	f.mu.Lock()
+	f.notice()
+	f.mu.Unlock()
+
+	err := syncWriter(f.primary)
+	if ferr := syncWriter(f.fallback); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// Close writes the pending notice, then flushes and closes both writers, except os.Stdout and os.Stderr
+func (f *FallbackWriter) Close() error {
+	f.mu.Lock()
+	f.notice()
+	f.mu.Unlock()
+
+	err := closeWriter(f.primary)
+	if ferr := closeWriter(f.fallback); err == nil {
+		err = ferr
+	}
+	return err
+}
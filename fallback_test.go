@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// toggleWriter fails while down is set
+type toggleWriter struct {
+	bytes.Buffer
+	down bool
+}
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if w.down {
+		return 0, errors.New("connection refused")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestFallbackWriter(t *testing.T) {
+	primary, fallback := &toggleWriter{}, new(bytes.Buffer)
+	w := NewFallbackWriter(primary, fallback, time.Hour)
+
+	w.Write([]byte("first\n"))
+	primary.down = true
+	w.Write([]byte("second\n"))
+	w.Write([]byte("third\n"))
+	primary.down = false
+	w.Write([]byte("fourth\n"))
+
+	if primary.String() != "first\nfourth\n" {
+		t.Errorf("unexpected primary output %q", primary.String())
+	}
+
+	// The first failure is noticed right away, the next one on Sync as the interval did not elapse
+	expected := "second\nlogger WARN: 1 entries failed to primary sink: connection refused\nthird\n"
+	if fallback.String() != expected {
+		t.Errorf("fallback output %q does not match expected string %q", fallback.String(), expected)
+	}
+
+	w.Sync()
+	expected += "logger WARN: 1 entries failed to primary sink: connection refused\n"
+	if fallback.String() != expected {
+		t.Errorf("fallback output %q does not match expected string %q", fallback.String(), expected)
+	}
+}
+
+func TestFallbackWriterBothFail(t *testing.T) {
+	w := NewFallbackWriter(failingWriter{}, failingWriter{}, 0)
+	if _, err := w.Write([]byte("entry\n")); err == nil || !strings.Contains(err.Error(), "fallback writer failed") {
+		t.Errorf("expected an error from both writers; got %v", err)
+	}
+}
+
+func TestLoggerWithFallback(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	dropped := DroppedEntries()
+	fallback := new(bytes.Buffer)
+	log := New().WithOutput(failingWriter{}).WithFallback(fallback)
+	log.Info("INFO message")
+
+	if !strings.Contains(fallback.String(), `"message":"INFO message"`) {
+		t.Errorf("fallback output %s does not contain the entry", fallback.String())
+	}
+	if DroppedEntries() != dropped {
+		t.Errorf("expected no dropped entry")
+	}
+}
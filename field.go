@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"time"
+)
+
+// Field is a strongly typed context entry, built with the String, Int, Bool... constructors.
+// The values are stored already converted to a JSON primitive, so the encoders do not need to
+// inspect them
+type Field struct {
+	Key   string
+	value interface{}
+}
+
+// String returns a Field with a string value
+func String(key, value string) Field {
+	return Field{Key: key, value: value}
+}
+
+// Int returns a Field with an integer value
+func Int(key string, value int) Field {
+	return Field{Key: key, value: value}
+}
+
+// Int64 returns a Field with a 64 bits integer value
+func Int64(key string, value int64) Field {
+	return Field{Key: key, value: value}
+}
+
+// Float64 returns a Field with a floating point value
+func Float64(key string, value float64) Field {
+	return Field{Key: key, value: value}
+}
+
+// Bool returns a Field with a boolean value
+func Bool(key string, value bool) Field {
+	return Field{Key: key, value: value}
+}
+
+// Duration returns a Field with a duration value, formatted like "1.5s"
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, value: value.String()}
+}
+
+// Time returns a Field with a time value, formatted as RFC3339 with nanoseconds
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, value: value.Format(time.RFC3339Nano)}
+}
+
+// Err returns a Field with the message of an error under the "error" key, or null when err is nil
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", value: nil}
+	}
+	return Field{Key: "error", value: err.Error()}
+}
+
+// Any returns a Field with an arbitrary value, encoded the same way as the Fields values
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, value: value}
+}
+
+// Value returns the value of the field
+func (f Field) Value() interface{} {
+	return f.value
+}
+
+// WithFields is the strongly typed version of With, specifying which values go in the log entry's context
+func (l *Log) WithFields(fields ...Field) *Log {
+	f := make(Fields, len(fields))
+	for _, field := range fields {
+		f[field.Key] = field.value
+	}
+
+	return l.With(f)
+}
+
+// badKey is the key of the WithKV arguments that are not a key followed by its value
+const badKey = "!BADKEY"
+
+// WithKV is the same as With with the fields given as alternating keys and values, e.g.
+// WithKV("user", id, "region", r). A Field can also be given in place of a key and value.
+// A key that is not a string or lacks a value is a mistake, the argument is then logged under
+// the "!BADKEY" key rather than lost, in a list when there are several of them
+func (l *Log) WithKV(keyvals ...interface{}) *Log {
+	f := make(Fields, (len(keyvals)+1)/2)
+	var bad []interface{}
+	for i := 0; i < len(keyvals); i++ {
+		switch key := keyvals[i].(type) {
+		case Field:
+			f[key.Key] = key.value
+		case string:
+			if i == len(keyvals)-1 {
+				bad = append(bad, key)
+				break
+			}
+			f[key] = keyvals[i+1]
+			i++
+		default:
+			bad = append(bad, key)
+		}
+	}
+
+	switch len(bad) {
+	case 0:
+	case 1:
+		f[badKey] = bad[0]
+	default:
+		f[badKey] = bad
+	}
+
+	return l.With(f)
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerWithFields(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New().With(Fields{"key": "value"}).WithOutput(buf).WithFields(
+		String("user", "+1234567890"),
+		Int("attempt", 3),
+		Int64("bytes", 1<<40),
+		Float64("ratio", 0.5),
+		Bool("retry", true),
+		Duration("latency", 1500*time.Millisecond),
+		Time("since", time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC)),
+		Err(errors.New("connection refused")),
+		Any("names", []string{"Mauricio", "Manuel"}),
+	)
+
+	log.Info("INFO message")
+	expected := `"context":{"data":{"attempt":3,"bytes":1099511627776,"error":"connection refused","key":"value","latency":"1.5s","names":["Mauricio","Manuel"],"ratio":0.5,"retry":true,"since":"2017-04-26T02:29:33Z","user":"+1234567890"}}`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+}
+
+func TestErrFieldWithNilError(t *testing.T) {
+	f := Err(nil)
+	if f.Key != "error" || f.Value() != nil {
+		t.Errorf("unexpected field %+v", f)
+	}
+}
+
+func TestLoggerWithKV(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	tests := []struct {
+		keyvals  []interface{}
+		expected string
+	}{
+		{[]interface{}{"user", 42, "region", "us-east1"}, `"data":{"key":"value","region":"us-east1","user":42}`},
+		{[]interface{}{"user", 42, Bool("retry", true)}, `"data":{"key":"value","retry":true,"user":42}`},
+		{[]interface{}{"user", 42, "region"}, `"data":{"!BADKEY":"region","key":"value","user":42}`},
+		{[]interface{}{42, "user", "+1234567890"}, `"data":{"!BADKEY":42,"key":"value","user":"+1234567890"}`},
+		{[]interface{}{42, true, "user", 1}, `"data":{"!BADKEY":[42,true],"key":"value","user":1}`},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		New().With(Fields{"key": "value"}).WithOutput(buf).WithKV(test.keyvals...).Info("INFO message")
+
+		if !strings.Contains(buf.String(), test.expected) {
+			t.Errorf("%v: output %s does not contain %s", test.keyvals, buf.String(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package logger
+
+import "sync"
+
+// fieldList is the immutable list of the fields added by successive With calls. A derived logger
+// shares the list of its parent and only allocates the fields it adds, the merged context being
+// built once, when the logger writes its first entry
+type fieldList struct {
+	parent *fieldList
+	// base is the context of the logger With was first called on, only set on the first node
+	base   *Context
+	fields Fields
+	// size is the number of fields of the list and its parents, the capacity of the merged data
+	size int
+
+	once sync.Once
+	ctx  *Context
+}
+
+// with returns a list adding a copy of the fields to l, which can be nil; base is the context of
+// the logger when l is nil
+func (l *fieldList) with(base *Context, fields Fields) *fieldList {
+	f := make(Fields, len(fields))
+	for k, v := range fields {
+		f[k] = v
+	}
+
+	n := &fieldList{parent: l, fields: f, size: len(f)}
+	if l != nil {
+		n.size += l.size
+	} else {
+		n.base = base
+		if base != nil {
+			n.size += len(base.Data)
+		}
+	}
+	return n
+}
+
+// context returns the context with the fields of the list and its parents, the latest ones
+// overriding the previous ones. It must not be modified
+func (l *fieldList) context() *Context {
+	l.once.Do(func() {
+		var nodes []*fieldList
+		for n := l; n != nil; n = n.parent {
+			nodes = append(nodes, n)
+		}
+
+		c := Context{}
+		root := nodes[len(nodes)-1]
+		if root.base != nil {
+			c = *root.base
+		}
+		data := make(Fields, l.size)
+		for k, v := range c.Data {
+			data[k] = v
+		}
+		for i := len(nodes) - 1; i >= 0; i-- {
+			for k, v := range nodes[i].fields {
+				data[k] = v
+			}
+		}
+		c.Data = data
+		l.ctx = &c
+	})
+	return l.ctx
+}
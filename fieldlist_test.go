@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestWithSharesParentFields(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	fields := Fields{"user": "alice", "region": "us-east1"}
+	parent := New(WithWriter(buf), WithProcessFields(false)).With(fields)
+	child := parent.With(Fields{"user": "bob", "attempt": 2}).WithOutput(buf)
+	fields["user"] = "carol"
+
+	child.Info("child")
+	parent.WithOutput(buf).Info("parent")
+
+	dec := json.NewDecoder(buf)
+	var c, p Payload
+	if err := dec.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Context.Data; got["user"] != "bob" || got["region"] != "us-east1" || got["attempt"] != 2.0 || got["pid"] == nil {
+		t.Errorf("unexpected child fields %v", got)
+	}
+	if got := p.Context.Data; got["user"] != "alice" || got["attempt"] != nil || got["pid"] == nil {
+		t.Errorf("unexpected parent fields %v", got)
+	}
+}
+
+func TestWithAllocatesTheAddedFieldsOnly(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New()
+	for i := 0; i < 100; i++ {
+		log = log.With(Fields{fmt.Sprint("key", i): i})
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		log.With(Fields{"key": "value"})
+	})
+	if allocs > 5 {
+		t.Errorf("expected the allocations of With not to depend on the parent fields; got %v", allocs)
+	}
+	if n := len(log.fields()); n != 100 {
+		t.Errorf("expected 100 fields; got %d", n)
+	}
+}
+
+func TestFieldListContextConcurrentFirstUse(t *testing.T) {
+	l := (*fieldList)(nil).with(&Context{Data: Fields{"a": 1}}, Fields{"b": 2}).with(nil, Fields{"a": 3})
+
+	var wg sync.WaitGroup
+	contexts := make([]*Context, 8)
+	for i := range contexts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			contexts[i] = l.context()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, c := range contexts {
+		if c != contexts[0] {
+			t.Fatal("expected the context to be built once")
+		}
+	}
+	if got := contexts[0].Data; len(got) != 2 || got["a"] != 3 || got["b"] != 2 {
+		t.Errorf("unexpected merged fields %v", got)
+	}
+}
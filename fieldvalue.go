@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// fieldEncoders holds the map[reflect.Type]func(interface{}) interface{} of the functions set
+	// with RegisterFieldEncoder. It is replaced on each change so the encoders read it without locking
+	fieldEncoders   atomic.Value
+	fieldEncodersMu sync.Mutex
+)
+
+// RegisterFieldEncoder sets the function converting the Fields values with the type of sample
+// before they are encoded, e.g. to log only the ID of a user:
+//
+//	logger.RegisterFieldEncoder(&User{}, func(v interface{}) interface{} { return v.(*User).ID })
+//
+// Without one, errors are encoded as their message, durations like "1.5s" and the other
+// fmt.Stringer values, unless they implement json.Marshaler, as their String(). A nil function
+// removes the encoder of the type
+func RegisterFieldEncoder(sample interface{}, fn func(v interface{}) interface{}) {
+	fieldEncodersMu.Lock()
+	defer fieldEncodersMu.Unlock()
+
+	encoders := make(map[reflect.Type]func(interface{}) interface{})
+	if old, ok := fieldEncoders.Load().(map[reflect.Type]func(interface{}) interface{}); ok {
+		for t, f := range old {
+			encoders[t] = f
+		}
+	}
+
+	if fn == nil {
+		delete(encoders, reflect.TypeOf(sample))
+	} else {
+		encoders[reflect.TypeOf(sample)] = fn
+	}
+	fieldEncoders.Store(encoders)
+}
+
+// convertFieldValue converts a value that encoding/json renders poorly, it returns false when
+// the value is to be encoded as is
+func convertFieldValue(v interface{}) (interface{}, bool) {
+	if encoders, ok := fieldEncoders.Load().(map[reflect.Type]func(interface{}) interface{}); ok {
+		if fn, ok := encoders[reflect.TypeOf(v)]; ok {
+			return fn(v), true
+		}
+	}
+
+	switch val := v.(type) {
+	case json.Marshaler:
+		return nil, false
+	case error:
+		if isNilPointer(v) {
+			return nil, true
+		}
+		return val.Error(), true
+	case time.Duration:
+		return val.String(), true
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return nil, true
+		}
+		return val.String(), true
+	}
+	return nil, false
+}
+
+// isNilPointer tells whether v is a nil pointer, whose methods may panic
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type userID int
+
+type user struct {
+	ID   userID
+	Name string
+}
+
+func (u userID) String() string {
+	return fmt.Sprintf("user-%d", int(u))
+}
+
+func TestFieldValueEncoding(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	var nilErr *net.OpError
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{errors.New("connection refused"), `"connection refused"`},
+		{1500 * time.Millisecond, `"1.5s"`},
+		{userID(7), `"user-7"`},
+		{net.ParseIP("10.0.0.1"), `"10.0.0.1"`},
+		{time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC), `"2017-04-26T02:29:33Z"`},
+		{[]interface{}{errors.New("a"), time.Second}, `["a","1s"]`},
+		{error(nilErr), `null`},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		New().With(Fields{"value": test.value}).WithOutput(buf).Info("INFO message")
+
+		if !strings.Contains(buf.String(), `"data":{"value":`+test.expected+`}`) {
+			t.Errorf("%#v: output %s does not contain %s", test.value, buf.String(), test.expected)
+		}
+	}
+}
+
+func TestRegisterFieldEncoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	RegisterFieldEncoder(&user{}, func(v interface{}) interface{} {
+		return v.(*user).ID
+	})
+	defer RegisterFieldEncoder(&user{}, nil)
+
+	buf := new(bytes.Buffer)
+	New().With(Fields{"user": &user{ID: 3, Name: "Mauricio"}}).WithOutput(buf).Info("INFO message")
+	if !strings.Contains(buf.String(), `"data":{"user":"user-3"}`) {
+		t.Errorf("output %s does not contain the converted value", buf.String())
+	}
+
+	buf.Reset()
+	New(WithConsoleOutput()).With(Fields{"user": &user{ID: 3}}).WithOutput(buf).Info("INFO message")
+	if !strings.Contains(buf.String(), "user=user-3") {
+		t.Errorf("console output %s does not contain the converted value", buf.String())
+	}
+
+	RegisterFieldEncoder(&user{}, nil)
+	buf.Reset()
+	New().With(Fields{"user": &user{ID: 3, Name: "Mauricio"}}).WithOutput(buf).Info("INFO message")
+	if !strings.Contains(buf.String(), `"data":{"user":{"ID":"user-3","Name":"Mauricio"}}`) {
+		t.Errorf("output %s does not contain the default encoding", buf.String())
+	}
+}
@@ -0,0 +1,36 @@
+package logger
+
+// Filter tells whether an entry is written. It runs after the redaction and before the hooks,
+// and must not modify the payload
+type Filter func(p *Payload) bool
+
+// AddFilter registers a filter dropping the entries it returns false for, e.g. the health checks:
+//
+//	log.AddFilter(func(p *logger.Payload) bool {
+//		return p.HTTPRequest == nil || p.HTTPRequest.RequestURL != "/healthz"
+//	})
+//
+// The filters are run in the order they were added, the first one returning false dropping the
+// entry. They are shared with the loggers derived from l, and with the one l derives from
+func (l *Log) AddFilter(f Filter) {
+	l.hooks.mu.Lock()
+	defer l.hooks.mu.Unlock()
+	l.hooks.filters = append(l.hooks.filters, f)
+}
+
+// keep runs the filters on the payload, telling whether it is written
+func (hs *hookSet) keep(p *Payload) bool {
+	hs.mu.RLock()
+	filters := hs.filters
+	hs.mu.RUnlock()
+
+	if len(filters) > 0 {
+		p.formatEventTime()
+	}
+	for _, f := range filters {
+		if !f(p) {
+			return false
+		}
+	}
+	return true
+}
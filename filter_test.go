@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithSequence())
+
+	var fired int
+	log.AddHook(HookFunc(func(p *Payload) error {
+		fired++
+		return nil
+	}))
+	log.AddFilter(func(p *Payload) bool {
+		return p.HTTPRequest == nil || p.HTTPRequest.RequestURL != "/healthz"
+	})
+	log.AddFilter(func(p *Payload) bool {
+		return p.Context != nil && p.Context.Data["tenant"] != nil
+	})
+
+	// The filters are shared with the derived loggers
+	tenant := log.With(Fields{"tenant": "acme"}).WithOutput(buf)
+	tenant.Info("kept")
+	tenant.WithHTTPRequest(&HTTPRequest{RequestURL: "/healthz"}).Info("health check")
+	log.With(Fields{"user": "+1234567890"}).WithOutput(buf).Info("no tenant")
+	tenant.Info("kept again")
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"seq":1,`) || !strings.Contains(lines[1], `"seq":2,`) || !strings.Contains(lines[1], "kept again") {
+		t.Errorf("unexpected entries %s", buf.String())
+	}
+	if fired != 2 {
+		t.Errorf("expected the hooks to fire for the kept entries only; got %d", fired)
+	}
+}
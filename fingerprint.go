@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// fingerprintFrames is the number of application frames identifying an error
+const fingerprintFrames = 3
+
+// WithFingerprint adds a "fingerprint" to the ERROR and CRITICAL entries, the same for the repeated
+// occurrences of an error, so the alerting can group them even when their messages contain
+// variable data. It is derived from the message, with the numbers, hex IDs and quoted strings
+// masked, and from the functions of the top application frames of the stack
+func WithFingerprint() Option {
+	return func(l *Log) {
+		l.fingerprint = true
+	}
+}
+
+// messageVariables matches the parts of the messages that differ between the occurrences of an
+// error: the quoted strings, the UUIDs and hex IDs, and the numbers
+var messageVariables = regexp.MustCompile(`"[^"]*"|'[^']*'|\b[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}\b|\b(0x)?[0-9a-fA-F]*[0-9][0-9a-fA-F]*\b`)
+
+// messageTemplate masks the variable parts of a message
+func messageTemplate(message string) string {
+	return messageVariables.ReplaceAllString(message, "?")
+}
+
+// fingerprint returns the grouping key of an error: a hash of its message template and of the
+// functions of the top application frames, the runtime and logger frames being skipped
+func fingerprint(message string, pcs []uintptr) string {
+	h := sha256.New()
+	h.Write([]byte(messageTemplate(message)))
+
+	n := 0
+	for _, f := range stackFrames(pcs, 0, nil) {
+		if n == fingerprintFrames {
+			break
+		}
+		if isRuntimeFrame(f) || isLoggerFrame(f) {
+			continue
+		}
+		h.Write([]byte{'\n'})
+		h.Write([]byte(f.Function))
+		n++
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// loggerDir is the directory of the logger sources
+var loggerDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// isLoggerFrame tells whether a frame is inside the logger, its tests excluded
+func isLoggerFrame(f StackFrame) bool {
+	return filepath.Dir(f.File) == loggerDir && !strings.HasSuffix(f.File, "_test.go")
+}
+
+// isRuntimeFrame tells whether a frame is inside the Go runtime
+func isRuntimeFrame(f StackFrame) bool {
+	return strings.HasPrefix(f.Function, "runtime.")
+}
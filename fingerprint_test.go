@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"user 42 not found", "user ? not found"},
+		{`cannot open "/tmp/a.txt": denied`, "cannot open ?: denied"},
+		{"order 7c1a5ea4-8dc4-4e5e-9a4c-3c1e5d1e8a42 failed", "order ? failed"},
+		{"trace 105445aa7843bc8bf206b12000100000 at 0x1f", "trace ? at ?"},
+		{"database is down", "database is down"},
+	}
+	for _, test := range tests {
+		if got := messageTemplate(test.message); got != test.expected {
+			t.Errorf("%q: expected %q; got %q", test.message, test.expected, got)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithFingerprint())
+
+	logUserError := func(id int) {
+		log.Errorf("user %d not found", id)
+	}
+	logUserError(1)
+	logUserError(2)
+	log.Errorf("user %d not found", 3)
+	log.Error("database is down")
+	log.Info("INFO message")
+
+	var fingerprints []string
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		p := Payload{}
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatalf("entry %s cannot be unmarshalled: %s", line, err.Error())
+		}
+		fingerprints = append(fingerprints, p.Fingerprint)
+	}
+
+	if len(fingerprints) != 5 || len(fingerprints[0]) != 16 {
+		t.Fatalf("unexpected fingerprints %v", fingerprints)
+	}
+	if fingerprints[0] != fingerprints[1] {
+		t.Errorf("expected the same fingerprint for the same error; got %v", fingerprints)
+	}
+	if fingerprints[2] == fingerprints[0] || fingerprints[3] == fingerprints[2] {
+		t.Errorf("expected other fingerprints for other locations and messages; got %v", fingerprints)
+	}
+	if fingerprints[4] != "" {
+		t.Errorf("expected no fingerprint for INFO; got %s", fingerprints[4])
+	}
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// recordSeparator starts the records of a JSON text sequence, see RFC 7464
+const recordSeparator = 0x1e
+
+// maxFrameLength is the maximum length of an entry read by the SplitFunc of LengthPrefixFraming
+const maxFrameLength = 64 << 20
+
+// Framing delimits the entries written to the output, so the ones containing raw newlines, e.g.
+// from WithPrettyJSON or a binary encoder, can be split back by the reader of a stream
+type Framing int
+
+const (
+	// NewlineFraming ends each entry with a newline, unless the encoder is binary. It is the default
+	NewlineFraming Framing = iota
+	// LengthPrefixFraming prefixes each entry with its length as a 4 bytes big endian integer,
+	// without a trailing newline
+	LengthPrefixFraming
+	// RecordSeparatorFraming starts each entry with the 0x1E record separator and ends it with a
+	// newline, the application/json-seq format of RFC 7464
+	RecordSeparatorFraming
+)
+
+// WithFraming sets the delimiting of the entries written to the output. The framings other than
+// NewlineFraming are meant for streams such as files and sockets, the writers sending the
+// entries to an API split them on the newlines
+func WithFraming(f Framing) Option {
+	return func(l *Log) {
+		l.framing = f
+	}
+}
+
+// encode appends the framed encoded payload to buf
+func (f Framing) encode(e Encoder, buf *bytes.Buffer, p *Payload) error {
+	switch f {
+	case LengthPrefixFraming:
+		start := buf.Len()
+		buf.Write([]byte{0, 0, 0, 0})
+		if err := encodeEntry(e, buf, p); err != nil {
+			return err
+		}
+		b := buf.Bytes()[start:]
+		binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+		return nil
+
+	case RecordSeparatorFraming:
+		buf.WriteByte(recordSeparator)
+		if err := encodeEntry(e, buf, p); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		return nil
+	}
+	return encode(e, buf, p)
+}
+
+// SplitFunc returns a bufio.SplitFunc splitting a stream written with the framing into entries,
+// without their delimiters:
+//
+//	s := bufio.NewScanner(conn)
+//	s.Split(logger.LengthPrefixFraming.SplitFunc())
+//	for s.Scan() {
+//		entry := s.Bytes()
+//	}
+//
+// The scanner buffer must be large enough for the longest entry, see bufio.Scanner.Buffer
+func (f Framing) SplitFunc() bufio.SplitFunc {
+	switch f {
+	case LengthPrefixFraming:
+		return splitLengthPrefixed
+	case RecordSeparatorFraming:
+		return splitRecords
+	}
+	return bufio.ScanLines
+}
+
+// splitLengthPrefixed splits the entries prefixed with their length
+func splitLengthPrefixed(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) < 4 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, errors.New("logger: truncated length prefix")
+		}
+		return 0, nil, nil
+	}
+
+	n := binary.BigEndian.Uint32(data)
+	if n > maxFrameLength {
+		return 0, nil, errors.New("logger: frame length exceeds the maximum")
+	}
+	if len(data) < 4+int(n) {
+		if atEOF {
+			return 0, nil, errors.New("logger: truncated frame")
+		}
+		return 0, nil, nil
+	}
+	return 4 + int(n), data[4 : 4+n], nil
+}
+
+// splitRecords splits the records of a JSON text sequence, ignoring the empty ones
+func splitRecords(data []byte, atEOF bool) (int, []byte, error) {
+	start := 0
+	for start < len(data) && data[start] == recordSeparator {
+		start++
+	}
+	if i := bytes.IndexByte(data[start:], recordSeparator); i >= 0 {
+		return start + i, bytes.TrimRight(data[start:start+i], "\n"), nil
+	}
+	if atEOF {
+		if start == len(data) {
+			return len(data), nil, nil
+		}
+		return len(data), bytes.TrimRight(data[start:], "\n"), nil
+	}
+	return start, nil, nil
+}
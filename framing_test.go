@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestFraming(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	tests := []struct {
+		name    string
+		framing Framing
+		opts    []Option
+		prefix  string
+	}{
+		{"newline", NewlineFraming, nil, "{"},
+		{"length prefix", LengthPrefixFraming, []Option{WithPrettyJSON()}, "\x00\x00"},
+		{"record separator", RecordSeparatorFraming, []Option{WithPrettyJSON()}, "\x1e{\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			log := New(append(tt.opts, WithWriter(buf), WithFraming(tt.framing))...)
+			log.With(Fields{"text": "line 1\nline 2"}).Info("first message")
+			log.Warn("second message")
+
+			if !bytes.HasPrefix(buf.Bytes(), []byte(tt.prefix)) {
+				t.Errorf("output %q does not start with %q", buf, tt.prefix)
+			}
+
+			s := bufio.NewScanner(buf)
+			s.Split(tt.framing.SplitFunc())
+			var messages []string
+			for s.Scan() {
+				var p Payload
+				if err := json.Unmarshal(s.Bytes(), &p); err != nil {
+					t.Fatalf("cannot decode entry %q: %s", s.Bytes(), err.Error())
+				}
+				messages = append(messages, p.Message)
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(messages) != 2 || messages[0] != "first message" || messages[1] != "second message" {
+				t.Errorf("unexpected entries %q", messages)
+			}
+		})
+	}
+}
+
+func TestFramingBinaryEncoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithMsgpackOutput(), WithFraming(LengthPrefixFraming))
+	log.Info("first message")
+	log.Info("second message")
+
+	s := bufio.NewScanner(buf)
+	s.Split(LengthPrefixFraming.SplitFunc())
+	n := 0
+	for s.Scan() {
+		m, err := NewMsgpackDecoder(bytes.NewReader(s.Bytes())).Decode()
+		if err != nil {
+			t.Fatalf("cannot decode entry %d: %s", n, err.Error())
+		}
+		if m["message"] == nil {
+			t.Errorf("unexpected entry %v", m)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 entries; got %d", n)
+	}
+}
+
+func TestSplitFuncTruncated(t *testing.T) {
+	for _, data := range []string{"\x00\x00", "\x00\x00\x00\x05abc"} {
+		s := bufio.NewScanner(bytes.NewBufferString(data))
+		s.Split(LengthPrefixFraming.SplitFunc())
+		for s.Scan() {
+			t.Errorf("unexpected entry %q", s.Bytes())
+		}
+		if s.Err() == nil {
+			t.Errorf("expected an error for the truncated stream %q", data)
+		}
+	}
+
+	s := bufio.NewScanner(bytes.NewBufferString("\x1e\x1e{\"a\":1}\n\x1e"))
+	s.Split(RecordSeparatorFraming.SplitFunc())
+	var records []string
+	for s.Scan() {
+		records = append(records, s.Text())
+	}
+	if len(records) != 1 || records[0] != `{"a":1}` {
+		t.Errorf("unexpected records %q", records)
+	}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"os"
+)
+
+// GCPEnvironment describes the GCP runtime the program runs on
+type GCPEnvironment struct {
+	// ProjectID is the project the program runs in
+	ProjectID string
+	// Resource is the monitored resource running the program, of type "global" outside GCP
+	Resource *MonitoredResource
+	// Service and Version identify the Cloud Run service, Cloud Function or App Engine service
+	// and their revision, they are empty on GCE and GKE
+	Service string
+	Version string
+}
+
+// DetectGCPEnvironment detects the GCP runtime, GCE, GKE, Cloud Run, Cloud Functions or App Engine,
+// from the environment variables they set and from the metadata server. It takes up to a few
+// seconds outside GCP, where the metadata server cannot be reached
+func DetectGCPEnvironment() *GCPEnvironment {
+	return detectGCPEnvironment(os.Getenv, metadataValue)
+}
+
+func detectGCPEnvironment(getenv func(string) string, metadata func(string) (string, error)) *GCPEnvironment {
+	env := &GCPEnvironment{
+		ProjectID: getenv("GOOGLE_CLOUD_PROJECT"),
+		Resource:  detectResource(getenv, metadata),
+	}
+	if env.ProjectID == "" {
+		env.ProjectID, _ = metadata("project/project-id")
+	}
+
+	switch env.Resource.Type {
+	case "cloud_function":
+		env.Service = getenv("K_SERVICE")
+		env.Version = getenv("K_REVISION")
+	case "cloud_run_revision":
+		env.Service = getenv("K_SERVICE")
+		env.Version = getenv("K_REVISION")
+	case "gae_app":
+		env.Service = getenv("GAE_SERVICE")
+		env.Version = getenv("GAE_VERSION")
+	case "gce_instance":
+		if name, err := metadata("instance/name"); err == nil {
+			env.Resource.Labels["instance_name"] = name
+		}
+	}
+	return env
+}
+
+// WithGCPDetection detects the GCP runtime with DetectGCPEnvironment and adds the project ID and
+// the labels of the monitored resource, such as the region and the instance or pod name, to the
+// labels of every entry. The serviceContext is set from the runtime when the SERVICE and VERSION
+// environment variables are not
+func WithGCPDetection() Option {
+	return func(l *Log) {
+		withGCPEnvironment(l, DetectGCPEnvironment())
+	}
+}
+
+func withGCPEnvironment(l *Log, env *GCPEnvironment) {
+	if env.Resource.Type == "global" && env.ProjectID == "" {
+		// Not on GCP
+		return
+	}
+
+	p := *l.payload
+	if p.ServiceContext == nil && env.Service != "" {
+		p.ServiceContext = &ServiceContext{
+			Service: env.Service,
+			Version: env.Version,
+		}
+	}
+
+	labels := make(map[string]string, len(p.Labels)+len(env.Resource.Labels)+1)
+	for k, v := range env.Resource.Labels {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	if env.ProjectID != "" {
+		labels["project_id"] = env.ProjectID
+	}
+	// The labels set by the application take precedence
+	for k, v := range p.Labels {
+		labels[k] = v
+	}
+	p.Labels = labels
+	l.payload = &p
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDetectGCPEnvironment(t *testing.T) {
+	metadata := func(path string) (string, error) {
+		switch path {
+		case "project/project-id":
+			return "my-project", nil
+		case "instance/region":
+			return "projects/123/regions/us-central1", nil
+		case "instance/zone":
+			return "projects/123/zones/us-central1-a", nil
+		case "instance/id":
+			return "42", nil
+		case "instance/name":
+			return "my-instance", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	env := map[string]string{"K_SERVICE": "api", "K_REVISION": "api-00001", "K_CONFIGURATION": "api"}
+	e := detectGCPEnvironment(func(k string) string { return env[k] }, metadata)
+	if e.ProjectID != "my-project" || e.Service != "api" || e.Version != "api-00001" || e.Resource.Type != "cloud_run_revision" {
+		t.Errorf("unexpected Cloud Run environment %+v", e)
+	}
+
+	e = detectGCPEnvironment(func(string) string { return "" }, metadata)
+	if e.Service != "" || e.Resource.Labels["instance_name"] != "my-instance" {
+		t.Errorf("unexpected GCE environment %+v", e)
+	}
+}
+
+func TestWithGCPEnvironment(t *testing.T) {
+	initConfig(DEBUG, "", "")
+	defer initConfig(DEBUG, "my-app", "1.0")
+
+	env := &GCPEnvironment{
+		ProjectID: "my-project",
+		Resource: &MonitoredResource{
+			Type:   "cloud_run_revision",
+			Labels: map[string]string{"service_name": "api", "location": "us-central1", "configuration_name": ""},
+		},
+		Service: "api",
+		Version: "api-00001",
+	}
+
+	buf := new(bytes.Buffer)
+	log := New().WithLabels(map[string]string{"location": "custom"})
+	withGCPEnvironment(log, env)
+	log.WithOutput(buf).Info("INFO message")
+
+	expected := `"serviceContext":{"service":"api","version":"api-00001"},"context":{},"logging.googleapis.com/labels":{"location":"custom","project_id":"my-project","service_name":"api"}}`
+	if !strings.HasSuffix(strings.TrimRight(buf.String(), "\n"), expected) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+}
+
+func TestWithGCPEnvironmentOutsideGCP(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New()
+	withGCPEnvironment(log, &GCPEnvironment{Resource: &MonitoredResource{Type: "global"}})
+	if log.payload.Labels != nil {
+		t.Errorf("unexpected labels %v outside GCP", log.payload.Labels)
+	}
+}
@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultGELFChunkSize is the size of the UDP datagrams recommended by Graylog for the WAN
+const defaultGELFChunkSize = 1420
+
+// gelfMaxChunks is the maximum number of chunks of a GELF message
+const gelfMaxChunks = 128
+
+// gelfInvalidKey matches the characters not allowed in the names of the GELF additional fields
+var gelfInvalidKey = regexp.MustCompile(`[^\w.\-]`)
+
+// GELFEncoder encodes a payload as a GELF 1.1 message for Graylog: the severity is mapped to the
+// syslog levels, the stacktrace is the full_message and the context fields, the service context
+// and the report location are additional fields, prefixed with an underscore
+type GELFEncoder struct {
+	// Host is the host field of the messages, os.Hostname() by default
+	Host string
+}
+
+// Encode formats the payload as a single GELF message
+func (e GELFEncoder) Encode(p *Payload) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := e.encodeTo(buf, p)
+	return buf.Bytes(), err
+}
+
+func (e GELFEncoder) encodeTo(buf *bytes.Buffer, p *Payload) error {
+	host := e.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	if host == "" {
+		host = "unknown"
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, p.EventTime)
+	if err != nil {
+		// The time format was changed with WithTimeFormat
+		t = time.Now()
+	}
+
+	lvl, ok := logLevelValue[p.Severity]
+	if !ok {
+		lvl = INFO
+	}
+
+	buf.WriteString(`{"version":"1.1","host":`)
+	writeJSONString(buf, host)
+	buf.WriteString(`,"short_message":`)
+	writeJSONString(buf, p.Message)
+	if p.Stacktrace != "" {
+		buf.WriteString(`,"full_message":`)
+		writeJSONString(buf, p.Stacktrace)
+	}
+	fmt.Fprintf(buf, `,"timestamp":%d.%06d,"level":%d`, t.Unix(), t.Nanosecond()/1000, syslogSeverity[lvl])
+
+	fields := gelfFields(p)
+	for _, k := range sortedKeys(fields) {
+		buf.WriteString(`,`)
+		writeJSONString(buf, k)
+		buf.WriteByte(':')
+		if err := writeGELFValue(buf, fields[k]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// gelfFields returns the additional fields of a message. The context fields do not override the other ones
+func gelfFields(p *Payload) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if sc := p.ServiceContext; sc != nil {
+		fields["_service"] = sc.Service
+		fields["_version"] = sc.Version
+	}
+	if p.Caller != "" {
+		fields["_caller"] = p.Caller
+	}
+	if p.Trace != "" {
+		fields["_trace"] = p.Trace
+	}
+	if p.SpanID != "" {
+		fields["_span_id"] = p.SpanID
+	}
+	if p.InsertID != "" {
+		fields["_insert_id"] = p.InsertID
+	}
+	if p.Fingerprint != "" {
+		fields["_fingerprint"] = p.Fingerprint
+	}
+	for k, v := range p.Labels {
+		fields["_label_"+gelfInvalidKey.ReplaceAllString(k, "_")] = v
+	}
+
+	if c := p.Context; c != nil {
+		if loc := c.ReportLocation; loc != nil {
+			fields["_file"] = loc.FilePath
+			fields["_line"] = loc.LineNumber
+			fields["_function"] = loc.FunctionName
+		}
+		for k, v := range c.Data {
+			key := "_" + gelfInvalidKey.ReplaceAllString(k, "_")
+			if key == "_id" {
+				// Reserved by Graylog
+				key = "__id"
+			}
+			if _, ok := fields[key]; !ok {
+				fields[key] = v
+			}
+		}
+	}
+	return fields
+}
+
+// writeGELFValue writes an additional field value, which GELF restricts to strings and numbers
+func writeGELFValue(buf *bytes.Buffer, v interface{}) error {
+	if conv, ok := convertFieldValue(v); ok {
+		v = conv
+	}
+
+	switch val := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return writeJSONValue(buf, val)
+	case string:
+		writeJSONString(buf, val)
+		return nil
+	case nil:
+		writeJSONString(buf, "null")
+		return nil
+	case bool:
+		writeJSONString(buf, strconv.FormatBool(val))
+		return nil
+	}
+
+	// Composite values are sent as their JSON encoding
+	composite := new(bytes.Buffer)
+	if err := writeJSONValue(composite, v); err != nil {
+		return err
+	}
+	writeJSONString(buf, composite.String())
+	return nil
+}
+
+// WithGELF sends the log entries to Graylog as GELF messages, over "udp" or "tcp"
+func WithGELF(network, addr string) Option {
+	return func(l *Log) {
+		l.encoder = GELFEncoder{}
+		l.writer = &GELFWriter{
+			Network: network,
+			Addr:    addr,
+		}
+	}
+}
+
+// WithGELFTLS sends the log entries to Graylog as GELF messages over TCP, encrypted with the given
+// configuration, see NewTLSConfig
+func WithGELFTLS(addr string, cfg *tls.Config) Option {
+	return func(l *Log) {
+		l.encoder = GELFEncoder{}
+		l.writer = &GELFWriter{
+			Network:   "tcp",
+			Addr:      addr,
+			TLSConfig: cfg,
+		}
+	}
+}
+
+// GELFWriter is an io.Writer sending each GELF message to a Graylog input. Over UDP, the messages
+// larger than ChunkSize are chunked; over TCP, they are delimited with a null byte.
+// It connects lazily on the first write and reconnects when a write fails.
+type GELFWriter struct {
+	// Network is "udp" or "tcp"
+	Network string
+	// Addr is the address of the Graylog input
+	Addr string
+	// ChunkSize is the maximum size of the UDP datagrams, 1420 by default
+	ChunkSize int
+	// TLSConfig encrypts the "tcp" connections when not nil, see NewTLSConfig
+	TLSConfig *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Write sends p as a GELF message, p must be a GELFEncoder output
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	msg := bytes.TrimRight(p, "\n")
+	var packets [][]byte
+	if w.udp() {
+		var err error
+		if packets, err = w.chunks(msg); err != nil {
+			return 0, err
+		}
+	} else {
+		packets = [][]byte{append(msg[:len(msg):len(msg)], 0)}
+	}
+
+	// Retry once with a fresh connection, the server may have closed the previous one
+	var err error
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if w.conn, err = dial(w.Network, w.Addr, w.TLSConfig); err != nil {
+				return 0, err
+			}
+		}
+
+		if err = writePackets(w.conn, packets); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+func writePackets(conn net.Conn, packets [][]byte) error {
+	for _, packet := range packets {
+		if _, err := conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the server
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *GELFWriter) udp() bool {
+	return w.Network == "udp" || w.Network == "udp4" || w.Network == "udp6"
+}
+
+// chunks splits a message in GELF chunks: magic bytes, message ID, sequence number and count, then the data
+func (w *GELFWriter) chunks(msg []byte) ([][]byte, error) {
+	size := w.ChunkSize
+	if size <= 0 {
+		size = defaultGELFChunkSize
+	}
+	if len(msg) <= size {
+		return [][]byte{msg}, nil
+	}
+
+	const header = 12
+	data := size - header
+	if data <= 0 {
+		return nil, errors.New("logger: GELF chunk size too small")
+	}
+	count := (len(msg) + data - 1) / data
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("logger: GELF message of %d bytes needs more than %d chunks", len(msg), gelfMaxChunks)
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * data
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk := make([]byte, 0, header+end-i*data)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*data:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFEncoder(t *testing.T) {
+	p := &Payload{
+		Severity:       "ERROR",
+		EventTime:      "2017-04-26T02:29:33.412587367-04:00",
+		Message:        "ERROR message",
+		ServiceContext: &ServiceContext{Service: "my-app", Version: "1.0"},
+		Context: &Context{
+			Data: Fields{"id": 7, "user name": "+1234567890", "retry": true, "tags": []string{"a"}, "err": errTest},
+			ReportLocation: &ReportLocation{
+				FilePath:     "/go/src/app/main.go",
+				FunctionName: "main.main",
+				LineNumber:   15,
+			},
+		},
+		Stacktrace: "goroutine 1 [running]:\nmain.main()\n",
+	}
+
+	got, err := GELFEncoder{Host: "web-1"}.Encode(p)
+	if err != nil {
+		t.Fatalf("cannot encode payload: %s", err.Error())
+	}
+
+	expected := `{"version":"1.1","host":"web-1","short_message":"ERROR message","full_message":"goroutine 1 [running]:\nmain.main()\n",` +
+		`"timestamp":1493188173.412587,"level":3,"__id":7,"_err":"test error","_file":"/go/src/app/main.go","_function":"main.main",` +
+		`"_line":15,"_retry":"true","_service":"my-app","_tags":"[\"a\"]","_user_name":"+1234567890","_version":"1.0"}`
+	if string(got) != expected {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+type testError struct{}
+
+func (testError) Error() string {
+	return "test error"
+}
+
+var errTest error = testError{}
+
+func TestGELFWriterUDPChunks(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %s", err.Error())
+	}
+	defer conn.Close()
+
+	w := &GELFWriter{Network: "udp", Addr: conn.LocalAddr().String(), ChunkSize: 32}
+	defer w.Close()
+
+	msg := `{"version":"1.1","host":"web-1","short_message":"a message longer than a single chunk"}`
+	if _, err := w.Write([]byte(msg + "\n")); err != nil {
+		t.Fatalf("cannot write: %s", err.Error())
+	}
+
+	var data []byte
+	count := -1
+	for i := 0; i != count; i++ {
+		packet := make([]byte, 64)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(packet)
+		if err != nil {
+			t.Fatalf("cannot read chunk %d: %s", i, err.Error())
+		}
+		packet = packet[:n]
+
+		if packet[0] != 0x1e || packet[1] != 0x0f || int(packet[10]) != i {
+			t.Fatalf("invalid chunk header % x", packet[:12])
+		}
+		count = int(packet[11])
+		data = append(data, packet[12:]...)
+	}
+
+	if count != 5 || string(data) != msg {
+		t.Errorf("unexpected message %s in %d chunks", data, count)
+	}
+}
+
+func TestGELFWriterTCP(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on TCP: %s", err.Error())
+	}
+	defer ln.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			messages <- strings.TrimSuffix(msg, "\x00")
+		}
+	}()
+
+	log := New(WithGELF("tcp", ln.Addr().String()))
+	log.With(Fields{"key": "value"}).WithOutput(log.writer).Warn("WARN message")
+	log.Info("INFO message")
+
+	for _, expected := range []string{"WARN message", "INFO message"} {
+		select {
+		case msg := <-messages:
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(msg), &m); err != nil {
+				t.Fatalf("invalid message %q: %s", msg, err.Error())
+			}
+			if m["short_message"] != expected || m["version"] != "1.1" {
+				t.Errorf("unexpected message %s", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no message received")
+		}
+	}
+}
+
+func TestGELFWriterTooLarge(t *testing.T) {
+	w := &GELFWriter{Network: "udp", Addr: "127.0.0.1:9", ChunkSize: 13}
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 200)); err == nil {
+		t.Errorf("expected an error for a message needing too many chunks")
+	}
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Hook is run for every log entry of the levels it was added for, right before the entry is
+// encoded. Hooks can modify the payload, e.g. to enrich its context data, but must not keep a
+// reference to it after Fire returns as it is reused for later entries
+type Hook interface {
+	Fire(p *Payload) error
+}
+
+// HookFunc adapts a function to the Hook interface
+type HookFunc func(p *Payload) error
+
+// Fire calls f(p)
+func (f HookFunc) Fire(p *Payload) error {
+	return f(p)
+}
+
+// hookSet holds the hooks and the filters of a Log, shared with all the loggers derived from it
+type hookSet struct {
+	mu           sync.RWMutex
+	hooks        map[severity][]Hook
+	filters      []Filter
+	transformers []Transformer
+}
+
+// AddHook registers a hook for the given levels, or for all of them when none is given.
+// The hook is shared with the loggers derived from l, and with the one l derives from
+func (l *Log) AddHook(h Hook, levels ...severity) {
+	if len(levels) == 0 {
+		levels = []severity{TRACE, DEBUG, INFO, WARN, ERROR, CRITICAL}
+	}
+
+	l.hooks.mu.Lock()
+	defer l.hooks.mu.Unlock()
+	if l.hooks.hooks == nil {
+		l.hooks.hooks = make(map[severity][]Hook)
+	}
+	for _, lvl := range levels {
+		l.hooks.hooks[lvl] = append(l.hooks.hooks[lvl], h)
+	}
+}
+
+// fire runs the hooks registered for the payload severity
+func (hs *hookSet) fire(p *Payload) {
+	hs.mu.RLock()
+	hooks := hs.hooks[logLevelValue[p.Severity]]
+	hs.mu.RUnlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	p.formatEventTime()
+	copyContext(p)
+	for _, h := range hooks {
+		if err := h.Fire(p); err != nil {
+			reportError(fmt.Errorf("logger: hook failed: %s", err.Error()))
+		}
+	}
+}
+
+// copyContext replaces the context of the payload, shared with the Log, with a copy that can
+// safely be modified
+func copyContext(p *Payload) {
+	if p.Context == nil {
+		return
+	}
+
+	data := make(Fields, len(p.Context.Data))
+	for k, v := range p.Context.Data {
+		data[k] = v
+	}
+	c := *p.Context
+	c.Data = data
+	p.Context = &c
+}
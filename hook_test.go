@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHookFiresForItsLevels(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New().WithOutput(buf)
+
+	var fired []string
+	log.AddHook(HookFunc(func(p *Payload) error {
+		fired = append(fired, p.Severity)
+		return nil
+	}), ERROR, CRITICAL)
+
+	log.Info("INFO message")
+	log.With(Fields{"key": "value"}).Error("ERROR message")
+
+	if len(fired) != 1 || fired[0] != "ERROR" {
+		t.Errorf("expected the hook to fire only for ERROR; got %v", fired)
+	}
+}
+
+func TestHookEnrichesPayload(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New().With(Fields{"key": "value"}).WithOutput(buf)
+
+	log.AddHook(HookFunc(func(p *Payload) error {
+		p.Context.Data["enriched"] = true
+		return nil
+	}))
+
+	log.Info("INFO message")
+	got := strings.TrimRight(buf.String(), "\n")
+	if !strings.Contains(got, `"context":{"data":{"enriched":true,"key":"value"}}`) {
+		t.Errorf("output %s does not contain the enriched context", got)
+	}
+
+	// The logger context itself is not modified by the hook
+	if _, ok := log.fields()["enriched"]; ok {
+		t.Errorf("the hook modified the logger context")
+	}
+}
+
+func TestHookErrorDoesNotDropEntry(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New().WithOutput(buf)
+
+	log.AddHook(HookFunc(func(p *Payload) error {
+		return errors.New("hook failure")
+	}))
+
+	log.Warn("WARN message")
+	if !strings.Contains(buf.String(), `"message":"WARN message"`) {
+		t.Errorf("output %s does not contain the entry", buf.String())
+	}
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPRequest describes the HTTP request an entry is about, as the Cloud Logging "httpRequest",
+// see https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
+type HTTPRequest struct {
+	RequestMethod string
+	RequestURL    string
+	RequestSize   int64
+	Status        int
+	ResponseSize  int64
+	UserAgent     string
+	RemoteIP      string
+	Referer       string
+	Latency       time.Duration
+	Protocol      string
+}
+
+// httpRequestJSON is the JSON representation of an HTTPRequest, with the sizes as strings and the
+// latency as a duration in seconds, e.g. "0.25s"
+type httpRequestJSON struct {
+	RequestMethod string `json:"requestMethod,omitempty"`
+	RequestURL    string `json:"requestUrl,omitempty"`
+	RequestSize   int64  `json:"requestSize,string,omitempty"`
+	Status        int    `json:"status,omitempty"`
+	ResponseSize  int64  `json:"responseSize,string,omitempty"`
+	UserAgent     string `json:"userAgent,omitempty"`
+	RemoteIP      string `json:"remoteIp,omitempty"`
+	Referer       string `json:"referer,omitempty"`
+	Latency       string `json:"latency,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// NewHTTPRequest describes a request served with the given response status, size and latency
+func NewHTTPRequest(r *http.Request, status int, responseSize int64, latency time.Duration) *HTTPRequest {
+	req := &HTTPRequest{
+		RequestMethod: r.Method,
+		RequestURL:    r.URL.String(),
+		Status:        status,
+		ResponseSize:  responseSize,
+		UserAgent:     r.UserAgent(),
+		RemoteIP:      r.RemoteAddr,
+		Referer:       r.Referer(),
+		Latency:       latency,
+		Protocol:      r.Proto,
+	}
+	if r.ContentLength > 0 {
+		req.RequestSize = r.ContentLength
+	}
+	return req
+}
+
+// WithHTTPRequest creates a copy of a Log whose entries are about the HTTP request
+func (l *Log) WithHTTPRequest(r *HTTPRequest) *Log {
+	n := l.With(Fields{})
+	n.payload.HTTPRequest = r
+	return n
+}
+
+// MarshalJSON encodes the request in the format of Cloud Logging
+func (r HTTPRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(httpRequestJSON{
+		RequestMethod: r.RequestMethod,
+		RequestURL:    r.RequestURL,
+		RequestSize:   r.RequestSize,
+		Status:        r.Status,
+		ResponseSize:  r.ResponseSize,
+		UserAgent:     r.UserAgent,
+		RemoteIP:      r.RemoteIP,
+		Referer:       r.Referer,
+		Latency:       formatLatency(r.Latency),
+		Protocol:      r.Protocol,
+	})
+}
+
+// UnmarshalJSON decodes a request in the format of Cloud Logging
+func (r *HTTPRequest) UnmarshalJSON(b []byte) error {
+	var j httpRequestJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	var latency time.Duration
+	if j.Latency != "" {
+		var err error
+		if latency, err = time.ParseDuration(j.Latency); err != nil {
+			return err
+		}
+	}
+
+	*r = HTTPRequest{
+		RequestMethod: j.RequestMethod,
+		RequestURL:    j.RequestURL,
+		RequestSize:   j.RequestSize,
+		Status:        j.Status,
+		ResponseSize:  j.ResponseSize,
+		UserAgent:     j.UserAgent,
+		RemoteIP:      j.RemoteIP,
+		Referer:       j.Referer,
+		Latency:       latency,
+		Protocol:      j.Protocol,
+	}
+	return nil
+}
+
+// formatLatency formats a duration in seconds, e.g. "0.25s", or returns "" for zero
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	s := strconv.FormatFloat(d.Seconds(), 'f', 9, 64)
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".") + "s"
+}
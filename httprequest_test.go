@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPRequest(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	r := httptest.NewRequest("POST", "/users?page=2", strings.NewReader(`{"name":"Mauricio"}`))
+	r.Header.Set("User-Agent", "curl/7.64.1")
+
+	buf := new(bytes.Buffer)
+	New().WithOutput(buf).WithHTTPRequest(NewHTTPRequest(r, 201, 42, 250*time.Millisecond)).Info("request served")
+
+	expected := `"httpRequest":{"requestMethod":"POST","requestUrl":"/users?page=2","requestSize":"19","status":201,"responseSize":"42","userAgent":"curl/7.64.1","remoteIp":"192.0.2.1:1234","latency":"0.25s","protocol":"HTTP/1.1"}}`
+	if !strings.HasSuffix(strings.TrimRight(buf.String(), "\n"), expected) {
+		t.Errorf("output %s does not contain the request %s", buf.String(), expected)
+	}
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if p.HTTPRequest == nil || p.HTTPRequest.Latency != 250*time.Millisecond || p.HTTPRequest.RequestSize != 19 {
+		t.Errorf("unexpected request %+v", p.HTTPRequest)
+	}
+}
+
+func TestFormatLatency(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                       "",
+		time.Second:             "1s",
+		1500 * time.Millisecond: "1.5s",
+		time.Nanosecond:         "0.000000001s",
+		2 * time.Minute:         "120s",
+	}
+	for d, expected := range tests {
+		if got := formatLatency(d); got != expected {
+			t.Errorf("expected %s for %s; got %s", expected, d, got)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPWriter is an io.Writer posting the log entries to an HTTP endpoint, e.g. an internal log
+// collector or a webhook. The newline separated entries of a single Write, e.g. from a
+// BatchWriter, are posted in one request as a JSON array; the entries that are not JSON are
+// sent as strings.
+// The requests failing with a 429 or 5xx status, or a network error, are retried with an
+// exponential backoff, wrap it with NewAsyncWriter to keep it off the hot path.
+type HTTPWriter struct {
+	// URL is the endpoint the entries are posted to
+	URL string
+	// Header is added to the requests, e.g. an Authorization or an API key header
+	Header http.Header
+	// Username and Password set the basic authentication of the requests when not empty
+	Username, Password string
+	// Gzip compresses the request bodies
+	Gzip bool
+	// Client is the HTTP client used to post the entries, http.DefaultClient by default
+	Client *http.Client
+	// Attempts is the number of tries of a request, 3 by default
+	Attempts int
+	// Backoff is the delay before the first retry, doubled on each attempt, 1 second by default
+	Backoff time.Duration
+
+	once sync.Once
+}
+
+// WithHTTP posts the log entries to an HTTP endpoint
+func WithHTTP(url string) Option {
+	return func(l *Log) {
+		l.writer = &HTTPWriter{URL: url}
+	}
+}
+
+// Write posts the lines of p in one request
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	w.once.Do(w.init)
+
+	var entries []json.RawMessage
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			line, _ = json.Marshal(string(line))
+		}
+		entries = append(entries, line)
+	}
+	if len(entries) == 0 {
+		return len(p), nil
+	}
+
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return 0, err
+	}
+	if w.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		body = buf.Bytes()
+	}
+
+	if err := retryPost(w.Attempts, w.Backoff, func() (bool, error) { return w.post(body) }); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// init fills the settings not set by the user
+func (w *HTTPWriter) init() {
+	if w.Client == nil {
+		w.Client = http.DefaultClient
+	}
+	if w.Attempts <= 0 {
+		w.Attempts = 3
+	}
+	if w.Backoff <= 0 {
+		w.Backoff = time.Second
+	}
+}
+
+// post sends a request, reporting whether it can be retried when it fails
+func (w *HTTPWriter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range w.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if w.Username != "" || w.Password != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	return doPost(w.Client, req)
+}
+
+// doPost sends a request, reporting whether it can be retried when it fails with a network
+// error or a 429 or 5xx status
+func doPost(client *http.Client, req *http.Request) (bool, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	msg, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("logger: %s returned %s: %s", req.URL.Host, resp.Status, bytes.TrimSpace(msg))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// retryPost calls post until it succeeds, fails with an error that cannot be retried or the
+// attempts are exhausted, doubling the backoff between the attempts
+func retryPost(attempts int, backoff time.Duration, post func() (bool, error)) error {
+	for attempt := 1; ; attempt++ {
+		retry, err := post()
+		if err == nil || !retry || attempt >= attempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
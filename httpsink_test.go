@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPWriter(t *testing.T) {
+	var calls int
+	var entries []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("X-Api-Key") != "secret" || r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "app" || pass != "pwd" {
+			t.Errorf("unexpected basic auth %s:%s", user, pass)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("invalid gzip body: %s", err.Error())
+		}
+		b, _ := ioutil.ReadAll(zr)
+		if err := json.Unmarshal(b, &entries); err != nil {
+			t.Errorf("invalid body %s: %s", b, err.Error())
+		}
+	}))
+	defer server.Close()
+
+	w := &HTTPWriter{
+		URL:      server.URL,
+		Header:   http.Header{"X-Api-Key": {"secret"}},
+		Username: "app",
+		Password: "pwd",
+		Gzip:     true,
+		Backoff:  time.Millisecond,
+	}
+	if _, err := w.Write([]byte("{\"message\":\"first\"}\nplain text\n")); err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the 503 to be retried; got %d calls", calls)
+	}
+	if len(entries) != 2 || entries[0].(map[string]interface{})["message"] != "first" || entries[1] != "plain text" {
+		t.Errorf("unexpected entries %v", entries)
+	}
+}
+
+func TestHTTPWriterAttempts(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	w := &HTTPWriter{URL: server.URL, Attempts: 2, Backoff: time.Millisecond}
+	if _, err := w.Write([]byte("{}\n")); err == nil {
+		t.Errorf("expected an error")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts; got %d", calls)
+	}
+}
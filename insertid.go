@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// WithInsertID adds a unique "logging.googleapis.com/insertId" to every entry, so Cloud Logging
+// drops the copies of an entry written more than once, e.g. when a write is retried. The IDs
+// are generated by gen, or by NewInsertID when it is nil
+func WithInsertID(gen func() string) Option {
+	return func(l *Log) {
+		if gen == nil {
+			gen = NewInsertID
+		}
+		l.insertID = gen
+	}
+}
+
+// NewInsertID returns a random (version 4) UUID
+func NewInsertID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("logger: cannot generate a random insertId: %s", err.Error()))
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
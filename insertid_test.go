@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithInsertID(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithInsertID(nil)).WithOutput(buf)
+	log.Info("INFO message")
+	log.Info("INFO message")
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ids := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		p := Payload{}
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatalf("entry %s cannot be unmarshalled: %s", line, err.Error())
+		}
+		if !uuid.MatchString(p.InsertID) || ids[p.InsertID] {
+			t.Errorf("entry %s does not have a unique UUID insertId", line)
+		}
+		ids[p.InsertID] = true
+	}
+}
+
+func TestWithInsertIDGenerator(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	n := 0
+	gen := func() string {
+		n++
+		return "id-" + strconv.Itoa(n)
+	}
+
+	buf := new(bytes.Buffer)
+	New(WithInsertID(gen)).WithOutput(buf).Warn("WARN message")
+	if !strings.Contains(buf.String(), `"logging.googleapis.com/insertId":"id-1"`) {
+		t.Errorf("output %s does not contain the generated insertId", buf.String())
+	}
+}
@@ -0,0 +1,29 @@
+package logger
+
+// Logger is the interface implemented by *Log, so the code using a logger can depend on it and
+// be given a fake in its tests. The builders return a *Log, a fake can return one from Nop or
+// from the logtest package:
+//
+//	func NewServer(log logger.Logger) *Server
+type Logger interface {
+	Trace(message string)
+	Tracef(message string, args ...interface{})
+	Debug(message string)
+	Debugf(message string, args ...interface{})
+	Info(message string)
+	Infof(message string, args ...interface{})
+	Warn(message string)
+	Warnf(message string, args ...interface{})
+	Error(message string)
+	Errorf(message string, args ...interface{})
+	Fatal(message string)
+	Fatalf(message string, args ...interface{})
+
+	With(fields Fields) *Log
+	WithFields(fields ...Field) *Log
+	WithKV(keyvals ...interface{}) *Log
+	WithError(err error) *Log
+	Named(name string) *Log
+}
+
+var _ Logger = (*Log)(nil)
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeLogger records the messages, the builders return a logger discarding the entries
+type fakeLogger struct {
+	*Log
+	messages []string
+}
+
+func (f *fakeLogger) Info(message string) {
+	f.messages = append(f.messages, message)
+}
+
+func (f *fakeLogger) Infof(message string, args ...interface{}) {
+	f.Info(fmt.Sprintf(message, args...))
+}
+
+func greet(log Logger, name string) {
+	log.Infof("hello %s", name)
+}
+
+func TestLoggerInterface(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	greet(New().WithOutput(buf), "gopher")
+	if !strings.Contains(buf.String(), `"message":"hello gopher"`) {
+		t.Errorf("output %s does not contain the entry", buf.String())
+	}
+
+	fake := &fakeLogger{Log: Nop()}
+	greet(fake, "gopher")
+	if len(fake.messages) != 1 || fake.messages[0] != "hello gopher" {
+		t.Errorf("unexpected messages %q", fake.messages)
+	}
+}
@@ -0,0 +1,680 @@
+package logger
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The JSON encoding is written by hand for the types making up a Payload and for the most
+// common Fields values, falling back to encoding/json for any other value. The output is
+// byte for byte the same as json.Marshal, HTML escaping included, except for the Fields values
+// converted first, see RegisterFieldEncoder.
+
+func (e JSONEncoder) encodeTo(buf *bytes.Buffer, p *Payload) error {
+	if e.Indent == "" {
+		return writeJSONPayload(buf, p)
+	}
+
+	compact := getBuffer()
+	defer putBuffer(compact)
+	if err := writeJSONPayload(compact, p); err != nil {
+		return err
+	}
+	return json.Indent(buf, compact.Bytes(), "", e.Indent)
+}
+
+func writeJSONPayload(buf *bytes.Buffer, p *Payload) error {
+	buf.WriteString(`{"severity":`)
+	writeJSONString(buf, p.Severity)
+	buf.WriteString(`,"eventTime":`)
+	writeJSONEventTime(buf, p)
+	if p.Seq != 0 {
+		buf.WriteString(`,"seq":`)
+		buf.WriteString(strconv.FormatUint(p.Seq, 10))
+	}
+	if p.Caller != "" {
+		buf.WriteString(`,"caller":`)
+		writeJSONString(buf, p.Caller)
+	}
+	buf.WriteString(`,"message":`)
+	writeJSONString(buf, p.Message)
+
+	if sc := p.ServiceContext; sc != nil {
+		buf.WriteString(`,"serviceContext":{`)
+		sep := ""
+		if sc.Service != "" {
+			buf.WriteString(`"service":`)
+			writeJSONString(buf, sc.Service)
+			sep = ","
+		}
+		if sc.Version != "" {
+			buf.WriteString(sep + `"version":`)
+			writeJSONString(buf, sc.Version)
+		}
+		buf.WriteByte('}')
+	}
+
+	if c := p.Context; c != nil {
+		buf.WriteString(`,"context":{`)
+		sep := ""
+		if len(c.Data) > 0 {
+			buf.WriteString(`"data":`)
+			p.keys = appendSortedKeys(p.keys[:0], c.Data)
+			if err := writeJSONSortedFields(buf, c.Data, p.keys); err != nil {
+				return err
+			}
+			sep = ","
+		}
+		if loc := c.ReportLocation; loc != nil {
+			buf.WriteString(sep + `"reportLocation":{"filePath":`)
+			writeJSONString(buf, loc.FilePath)
+			buf.WriteString(`,"functionName":`)
+			writeJSONString(buf, loc.FunctionName)
+			buf.WriteString(`,"lineNumber":`)
+			buf.WriteString(strconv.Itoa(loc.LineNumber))
+			buf.WriteByte('}')
+			sep = ","
+		}
+		if len(c.Frames) > 0 {
+			buf.WriteString(sep + `"frames":[`)
+			for i, f := range c.Frames {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(`{"function":`)
+				writeJSONString(buf, f.Function)
+				buf.WriteString(`,"file":`)
+				writeJSONString(buf, f.File)
+				buf.WriteString(`,"line":`)
+				buf.WriteString(strconv.Itoa(f.Line))
+				buf.WriteByte('}')
+			}
+			buf.WriteByte(']')
+		}
+		buf.WriteByte('}')
+	}
+
+	writeJSONStringField(buf, "stacktrace", p.Stacktrace)
+	writeJSONStringField(buf, "fingerprint", p.Fingerprint)
+	writeJSONStringField(buf, "logging.googleapis.com/trace", p.Trace)
+	writeJSONStringField(buf, "logging.googleapis.com/spanId", p.SpanID)
+	if p.TraceSampled {
+		buf.WriteString(`,"logging.googleapis.com/trace_sampled":true`)
+	}
+	if loc := p.SourceLocation; loc != nil {
+		buf.WriteString(`,"logging.googleapis.com/sourceLocation":{"file":`)
+		writeJSONString(buf, loc.File)
+		buf.WriteString(`,"line":"`)
+		buf.WriteString(strconv.Itoa(loc.Line))
+		buf.WriteString(`","function":`)
+		writeJSONString(buf, loc.Function)
+		buf.WriteByte('}')
+	}
+	writeJSONStringField(buf, "logging.googleapis.com/insertId", p.InsertID)
+	if len(p.Labels) > 0 {
+		buf.WriteString(`,"logging.googleapis.com/labels":{`)
+		for i, k := range sortedLabels(p.Labels) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, k)
+			buf.WriteByte(':')
+			writeJSONString(buf, p.Labels[k])
+		}
+		buf.WriteByte('}')
+	}
+	if op := p.Operation; op != nil {
+		buf.WriteString(`,"logging.googleapis.com/operation":{`)
+		sep := ""
+		if op.ID != "" {
+			buf.WriteString(`"id":`)
+			writeJSONString(buf, op.ID)
+			sep = ","
+		}
+		if op.Producer != "" {
+			buf.WriteString(sep + `"producer":`)
+			writeJSONString(buf, op.Producer)
+			sep = ","
+		}
+		if op.First {
+			buf.WriteString(sep + `"first":true`)
+			sep = ","
+		}
+		if op.Last {
+			buf.WriteString(sep + `"last":true`)
+		}
+		buf.WriteByte('}')
+	}
+	if r := p.HTTPRequest; r != nil {
+		buf.WriteString(`,"httpRequest":`)
+		writeJSONHTTPRequest(buf, r)
+	}
+	if m := p.Metric; m != nil {
+		buf.WriteString(`,"metric":{"name":`)
+		writeJSONString(buf, m.Name)
+		buf.WriteString(`,"value":`)
+		if err := writeJSONFloat(buf, m.Value, 64); err != nil {
+			return err
+		}
+		writeJSONStringField(buf, "unit", m.Unit)
+		if len(m.Tags) > 0 {
+			buf.WriteString(`,"tags":`)
+			if err := writeJSONFields(buf, m.Tags); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeJSONHTTPRequest writes the request as HTTPRequest.MarshalJSON does
+func writeJSONHTTPRequest(buf *bytes.Buffer, r *HTTPRequest) {
+	buf.WriteByte('{')
+	sep := ""
+	field := func(key string) {
+		buf.WriteString(sep + `"` + key + `":`)
+		sep = ","
+	}
+	if r.RequestMethod != "" {
+		field("requestMethod")
+		writeJSONString(buf, r.RequestMethod)
+	}
+	if r.RequestURL != "" {
+		field("requestUrl")
+		writeJSONString(buf, r.RequestURL)
+	}
+	if r.RequestSize != 0 {
+		field("requestSize")
+		buf.WriteString(`"` + strconv.FormatInt(r.RequestSize, 10) + `"`)
+	}
+	if r.Status != 0 {
+		field("status")
+		buf.WriteString(strconv.Itoa(r.Status))
+	}
+	if r.ResponseSize != 0 {
+		field("responseSize")
+		buf.WriteString(`"` + strconv.FormatInt(r.ResponseSize, 10) + `"`)
+	}
+	if r.UserAgent != "" {
+		field("userAgent")
+		writeJSONString(buf, r.UserAgent)
+	}
+	if r.RemoteIP != "" {
+		field("remoteIp")
+		writeJSONString(buf, r.RemoteIP)
+	}
+	if r.Referer != "" {
+		field("referer")
+		writeJSONString(buf, r.Referer)
+	}
+	if r.Latency != 0 {
+		field("latency")
+		writeJSONString(buf, formatLatency(r.Latency))
+	}
+	if r.Protocol != "" {
+		field("protocol")
+		writeJSONString(buf, r.Protocol)
+	}
+	buf.WriteByte('}')
+}
+
+// writeJSONStringField writes an omitempty string field, which is never the first of an object
+func writeJSONStringField(buf *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	buf.WriteString(`,"` + key + `":`)
+	writeJSONString(buf, value)
+}
+
+// maxJSONDepth bounds the nesting of the Fields values, beyond it a value is assumed to be cyclic
+const maxJSONDepth = 100
+
+// writeJSONFields writes the fields as an object with its keys sorted, as encoding/json does
+func writeJSONFields(buf *bytes.Buffer, f map[string]interface{}) error {
+	return writeJSONObject(buf, f, 0)
+}
+
+// writeJSONSortedFields writes the fields in the order of keys, the sorted keys of the fields
+func writeJSONSortedFields(buf *bytes.Buffer, f map[string]interface{}, keys []string) error {
+	return writeJSONObjectKeys(buf, f, keys, 0)
+}
+
+func writeJSONObject(buf *bytes.Buffer, f map[string]interface{}, depth int) error {
+	if f == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	return writeJSONObjectKeys(buf, f, sortedKeys(f), depth)
+}
+
+func writeJSONObjectKeys(buf *bytes.Buffer, f map[string]interface{}, keys []string, depth int) error {
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, k)
+		buf.WriteByte(':')
+		if err := writeJSONNested(buf, f[k], depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeJSONValue writes a Fields value. The common types are written directly, the errors, durations
+// and Stringers are converted first, see RegisterFieldEncoder, and the maps, slices and structs are
+// walked so their elements are converted too: the map keys are sorted and the struct fields keep
+// their declaration order, as with encoding/json
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	return writeJSONNested(buf, v, 0)
+}
+
+func writeJSONNested(buf *bytes.Buffer, v interface{}, depth int) error {
+	if depth > maxJSONDepth {
+		return &json.UnsupportedValueError{Str: "nested too deeply, it may be cyclic"}
+	}
+
+	switch val := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		writeJSONString(buf, val)
+	case bool:
+		buf.WriteString(strconv.FormatBool(val))
+	case int:
+		buf.WriteString(strconv.FormatInt(int64(val), 10))
+	case int8:
+		buf.WriteString(strconv.FormatInt(int64(val), 10))
+	case int16:
+		buf.WriteString(strconv.FormatInt(int64(val), 10))
+	case int32:
+		buf.WriteString(strconv.FormatInt(int64(val), 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(val, 10))
+	case uint:
+		buf.WriteString(strconv.FormatUint(uint64(val), 10))
+	case uint8:
+		buf.WriteString(strconv.FormatUint(uint64(val), 10))
+	case uint16:
+		buf.WriteString(strconv.FormatUint(uint64(val), 10))
+	case uint32:
+		buf.WriteString(strconv.FormatUint(uint64(val), 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(val, 10))
+	case float32:
+		return writeJSONFloat(buf, float64(val), 32)
+	case float64:
+		return writeJSONFloat(buf, val, 64)
+	case Fields:
+		return writeJSONObject(buf, val, depth)
+	case map[string]interface{}:
+		return writeJSONObject(buf, val, depth)
+	case []string:
+		if val == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, s := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, s)
+		}
+		buf.WriteByte(']')
+	case []interface{}:
+		if val == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, e := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONNested(buf, e, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		if conv, ok := convertFieldValue(val); ok && reflect.TypeOf(conv) != reflect.TypeOf(val) {
+			return writeJSONNested(buf, conv, depth)
+		}
+		return writeJSONReflect(buf, reflect.ValueOf(val), depth)
+	}
+	return nil
+}
+
+// writeJSONReflect walks the maps, slices, arrays, pointers and structs, and writes any other
+// value, or the ones implementing their own encoding, with encoding/json
+func writeJSONReflect(buf *bytes.Buffer, rv reflect.Value, depth int) error {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if !hasCustomJSON(rv.Type()) {
+			return writeJSONNested(buf, rv.Elem().Interface(), depth+1)
+		}
+
+	case reflect.Map:
+		if !hasCustomJSON(rv.Type()) && !hasCustomJSON(rv.Type().Key()) && rv.Type().Key().Kind() == reflect.String {
+			return writeJSONMap(buf, rv, depth)
+		}
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		// Byte slices are base64 encoded
+		if !hasCustomJSON(rv.Type()) && rv.Type().Elem().Kind() != reflect.Uint8 {
+			return writeJSONArray(buf, rv, depth)
+		}
+
+	case reflect.Array:
+		if !hasCustomJSON(rv.Type()) {
+			return writeJSONArray(buf, rv, depth)
+		}
+
+	case reflect.Struct:
+		if ok, err := writeJSONStruct(buf, rv, depth); ok {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+func writeJSONMap(buf *bytes.Buffer, rv reflect.Value, depth int) error {
+	if rv.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, k.String())
+		buf.WriteByte(':')
+		if err := writeJSONNested(buf, rv.MapIndex(k).Interface(), depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeJSONArray(buf *bytes.Buffer, rv reflect.Value, depth int) error {
+	buf.WriteByte('[')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSONNested(buf, rv.Index(i).Interface(), depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// writeJSONStruct writes the exported fields of a struct with their json tag names, omitting the
+// empty ones tagged with omitempty. It returns false for the structs it does not handle, see
+// eachJSONField
+func writeJSONStruct(buf *bytes.Buffer, rv reflect.Value, depth int) (bool, error) {
+	if !plainJSONStruct(rv.Type()) {
+		return false, nil
+	}
+
+	buf.WriteByte('{')
+	sep := false
+	err := eachJSONField(rv, func(name string, fv reflect.Value) error {
+		if sep {
+			buf.WriteByte(',')
+		}
+		sep = true
+		writeJSONString(buf, name)
+		buf.WriteByte(':')
+		return writeJSONNested(buf, fv.Interface(), depth+1)
+	})
+	if err != nil {
+		return true, err
+	}
+	buf.WriteByte('}')
+	return true, nil
+}
+
+// plainJSONStruct tells whether the struct type is walked field by field rather than left to
+// encoding/json: it has no JSON encoding of its own, no embedded fields and no string tag option
+func plainJSONStruct(t reflect.Type) bool {
+	if hasCustomJSON(t) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous || strings.Contains(f.Tag.Get("json"), ",string") {
+			return false
+		}
+	}
+	return true
+}
+
+// eachJSONField calls fn with the JSON name and the value of the fields of a plain struct encoded
+// by encoding/json, in their declaration order, stopping at the first error
+func eachJSONField(rv reflect.Value, fn func(name string, fv reflect.Value) error) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		name, opts := f.Name, ""
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if comma := strings.IndexByte(tag, ','); comma >= 0 {
+				tag, opts = tag[:comma], tag[comma:]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		fv := rv.Field(i)
+		if strings.Contains(opts, ",omitempty") && isEmptyJSONValue(fv) {
+			continue
+		}
+		if err := fn(name, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// hasCustomJSON tells whether the values of t, or their address, have their own JSON encoding
+func hasCustomJSON(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// isEmptyJSONValue tells whether a field tagged with omitempty is omitted, as encoding/json does
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// writeJSONFloat formats a float like encoding/json: exponent notation only for very small or large values
+func writeJSONFloat(buf *bytes.Buffer, f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	var scratch [64]byte
+	b := strconv.AppendFloat(scratch[:0], f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	buf.Write(b)
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes a quoted JSON string with the escaping rules of encoding/json
+// writeJSONEventTime writes the event time, formatting it into the buffer when EventTime is not set
+func writeJSONEventTime(buf *bytes.Buffer, p *Payload) {
+	if p.EventTime != "" || p.eventTime.IsZero() {
+		writeJSONString(buf, p.EventTime)
+		return
+	}
+
+	var scratch [64]byte
+	b := p.eventTime.AppendFormat(scratch[:0], p.timeFormat)
+	for _, c := range b {
+		// The layouts with text to escape are written as any other string
+		if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			writeJSONString(buf, string(b))
+			return
+		}
+	}
+	buf.WriteByte('"')
+	buf.Write(b)
+	buf.WriteByte('"')
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				// Control characters and the HTML sensitive <, > and &
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but break JavaScript parsers
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+// sortedKeys returns the keys of the fields in a deterministic order
+func sortedKeys(f map[string]interface{}) []string {
+	return appendSortedKeys(make([]string, 0, len(f)), f)
+}
+
+// appendSortedKeys appends the sorted keys of the fields to keys
+func appendSortedKeys(keys []string, f map[string]interface{}) []string {
+	start := len(keys)
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys[start:])
+	return keys
+}
+
+// sortedLabels returns the keys of the labels in order
+func sortedLabels(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
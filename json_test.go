@@ -0,0 +1,287 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestJSONEncoderMatchesEncodingJSON(t *testing.T) {
+	payloads := []*Payload{
+		{},
+		{
+			Severity:  "ERROR",
+			EventTime: "2017-04-26T02:29:33-04:00",
+			Seq:       math.MaxUint64,
+			Caller:    "logger/logger.go:42",
+			Message:   "quotes \" backslash \\ html <b>&</b> control \x01\t\r\n unicode é \u2028 \u2029",
+			ServiceContext: &ServiceContext{
+				Service: "my-app",
+				Version: "1.0",
+			},
+			Context: &Context{
+				Data: Fields{
+					"string":   "value",
+					"bool":     true,
+					"int":      -42,
+					"int8":     int8(8),
+					"uint64":   uint64(math.MaxUint64),
+					"float":    3.14,
+					"tiny":     1e-9,
+					"huge":     1e21,
+					"float32":  float32(0.1),
+					"nil":      nil,
+					"strings":  []string{"a", "b"},
+					"empty":    []string{},
+					"nilslice": []string(nil),
+					"list":     []interface{}{1, "two", Fields{"three": 3}},
+					"nested":   map[string]interface{}{"b": 1, "a": Fields{"c": "d"}},
+					"time":     time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC),
+					"struct":   struct{ Name string }{"Mauricio"},
+				},
+				ReportLocation: &ReportLocation{
+					FilePath:     "/go/src/app/main.go",
+					FunctionName: "main.main",
+					LineNumber:   15,
+				},
+				Frames: []StackFrame{
+					{Function: "main.run", File: "/go/src/app/run.go", Line: 42},
+					{Function: "main.main", File: "/go/src/app/main.go", Line: 15},
+				},
+			},
+			Stacktrace:   "goroutine 1 [running]:\nmain.main()\n",
+			Fingerprint:  "3f2a9c1e0b7d4e65",
+			Trace:        "projects/my-app/traces/105445aa7843bc8bf206b12000100000",
+			SpanID:       "0000000000000001",
+			TraceSampled: true,
+			SourceLocation: &SourceLocation{
+				File:     "/go/src/app/main.go",
+				Line:     15,
+				Function: "main.main",
+			},
+			InsertID: "7c1a5ea4-8dc4-4e5e-9a4c-3c1e5d1e8a42",
+			Labels:   map[string]string{"region": "us-east1", "env": "<prod>"},
+			Operation: &Operation{
+				ID:       "import-42",
+				Producer: "github.com/teltech/importer",
+				First:    true,
+				Last:     true,
+			},
+			HTTPRequest: &HTTPRequest{
+				RequestMethod: "POST",
+				RequestURL:    "/users?name=<script>",
+				RequestSize:   512,
+				Status:        201,
+				ResponseSize:  1 << 40,
+				UserAgent:     "curl/7.64.1",
+				RemoteIP:      "10.0.0.1:54321",
+				Referer:       "https://example.com/",
+				Latency:       1500 * time.Millisecond,
+				Protocol:      "HTTP/1.1",
+			},
+			Metric: &Metric{
+				Name:  "checkout.latency",
+				Value: 42.5,
+				Unit:  "ms",
+				Tags:  Fields{"region": "us-east1", "retries": 2},
+			},
+		},
+		{
+			Severity:       "INFO",
+			ServiceContext: &ServiceContext{Version: "1.0"},
+			Context:        &Context{Data: Fields{}},
+		},
+		{
+			Context: &Context{ReportLocation: &ReportLocation{}},
+		},
+		{
+			Context: &Context{Frames: []StackFrame{{}}},
+		},
+		{
+			Operation: &Operation{},
+		},
+		{
+			Operation: &Operation{Last: true},
+		},
+		{
+			HTTPRequest: &HTTPRequest{},
+		},
+		{
+			HTTPRequest: &HTTPRequest{Status: 404, Latency: time.Nanosecond},
+		},
+		{
+			Metric: &Metric{},
+		},
+	}
+
+	for _, p := range payloads {
+		expected, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("cannot marshal payload: %s", err.Error())
+		}
+
+		got, err := JSONEncoder{}.Encode(p)
+		if err != nil {
+			t.Fatalf("cannot encode payload: %s", err.Error())
+		}
+
+		if !bytes.Equal(expected, got) {
+			t.Errorf("output %s does not match encoding/json %s", got, expected)
+		}
+	}
+}
+
+type nestedInner struct {
+	Values map[string]int
+}
+
+type nestedValue struct {
+	Name     string            `json:"name"`
+	Tags     []string          `json:"tags,omitempty"`
+	Skipped  string            `json:"-"`
+	Empty    int               `json:",omitempty"`
+	Inner    *nestedInner      `json:"inner"`
+	Labels   map[string]string `json:"labels"`
+	Points   [2]float64        `json:"points"`
+	Raw      []byte            `json:"raw"`
+	private  string
+	Children []nestedValue `json:"children,omitempty"`
+}
+
+type embeddingValue struct {
+	nestedInner
+	ID int `json:"id,string"`
+}
+
+func TestJSONEncoderNestedValuesMatchEncodingJSON(t *testing.T) {
+	values := []interface{}{
+		map[string]int{"b": 2, "a": 1, "c": 3},
+		[]map[string]string{{"z": "1", "y": "2"}, nil},
+		map[int]string{2: "two", 1: "one"},
+		nestedValue{
+			Name:    "root",
+			Skipped: "skipped",
+			Inner:   &nestedInner{Values: map[string]int{"y": 1, "x": 2}},
+			Labels:  map[string]string{"env": "<prod>", "app": "api"},
+			Points:  [2]float64{1.5, 2},
+			Raw:     []byte("raw"),
+			private: "private",
+			Children: []nestedValue{
+				{Name: "child", Tags: []string{"a"}},
+			},
+		},
+		&nestedValue{Name: "pointer"},
+		embeddingValue{nestedInner: nestedInner{Values: map[string]int{"a": 1}}, ID: 42},
+		[]*nestedInner{nil, {}},
+	}
+
+	for _, v := range values {
+		expected, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("cannot marshal value: %s", err.Error())
+		}
+
+		buf := new(bytes.Buffer)
+		if err := writeJSONValue(buf, v); err != nil {
+			t.Fatalf("cannot encode value: %s", err.Error())
+		}
+		if !bytes.Equal(expected, buf.Bytes()) {
+			t.Errorf("output %s does not match encoding/json %s", buf.Bytes(), expected)
+		}
+	}
+}
+
+type cyclicValue struct {
+	Next *cyclicValue
+}
+
+func TestJSONEncoderNestedConversions(t *testing.T) {
+	v := map[string]interface{}{
+		"request": struct {
+			Err     error
+			Timeout time.Duration
+			Retries []error
+		}{errors.New("timeout"), time.Second, []error{errors.New("refused")}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeJSONValue(buf, v); err != nil {
+		t.Fatalf("cannot encode value: %s", err.Error())
+	}
+	expected := `{"request":{"Err":"timeout","Timeout":"1s","Retries":["refused"]}}`
+	if buf.String() != expected {
+		t.Errorf("output %s does not match expected string %s", buf.String(), expected)
+	}
+
+	c := &cyclicValue{}
+	c.Next = c
+	if err := writeJSONValue(new(bytes.Buffer), c); err == nil {
+		t.Errorf("expected an error encoding a cyclic value")
+	}
+}
+
+func TestJSONEncoderInvalidUTF8(t *testing.T) {
+	got, err := JSONEncoder{}.Encode(&Payload{Message: "invalid \xff utf-8"})
+	if err != nil {
+		t.Fatalf("cannot encode payload: %s", err.Error())
+	}
+
+	p := Payload{}
+	if err := json.Unmarshal(got, &p); err != nil {
+		t.Fatalf("output %s cannot be unmarshalled: %s", got, err.Error())
+	}
+	if p.Message != "invalid \ufffd utf-8" {
+		t.Errorf("invalid bytes were not replaced: %q", p.Message)
+	}
+}
+
+func TestJSONEncoderUnsupportedValue(t *testing.T) {
+	p := &Payload{Context: &Context{Data: Fields{"nan": math.NaN()}}}
+	if _, err := (JSONEncoder{}).Encode(p); err == nil {
+		t.Errorf("expected an error encoding NaN")
+	}
+}
+
+func benchmarkPayload() *Payload {
+	return &Payload{
+		Severity:  "INFO",
+		EventTime: "2017-04-26T02:29:33-04:00",
+		Message:   "INFO message",
+		ServiceContext: &ServiceContext{
+			Service: "my-app",
+			Version: "1.0",
+		},
+		Context: &Context{
+			Data: Fields{
+				"user":    "+1234567890",
+				"action":  "create-account",
+				"attempt": 3,
+			},
+		},
+	}
+}
+
+func BenchmarkJSONEncoder(b *testing.B) {
+	p := benchmarkPayload()
+	buf := new(bytes.Buffer)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		JSONEncoder{}.encodeTo(buf, p)
+	}
+}
+
+func BenchmarkEncodingJSON(b *testing.B) {
+	p := benchmarkPayload()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(p)
+	}
+}
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// KafkaProducer publishes messages to Kafka. It is implemented by a thin adapter over the
+// client library of the application, e.g. for segmentio/kafka-go:
+//
+//	type producer struct{ w *kafka.Writer }
+//
+//	func (p producer) Produce(topic string, key, value []byte) error {
+//		return p.w.WriteMessages(context.Background(), kafka.Message{Topic: topic, Key: key, Value: value})
+//	}
+//
+// The delivery guarantees, such as the required acknowledgements, are configured on the client
+type KafkaProducer interface {
+	Produce(topic string, key, value []byte) error
+}
+
+// KafkaKey tells which message key a KafkaWriter sets, the messages with a same key going to the same partition
+type KafkaKey int
+
+const (
+	// KeyByService uses the service name, keeping the entries of a service in order
+	KeyByService KafkaKey = iota
+	// KeyByTrace uses the trace ID, keeping the entries of a request together. The entries
+	// without a trace have no key
+	KeyByTrace
+	// NoKey lets the producer spread the entries over the partitions
+	NoKey
+)
+
+// KafkaDelivery tells whether a KafkaWriter returns the errors of the producer
+type KafkaDelivery int
+
+const (
+	// AtLeastOnce returns the producer errors from Write, so they can be retried, e.g. with a RetryWriter
+	AtLeastOnce KafkaDelivery = iota
+	// AtMostOnce never fails a Write, the producer errors are passed to the OnError function
+	AtMostOnce
+)
+
+// KafkaWriter is an io.Writer publishing each log entry as a Kafka message, so the logs can feed
+// a streaming pipeline. It expects the JSON entries of the default encoder to set the message keys
+type KafkaWriter struct {
+	// Producer publishes the messages
+	Producer KafkaProducer
+	// Topic is the topic of the messages
+	Topic string
+	// Key tells which message key is set, the service name by default
+	Key KafkaKey
+	// Delivery tells whether the producer errors are returned
+	Delivery KafkaDelivery
+}
+
+// WithKafka publishes the log entries to a Kafka topic, keyed by service
+func WithKafka(producer KafkaProducer, topic string) Option {
+	return func(l *Log) {
+		l.writer = &KafkaWriter{
+			Producer: producer,
+			Topic:    topic,
+		}
+	}
+}
+
+// Write publishes a copy of p, as the producer may keep it after returning
+func (w *KafkaWriter) Write(p []byte) (int, error) {
+	value := append([]byte(nil), bytes.TrimRight(p, "\n")...)
+
+	if err := w.Producer.Produce(w.Topic, w.key(value), value); err != nil {
+		if w.Delivery == AtMostOnce {
+			dropEntry(err)
+			return len(p), nil
+		}
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// key returns the message key of an entry, nil when there is none
+func (w *KafkaWriter) key(value []byte) []byte {
+	if w.Key == NoKey {
+		return nil
+	}
+
+	var entry struct {
+		ServiceContext *ServiceContext `json:"serviceContext"`
+		Trace          string          `json:"logging.googleapis.com/trace"`
+	}
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil
+	}
+
+	switch w.Key {
+	case KeyByService:
+		if entry.ServiceContext != nil && entry.ServiceContext.Service != "" {
+			return []byte(entry.ServiceContext.Service)
+		}
+	case KeyByTrace:
+		if entry.Trace != "" {
+			return []byte(entry.Trace)
+		}
+	}
+	return nil
+}
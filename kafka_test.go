@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type kafkaMessage struct {
+	topic, key, value string
+}
+
+// fakeProducer records the messages, or fails with err
+type fakeProducer struct {
+	messages []kafkaMessage
+	err      error
+}
+
+func (p *fakeProducer) Produce(topic string, key, value []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, kafkaMessage{topic, string(key), string(value)})
+	return nil
+}
+
+func TestKafkaWriter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "")
+
+	tests := []struct {
+		key      KafkaKey
+		expected string
+	}{
+		{KeyByService, "my-app"},
+		{KeyByTrace, "105445aa7843bc8bf206b12000100000"},
+		{NoKey, ""},
+	}
+
+	for _, test := range tests {
+		producer := &fakeProducer{}
+		log := New(WithKafka(producer, "logs"))
+		log.writer.(*KafkaWriter).Key = test.key
+		log.WithTrace("105445aa7843bc8bf206b12000100000", "", false).Info("INFO message")
+
+		if len(producer.messages) != 1 {
+			t.Fatalf("expected 1 message; got %d", len(producer.messages))
+		}
+		m := producer.messages[0]
+		if m.topic != "logs" || m.key != test.expected {
+			t.Errorf("key %d: unexpected topic %s and key %s", test.key, m.topic, m.key)
+		}
+		if !strings.HasPrefix(m.value, `{"severity":"INFO"`) || strings.HasSuffix(m.value, "\n") {
+			t.Errorf("unexpected value %q", m.value)
+		}
+	}
+}
+
+func TestKafkaWriterDelivery(t *testing.T) {
+	OnError(func(error) {})
+	defer OnError(nil)
+
+	producer := &fakeProducer{err: errors.New("broker unavailable")}
+	w := &KafkaWriter{Producer: producer, Topic: "logs"}
+	if _, err := w.Write([]byte("{}\n")); err == nil {
+		t.Errorf("expected the producer error with AtLeastOnce")
+	}
+
+	dropped := DroppedEntries()
+	w.Delivery = AtMostOnce
+	if _, err := w.Write([]byte("{}\n")); err != nil {
+		t.Errorf("expected no error with AtMostOnce; got %s", err.Error())
+	}
+	if DroppedEntries()-dropped != 1 {
+		t.Errorf("expected the entry to be counted as dropped")
+	}
+}
@@ -0,0 +1,18 @@
+package logger
+
+// WithLabels creates a copy of a Log adding the labels to its entries, as the Cloud Logging
+// "logging.googleapis.com/labels". Unlike the context data, the labels are indexed and can be
+// used in the log sinks and exclusion filters, e.g. for the environment, region or tenant
+func (l *Log) WithLabels(labels map[string]string) *Log {
+	n := l.With(Fields{})
+
+	merged := make(map[string]string, len(l.payload.Labels)+len(labels))
+	for k, v := range l.payload.Labels {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	n.payload.Labels = merged
+	return n
+}
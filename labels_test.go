@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWithLabels(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	base := New().WithOutput(buf).WithLabels(map[string]string{"env": "prod", "region": "us-east1"})
+	base.WithLabels(map[string]string{"tenant": "acme", "region": "europe-west1"}).Info("INFO message")
+
+	expected := `"logging.googleapis.com/labels":{"env":"prod","region":"europe-west1","tenant":"acme"}}`
+	if !strings.HasSuffix(strings.TrimRight(buf.String(), "\n"), expected) {
+		t.Errorf("output %s does not contain the labels %s", buf.String(), expected)
+	}
+
+	// The labels of the original logger are not modified, and are kept by With
+	buf.Reset()
+	base.With(Fields{"key": "value"}).WithOutput(buf).Error("ERROR message")
+	expected = `"logging.googleapis.com/labels":{"env":"prod","region":"us-east1"}}`
+	if !strings.HasSuffix(strings.TrimRight(buf.String(), "\n"), expected) {
+		t.Errorf("output %s does not contain the labels %s", buf.String(), expected)
+	}
+}
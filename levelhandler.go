@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// LevelHandler is an http.Handler reporting the global log level on GET requests and
+// changing it on PUT requests, so operators can enable DEBUG on a running process:
+//
+//	http.Handle("/admin/log/level", logger.LevelHandler{})
+//
+//	curl -X PUT -d '{"level":"DEBUG"}' localhost:8080/admin/log/level
+//	curl -X PUT -d '{"module":"storage","level":"DEBUG"}' localhost:8080/admin/log/level
+//	curl -X PUT -d '{"level":"DEBUG","duration":"5m"}' localhost:8080/admin/log/level
+//
+// The level is read from the JSON body, whatever its content type, or from the "level" query
+// parameter. When a module is given, in the body or as the "module" query parameter, the level of
+// that module is reported or changed instead of the global one. When a duration is given, the global level is boosted for that
+// duration only, see BoostLevel.
+type LevelHandler struct{}
+
+// maxLevelBodySize is the size limit of the request bodies of LevelHandler
+const maxLevelBodySize = 1 << 16
+
+type levelMessage struct {
+	Module   string `json:"module,omitempty"`
+	Level    string `json:"level,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ServeHTTP implements http.Handler
+func (LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if module := r.URL.Query().Get("module"); module != "" {
+			writeLevelMessage(w, http.StatusOK, levelMessage{Module: module, Level: ModuleLevel(module).String()})
+			return
+		}
+		writeLevelMessage(w, http.StatusOK, levelMessage{Level: GetLevel().String()})
+
+	case http.MethodPut:
+		q := r.URL.Query()
+		module, name, duration := q.Get("module"), q.Get("level"), q.Get("duration")
+
+		// curl -d sends the JSON body as a form, the content type is ignored
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxLevelBodySize))
+		if err != nil {
+			writeLevelMessage(w, http.StatusBadRequest, levelMessage{Error: "cannot read the request body"})
+			return
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			var msg levelMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				name = ""
+			} else {
+				if msg.Module != "" {
+					module = msg.Module
+				}
+				if msg.Level != "" {
+					name = msg.Level
+				}
+				if msg.Duration != "" {
+					duration = msg.Duration
+				}
+			}
+		}
+		if name == "" {
+			writeLevelMessage(w, http.StatusBadRequest, levelMessage{Error: "request body must be a JSON object with a level key"})
+			return
+		}
+
+		lvl, err := ParseLevel(name)
+		if err != nil {
+			writeLevelMessage(w, http.StatusBadRequest, levelMessage{Error: err.Error()})
+			return
+		}
+
+		if duration != "" {
+			d, err := time.ParseDuration(duration)
+			if err != nil || d <= 0 || module != "" {
+				writeLevelMessage(w, http.StatusBadRequest, levelMessage{Error: "duration must be a positive duration such as 5m, for the global level only"})
+				return
+			}
+			BoostLevel(lvl, d)
+		} else if module != "" {
+			SetModuleLevel(module, lvl)
+		} else {
+			SetLevel(lvl)
+		}
+		writeLevelMessage(w, http.StatusOK, levelMessage{Module: module, Level: lvl.String(), Duration: duration})
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelMessage(w, http.StatusMethodNotAllowed, levelMessage{Error: "only GET and PUT are supported"})
+	}
+}
+
+func writeLevelMessage(w http.ResponseWriter, status int, msg levelMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(msg)
+}
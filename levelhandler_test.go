@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	handler := LevelHandler{}
+
+	tests := []struct {
+		method   string
+		body     string
+		status   int
+		expected string
+		level    severity
+	}{
+		{http.MethodGet, "", http.StatusOK, `{"level":"INFO"}`, INFO},
+		{http.MethodPut, `{"level":"debug"}`, http.StatusOK, `{"level":"DEBUG"}`, DEBUG},
+		{http.MethodPut, `{"level":"verbose"}`, http.StatusBadRequest, `{"error":"logger: unknown log level \"verbose\""}`, DEBUG},
+		{http.MethodPut, `not json`, http.StatusBadRequest, `{"error":"request body must be a JSON object with a level key"}`, DEBUG},
+		{http.MethodPost, "", http.StatusMethodNotAllowed, `{"error":"only GET and PUT are supported"}`, DEBUG},
+		{http.MethodGet, "", http.StatusOK, `{"level":"DEBUG"}`, DEBUG},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/log/level", strings.NewReader(test.body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s %s: expected status %d; got %d", test.method, test.body, test.status, rec.Code)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != test.expected {
+			t.Errorf("%s %s: output %s does not match expected string %s", test.method, test.body, got, test.expected)
+		}
+		if GetLevel() != test.level {
+			t.Errorf("%s %s: expected level %s; got %s", test.method, test.body, test.level, GetLevel())
+		}
+	}
+}
+
+func TestLevelHandlerFormValue(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	req := httptest.NewRequest(http.MethodPut, "/log/level?level=WARN", nil)
+	rec := httptest.NewRecorder()
+	LevelHandler{}.ServeHTTP(rec, req)
+
+	if GetLevel() != WARN {
+		t.Errorf("expected level WARN; got %s", GetLevel())
+	}
+}
+
+func TestLevelHandlerFormContentType(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	// The content type of curl -X PUT -d '{"level":"WARN"}'
+	req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"WARN"}`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	LevelHandler{}.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || GetLevel() != WARN {
+		t.Errorf("expected level WARN; got status %d and level %s", rec.Code, GetLevel())
+	}
+}
+
+func TestLevelHandlerModule(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetNamedLevels("")
+
+	req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"module":"storage","level":"debug"}`))
+	rec := httptest.NewRecorder()
+	LevelHandler{}.ServeHTTP(rec, req)
+
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"module":"storage","level":"DEBUG"}` {
+		t.Errorf("unexpected response %s", got)
+	}
+	if ModuleLevel("storage") != DEBUG || GetLevel() != INFO {
+		t.Errorf("expected DEBUG for storage only; got %s and %s", ModuleLevel("storage"), GetLevel())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/log/level?module=storage.s3", nil)
+	rec = httptest.NewRecorder()
+	LevelHandler{}.ServeHTTP(rec, req)
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"module":"storage.s3","level":"DEBUG"}` {
+		t.Errorf("unexpected response %s", got)
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// LogfmtEncoder encodes a payload as a logfmt line: ts=... level=... msg=... key=value
+type LogfmtEncoder struct{}
+
+// Encode formats the payload as a single logfmt line
+func (e LogfmtEncoder) Encode(p *Payload) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := e.encodeTo(buf, p)
+	return buf.Bytes(), err
+}
+
+func (e LogfmtEncoder) encodeTo(buf *bytes.Buffer, p *Payload) error {
+	writeLogfmtPair(buf, "ts", p.EventTime)
+	buf.WriteByte(' ')
+	if p.Seq != 0 {
+		writeLogfmtPair(buf, "seq", strconv.FormatUint(p.Seq, 10))
+		buf.WriteByte(' ')
+	}
+	writeLogfmtPair(buf, "level", p.Severity)
+	buf.WriteByte(' ')
+	if p.Caller != "" {
+		writeLogfmtPair(buf, "caller", p.Caller)
+		buf.WriteByte(' ')
+	}
+	writeLogfmtPair(buf, "msg", p.Message)
+
+	if p.Context != nil {
+		for _, k := range sortedKeys(p.Context.Data) {
+			s, _ := valueString(p.Context.Data[k])
+			buf.WriteByte(' ')
+			writeLogfmtPair(buf, k, s)
+		}
+
+		if loc := p.Context.ReportLocation; loc != nil {
+			buf.WriteByte(' ')
+			writeLogfmtPair(buf, "location", loc.FilePath+":"+strconv.Itoa(loc.LineNumber))
+			buf.WriteByte(' ')
+			writeLogfmtPair(buf, "function", loc.FunctionName)
+		}
+	}
+
+	return nil
+}
+
+// WithLogfmtOutput sets a LogfmtEncoder as the log entries format
+func WithLogfmtOutput() Option {
+	return WithEncoder(LogfmtEncoder{})
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	buf.WriteString(quoteValue(value))
+}
+
+// logfmtKey replaces the characters a key cannot contain, the ones quoteValue quotes, with '_'
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	if strings.IndexFunc(key, needsQuoting) == -1 {
+		return key
+	}
+	return strings.Map(func(r rune) rune {
+		if needsQuoting(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
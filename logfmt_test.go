@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New(WithLogfmtOutput(), WithClock(testClock)).With(Fields{
+		"key":   "value",
+		"count": 3,
+		"names": []string{"Mauricio", "Manuel"},
+	}).WithOutput(buf)
+
+	log.Info("INFO message")
+	expected := fmt.Sprintf(`ts=%s level=INFO msg="INFO message" count=3 key=value names="[\"Mauricio\",\"Manuel\"]"`, testTime.Format(time.RFC3339Nano))
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestLogfmtEncoderInvalidKeys(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithLogfmtOutput(), WithClock(testClock)).With(Fields{
+		"with space": 1,
+		"a=b":        2,
+		`"quoted"`:   3,
+		"":           4,
+	}).WithOutput(buf)
+
+	log.Info("INFO message")
+	expected := fmt.Sprintf(`ts=%s level=INFO msg="INFO message" _=4 _quoted_=3 a_b=2 with_space=1`, testTime.Format(time.RFC3339Nano))
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestLogfmtEncoderPerLogger(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New().WithOutput(buf)
+	log.WithOptions(WithLogfmtOutput()).Error("ERROR message")
+	got := strings.TrimRight(buf.String(), "\n")
+	if !strings.Contains(got, `level=ERROR msg="ERROR message" location=`) {
+		t.Errorf("output %s is not logfmt encoded", got)
+	}
+	if !strings.HasSuffix(got, "function=logger.TestLogfmtEncoderPerLogger") {
+		t.Errorf("output %s does not contain the caller function", got)
+	}
+}
@@ -1,20 +1,22 @@
 package logger
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type severity int
 
 const (
-	DEBUG severity = iota
+	TRACE severity = iota
+	DEBUG
 	INFO
 	WARN
 	ERROR
@@ -26,6 +28,7 @@ func (s severity) String() string {
 }
 
 var logLevelName = [...]string{
+	"TRACE",
 	"DEBUG",
 	"INFO",
 	"WARN",
@@ -34,6 +37,7 @@ var logLevelName = [...]string{
 }
 
 var logLevelValue = map[string]severity{
+	"TRACE":    TRACE,
 	"DEBUG":    DEBUG,
 	"INFO":     INFO,
 	"WARN":     WARN,
@@ -61,55 +65,197 @@ type ReportLocation struct {
 type Context struct {
 	Data           Fields          `json:"data,omitempty"`
 	ReportLocation *ReportLocation `json:"reportLocation,omitempty"`
+	Frames         []StackFrame    `json:"frames,omitempty"`
 }
 
 // Payload groups all the data for a log entry
 type Payload struct {
-	Severity       string          `json:"severity"`
-	EventTime      string          `json:"eventTime"`
-	Caller         string          `json:"caller,omitempty"`
-	Message        string          `json:"message"`
-	ServiceContext *ServiceContext `json:"serviceContext,omitempty"`
-	Context        *Context        `json:"context,omitempty"`
-	Stacktrace     string          `json:"stacktrace,omitempty"`
+	Severity       string            `json:"severity"`
+	EventTime      string            `json:"eventTime"`
+	Seq            uint64            `json:"seq,omitempty"`
+	Caller         string            `json:"caller,omitempty"`
+	Message        string            `json:"message"`
+	ServiceContext *ServiceContext   `json:"serviceContext,omitempty"`
+	Context        *Context          `json:"context,omitempty"`
+	Stacktrace     string            `json:"stacktrace,omitempty"`
+	Fingerprint    string            `json:"fingerprint,omitempty"`
+	Trace          string            `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string            `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool              `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	SourceLocation *SourceLocation   `json:"logging.googleapis.com/sourceLocation,omitempty"`
+	InsertID       string            `json:"logging.googleapis.com/insertId,omitempty"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
+	Operation      *Operation        `json:"logging.googleapis.com/operation,omitempty"`
+	HTTPRequest    *HTTPRequest      `json:"httpRequest,omitempty"`
+	Metric         *Metric           `json:"metric,omitempty"`
+
+	// eventTime is the time of an entry whose EventTime is only formatted when needed, the JSON
+	// encoder writing it straight into its buffer
+	eventTime  time.Time
+	timeFormat string
+	// keys is reused to sort the fields when encoding the entry
+	keys []string
+}
+
+// formatEventTime sets EventTime from the time of the entry when it was not formatted yet
+func (p *Payload) formatEventTime() {
+	if p.EventTime == "" && !p.eventTime.IsZero() {
+		p.EventTime = p.eventTime.Format(p.timeFormat)
+	}
 }
 
 // Log is the main type for the logger package
 type Log struct {
 	payload *Payload
+	// added are the fields added by With, merged with the payload context on first use. It is nil
+	// when the payload context is complete
+	added   *fieldList
 	writer  io.Writer
+	encoder Encoder
+	// framing delimits the encoded entries, NewlineFraming by default
+	framing Framing
+	// mu serializes the writes of a Log and of all the loggers derived from it
+	mu    *sync.Mutex
+	hooks *hookSet
+	// level overrides the global log level when set
+	level *severity
+	// name is the name of the logger given by Named, empty for the unnamed loggers
+	name string
+	// sampler limits the number of entries written, nil when sampling is disabled
+	sampler *sampler
+	// dedup collapses the repeated entries, nil when disabled
+	dedup *deduper
+	// redactor hides the sensitive context fields, nil when disabled
+	redactor *Redactor
+	// sanitizer renames the context fields, nil when disabled
+	sanitizer *KeySanitizer
+	// stackLimit is the maximum number of frames in the stacktraces, zero for no limit
+	stackLimit int
+	// stackFilter removes frames from the stacks, nil to keep them all
+	stackFilter *StackFilter
+	// stackFrames adds the stack as frame objects to the context of the ERROR and CRITICAL entries
+	stackFrames bool
+	// addCaller records the location of the logging call in every entry
+	addCaller bool
+	// callerFormat formats the caller field, shortCaller when nil
+	callerFormat CallerFormat
+	// callerSkip is the number of extra frames between the logging call and the logger
+	callerSkip int
+	// seq is the sequence number of the last entry written, shared with the derived loggers. It is nil
+	// when the sequence numbers are disabled
+	seq *uint64
+	// insertID generates the insertId of the entries, nil when they have none
+	insertID func() string
+	// opFirst is 1 until the first entry of the operation started with StartOperation is written
+	opFirst *int32
+	// goroutineID adds the ID of the goroutine to the context data of the entries
+	goroutineID bool
+	// clock returns the time of the entries
+	clock func() time.Time
+	// timeFormat is the layout of the entries eventTime
+	timeFormat string
+	// exitCode is the code passed to the exit function by Fatal and Fatalf
+	exitCode int
+	// errStack is the stack of the error attached with WithError, used instead of the call site one
+	errStack []uintptr
+	// trimPaths makes the file paths of the locations relative to the module of the program
+	trimPaths bool
+	// fingerprint adds the grouping key of the errors to the ERROR and CRITICAL entries
+	fingerprint bool
+	// nop discards all the entries, see Nop
+	nop bool
+}
+
+// levelWriter is implemented by the writers that handle the entries differently depending on their
+// severity, or that use their time
+type levelWriter interface {
+	writeLevel(s severity, t time.Time, p []byte) (int, error)
 }
 
+// Option configures a Log when passed to New or WithOptions
+type Option func(*Log)
+
 var (
-	logLevel severity
+	// logLevel holds the severity, it is only accessed atomically
+	logLevel int32
 	service  string
 	version  string
+
+	// configureOnce loads the configuration from the environment on first use, unless Configure
+	// was called before
+	configureOnce sync.Once
 )
 
-func init() {
+// Configure sets the global level, service and version explicitly. Otherwise, they are read from
+// the LOG_LEVEL, SERVICE and VERSION environment variables when the package is first used, so
+// that the environment can still be set up after the package is imported
+func Configure(lvl severity, svc, ver string) {
+	initConfig(lvl, svc, ver)
+}
+
+// configured loads the configuration from the environment, unless it is already configured
+func configured() {
+	configureOnce.Do(configureFromEnv)
+}
+
+func configureFromEnv() {
 	ll, ok := logLevelValue[strings.ToUpper(os.Getenv("LOG_LEVEL"))]
 	if !ok {
-		fmt.Println("logger WARN: LOG_LEVEL is not valid or not set, defaulting to INFO")
-		logLevel = logLevelValue[INFO.String()]
-	} else {
-		logLevel = ll
+		fmt.Fprintln(os.Stderr, "logger WARN: LOG_LEVEL is not valid or not set, defaulting to INFO")
+		ll = INFO
 	}
 
 	if os.Getenv("SERVICE") == "" || os.Getenv("VERSION") == "" {
-		fmt.Println("logger ERROR: cannot instantiate the logger, make sure the SERVICE and VERSION environment vars are set correctly")
+		fmt.Fprintln(os.Stderr, "logger ERROR: cannot instantiate the logger, make sure the SERVICE and VERSION environment vars are set correctly")
 	}
 
-	initConfig(logLevel, os.Getenv("SERVICE"), os.Getenv("VERSION"))
+	setConfig(ll, os.Getenv("SERVICE"), os.Getenv("VERSION"))
 }
 
 func initConfig(lvl severity, svc, ver string) {
-	logLevel = lvl
+	// Skip the configuration from the environment
+	configureOnce.Do(func() {})
+	setConfig(lvl, svc, ver)
+}
+
+func setConfig(lvl severity, svc, ver string) {
+	atomic.StoreInt32(&logLevel, int32(lvl))
 	service = svc
 	version = ver
 }
 
-// New instantiates and returns a Log object
-func New() *Log {
+// SetLevel changes the minimum severity of the entries written by all the loggers.
+// It is safe to call it at any time, concurrently with the logging calls
+func SetLevel(s severity) {
+	configured()
+	atomic.StoreInt32(&logLevel, int32(s))
+}
+
+// ParseLevel returns the severity matching a level name such as "debug" or "WARN"
+func ParseLevel(name string) (severity, error) {
+	s, ok := logLevelValue[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return INFO, fmt.Errorf("logger: unknown log level %q", name)
+	}
+	return s, nil
+}
+
+// GetLevel returns the current minimum severity of the entries written
+func GetLevel() severity {
+	configured()
+	s := severity(atomic.LoadInt32(&logLevel))
+	if b, ok := boostedLevel(); ok && b < s {
+		return b
+	}
+	return s
+}
+
+// New instantiates and returns a Log object configured with the given options. The service,
+// version and level default to the SERVICE, VERSION and LOG_LEVEL environment variables, and
+// can be set per logger with WithService, WithVersion and WithLevel
+func New(opts ...Option) *Log {
+	configured()
+
 	// Set the ServiceContext only within a GCP context
 	p := &Payload{}
 	if service != "" && version != "" {
@@ -121,10 +267,30 @@ func New() *Log {
 		}
 	}
 
-	return &Log{
-		payload: p,
-		writer:  os.Stdout,
+	l := &Log{
+		payload:    p,
+		writer:     os.Stdout,
+		encoder:    JSONEncoder{},
+		mu:         new(sync.Mutex),
+		hooks:      new(hookSet),
+		clock:      time.Now,
+		timeFormat: time.RFC3339Nano,
+		// Keep the exit code of os.Exit(1)
+		exitCode: 1,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithOptions creates a copy of a Log with the given options applied.
+func (l *Log) WithOptions(opts ...Option) *Log {
+	n := l.With(Fields{})
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 // WithOutput creates a copy of a Log with a different output.
@@ -134,72 +300,249 @@ func (l *Log) WithOutput(w io.Writer) *Log {
 	return n
 }
 
-func (l *Log) log(severity, message string) {
-	// Do not persist the payload here, just format it, marshal it and return it
-	l.payload = &Payload{
-		Severity:       severity,
-		EventTime:      time.Now().Format(time.RFC3339),
-		Message:        message,
-		ServiceContext: l.payload.ServiceContext,
-		Context:        l.payload.Context,
-		Stacktrace:     l.payload.Stacktrace,
+func (l *Log) log(severity, message string) error {
+	p := l.entry(severity, message)
+	if l.addCaller {
+		// Skip log and the level method
+		fpc, file, line, _ := runtime.Caller(2 + l.callerSkip)
+		l.setCaller(p, fpc, file, line)
+	}
+	return l.write(p)
+}
+
+// payloadPool recycles the payloads of the entries once they are written
+var payloadPool = sync.Pool{
+	New: func() interface{} {
+		return new(Payload)
+	},
+}
+
+// entry returns a payload for a log entry built from the logger's own payload.
+// It is returned to the pool by write
+func (l *Log) entry(severity, message string) *Payload {
+	p := payloadPool.Get().(*Payload)
+	keys := p.keys
+	*p = *l.payload
+	p.keys = keys[:0]
+	p.Context = l.context()
+	p.Severity = severity
+	p.EventTime = ""
+	p.eventTime = l.clock()
+	p.timeFormat = l.timeFormat
+	p.Message = message
+	p.Stacktrace = ""
+	return p
+}
+
+// write encodes the payload into a pooled buffer and writes it out, newline included, in a
+// single call. The Log itself is never modified so a single *Log can be shared across goroutines
+func (l *Log) write(p *Payload) error {
+	defer payloadPool.Put(p)
+
+	if l.dedup != nil && l.dedup.suppress(l, p) {
+		return nil
+	}
+	return l.emit(p)
+}
+
+// emit redacts the payload, filters it, runs the transformers and the hooks on it, encodes it
+// and writes it out. The entry is counted as dropped when it fails
+func (l *Log) emit(p *Payload) error {
+	// Redact first so the hooks never see the sensitive values
+	if l.redactor != nil {
+		l.redactor.redact(p)
+	}
+	if l.sanitizer != nil {
+		l.sanitizer.sanitize(p)
+	}
+	if !l.hooks.keep(p) {
+		return nil
+	}
+	if l.goroutineID {
+		addGoroutineID(p)
+	}
+	if l.seq != nil {
+		p.Seq = atomic.AddUint64(l.seq, 1)
+	}
+	if l.insertID != nil {
+		p.InsertID = l.insertID()
+	}
+	if l.opFirst != nil && atomic.CompareAndSwapInt32(l.opFirst, 1, 0) {
+		op := *p.Operation
+		op.First = true
+		p.Operation = &op
+	}
+	l.hooks.transform(p)
+	l.hooks.fire(p)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := l.framing.encode(l.encoder, buf, p); err != nil {
+		err = fmt.Errorf("logger: cannot marshal payload: %s", err.Error())
+		dropEntry(err)
+		return err
 	}
 
-	payload, ok := json.Marshal(l.payload)
-	if ok != nil {
-		fmt.Printf("logger ERROR: cannot marshal payload: %s", ok.Error())
+	l.mu.Lock()
+	var err error
+	if lw, ok := l.writer.(levelWriter); ok {
+		_, err = lw.writeLevel(logLevelValue[p.Severity], l.entryTime(p), buf.Bytes())
+	} else {
+		_, err = l.writer.Write(buf.Bytes())
 	}
+	l.mu.Unlock()
 
-	fmt.Fprintln(l.writer, string(payload))
+	// Reported once the lock is released, the OnError function may log with this logger
+	if err != nil {
+		dropEntry(err)
+	}
+	return err
+}
+
+// entryTime returns the time of the entry, parsed back from EventTime for the payloads not built by
+// entry, or the current time when it cannot be parsed with the format set with WithTimeFormat
+func (l *Log) entryTime(p *Payload) time.Time {
+	if !p.eventTime.IsZero() {
+		return p.eventTime
+	}
+	if t, err := time.Parse(l.timeFormat, p.EventTime); err == nil && t.Year() != 0 {
+		return t
+	}
+	return time.Now()
 }
 
 // Checks whether the specified log level is valid in the current environment
 func isValidLogLevel(s severity) bool {
-	return s >= logLevel
+	return s >= GetLevel()
 }
 
-// fields returns a valid Fields whether or not one exists in the *Log.
-func (l *Log) fields() Fields {
-	f := make(Fields)
-	if l.payload == nil {
-		return f
+// isEnabled checks whether the specified log level is valid for this logger
+func (l *Log) isEnabled(s severity) bool {
+	if l.nop {
+		return false
 	}
-	if l.payload.Context == nil {
-		return f
+	if l.level != nil {
+		return s >= *l.level
+	}
+	if l.name != "" {
+		if lvl, ok := namedLevel(l.name); ok {
+			return s >= lvl
+		}
 	}
+	return isValidLogLevel(s)
+}
+
+// check tells whether an entry must be written, according to the level and the sampling
+func (l *Log) check(s severity, message string) bool {
+	return l.isEnabled(s) && l.sampler.allow(s, message)
+}
+
+// Enabled tells whether l writes the entries of a severity, e.g. to skip building expensive fields
+func (l *Log) Enabled(s severity) bool {
+	return l.isEnabled(s)
+}
 
-	if l.payload.Context.Data == nil {
+// WithLevel creates a copy of a Log with its own log level, regardless of the global one
+func (l *Log) WithLevel(s severity) *Log {
+	n := l.With(Fields{})
+	n.level = &s
+	return n
+}
+
+// context returns the context of the entries, shared with the other entries and the derived loggers
+func (l *Log) context() *Context {
+	if l.added != nil {
+		return l.added.context()
+	}
+	if l.payload == nil {
+		return nil
+	}
+	return l.payload.Context
+}
+
+// fields returns a valid Fields whether or not one exists in the *Log.
+func (l *Log) fields() Fields {
+	f := make(Fields)
+	c := l.context()
+	if c == nil {
 		return f
 	}
 
-	for k, v := range l.payload.Context.Data {
+	for k, v := range c.Data {
 		f[k] = v
 	}
 	return f
 }
 
-// With is used as a chained method to specify which values go in the log entry's context
+// With is used as a chained method to specify which values go in the log entry's context. The
+// copy keeps the configuration of l, its writer included. The fields of l are shared rather than
+// copied, only the given ones are allocated
 func (l *Log) With(fields Fields) *Log {
-	f := l.fields()
-	for k, v := range fields {
-		f[k] = v
+	added := l.added
+	if len(fields) > 0 || added == nil {
+		added = l.added.with(l.payload.Context, fields)
 	}
 
+	p := *l.payload
+	p.Stacktrace = ""
+
 	return &Log{
-		payload: &Payload{
-			ServiceContext: l.payload.ServiceContext,
-			Context: &Context{
-				Data: f,
-			},
-			Stacktrace: "",
-		},
-		writer: os.Stdout,
+		payload:      &p,
+		added:        added,
+		writer:       l.writer,
+		encoder:      l.encoder,
+		framing:      l.framing,
+		mu:           l.mu,
+		hooks:        l.hooks,
+		level:        l.level,
+		name:         l.name,
+		sampler:      l.sampler,
+		dedup:        l.dedup,
+		redactor:     l.redactor,
+		sanitizer:    l.sanitizer,
+		stackLimit:   l.stackLimit,
+		stackFilter:  l.stackFilter,
+		stackFrames:  l.stackFrames,
+		addCaller:    l.addCaller,
+		callerFormat: l.callerFormat,
+		callerSkip:   l.callerSkip,
+		seq:          l.seq,
+		insertID:     l.insertID,
+		opFirst:      l.opFirst,
+		goroutineID:  l.goroutineID,
+		clock:        l.clock,
+		timeFormat:   l.timeFormat,
+		exitCode:     l.exitCode,
+		errStack:     l.errStack,
+		trimPaths:    l.trimPaths,
+		fingerprint:  l.fingerprint,
+		nop:          l.nop,
 	}
 }
 
+// Trace prints out a message with TRACE severity level
+func (l Log) Trace(message string) {
+	if !l.check(TRACE, message) {
+		return
+	}
+
+	l.log(TRACE.String(), message)
+}
+
+// Tracef prints out a message with TRACE severity level
+func (l Log) Tracef(message string, args ...interface{}) {
+	// Check the level first to avoid formatting messages that are discarded
+	if !l.check(TRACE, message) {
+		return
+	}
+
+	l.log(TRACE.String(), fmt.Sprintf(message, args...))
+}
+
 // Debug prints out a message with DEBUG severity level
 func (l Log) Debug(message string) {
-	if !isValidLogLevel(DEBUG) {
+	if !l.check(DEBUG, message) {
 		return
 	}
 
@@ -208,12 +551,17 @@ func (l Log) Debug(message string) {
 
 // Debugf prints out a message with DEBUG severity level
 func (l Log) Debugf(message string, args ...interface{}) {
-	l.Debug(fmt.Sprintf(message, args...))
+	// Check the level first to avoid formatting messages that are discarded
+	if !l.check(DEBUG, message) {
+		return
+	}
+
+	l.log(DEBUG.String(), fmt.Sprintf(message, args...))
 }
 
 // Info prints out a message with INFO severity level
 func (l Log) Info(message string) {
-	if !isValidLogLevel(INFO) {
+	if !l.check(INFO, message) {
 		return
 	}
 
@@ -222,17 +570,27 @@ func (l Log) Info(message string) {
 
 // Infof prints out a message with INFO severity level
 func (l Log) Infof(message string, args ...interface{}) {
-	l.Info(fmt.Sprintf(message, args...))
+	// Check the level first to avoid formatting messages that are discarded
+	if !l.check(INFO, message) {
+		return
+	}
+
+	l.log(INFO.String(), fmt.Sprintf(message, args...))
 }
 
 // Printf prints out a message with INFO severity level
 func (l Log) Printf(message string, args ...interface{}) {
-	l.Info(fmt.Sprintf(message, args...))
+	// Check the level first to avoid formatting messages that are discarded
+	if !l.check(INFO, message) {
+		return
+	}
+
+	l.log(INFO.String(), fmt.Sprintf(message, args...))
 }
 
 // Warn prints out a message with WARN severity level
 func (l Log) Warn(message string) {
-	if !isValidLogLevel(WARN) {
+	if !l.check(WARN, message) {
 		return
 	}
 
@@ -241,7 +599,12 @@ func (l Log) Warn(message string) {
 
 // Warnf prints out a message with WARN severity level
 func (l Log) Warnf(message string, args ...interface{}) {
-	l.Warn(fmt.Sprintf(message, args...))
+	// Check the level first to avoid formatting messages that are discarded
+	if !l.check(WARN, message) {
+		return
+	}
+
+	l.log(WARN.String(), fmt.Sprintf(message, args...))
 }
 
 // Error prints out a message with ERROR severity level
@@ -254,51 +617,124 @@ func (l Log) Errorf(message string, args ...interface{}) {
 	l.error(ERROR.String(), fmt.Sprintf(message, args...))
 }
 
-// Fatal is equivalent to Error() followed by a call to os.Exit(1).
+// Fatal is equivalent to Error() followed by a call to os.Exit(1), or to the function set
+// with SetExitFunc and the code set with WithExitCode.
 // It prints out a message with CRITICAL severity level
 func (l Log) Fatal(message string) {
 	l.error(CRITICAL.String(), message)
-	os.Exit(1)
+	exit(l.exitCode)
 }
 
-// Fatalf is equivalent to Errorf() followed by a call to os.Exit(1).
+// Fatalf is equivalent to Errorf() followed by a call to os.Exit(1), or to the function set
+// with SetExitFunc and the code set with WithExitCode.
 // It prints out a message with CRITICAL severity level
 func (l Log) Fatalf(message string, args ...interface{}) {
 	l.error(CRITICAL.String(), fmt.Sprintf(message, args...))
-	os.Exit(1)
+	exit(l.exitCode)
+}
+
+// Panic is equivalent to Error() followed by a call to panic().
+// It prints out a message with CRITICAL severity level
+func (l Log) Panic(message string) {
+	l.error(CRITICAL.String(), message)
+	panic(message)
+}
+
+// Panicf is equivalent to Errorf() followed by a call to panic().
+// It prints out a message with CRITICAL severity level
+func (l Log) Panicf(message string, args ...interface{}) {
+	message = fmt.Sprintf(message, args...)
+	l.error(CRITICAL.String(), message)
+	panic(message)
+}
+
+// Emit prints out a message with the given severity level, ERROR and CRITICAL included, and
+// returns the error of the encoder or of the output, e.g. ENOSPC or a broken pipe, so the callers
+// which cannot afford to lose an entry can detect it. A discarded entry is not an error.
+// Unlike Fatal and Panic, a CRITICAL entry neither exits nor panics
+func (l Log) Emit(s severity, message string) error {
+	if s >= ERROR {
+		return l.error(s.String(), message)
+	}
+	if !l.check(s, message) {
+		return nil
+	}
+	return l.log(s.String(), message)
+}
+
+// Emitf is the formatting version of Emit
+func (l Log) Emitf(s severity, message string, args ...interface{}) error {
+	if s >= ERROR {
+		return l.error(s.String(), fmt.Sprintf(message, args...))
+	}
+	if !l.check(s, message) {
+		return nil
+	}
+	return l.log(s.String(), fmt.Sprintf(message, args...))
 }
 
 // ERROR prints out a message with the passed severity level (ERROR or CRITICAL)
-func (l Log) error(severity, message string) {
-	buffer := make([]byte, 1024)
-	buffer = buffer[:runtime.Stack(buffer, false)]
-	fpc, file, line, _ := runtime.Caller(2)
+func (l Log) error(severity, message string) error {
+	if l.nop {
+		return nil
+	}
+	fpc, file, line, _ := runtime.Caller(2 + l.callerSkip)
+	return l.report(severity, message, fpc, file, line)
+}
 
-	funcName := "unknown"
-	fun := runtime.FuncForPC(fpc)
-	if fun != nil {
-		_, funcName = filepath.Split(fun.Name())
+// report prints out a message with the stacktrace and the given report location
+func (l Log) report(severity, message string, fpc uintptr, file string, line int) error {
+	if l.nop {
+		return nil
 	}
 
-	// Set the data when the context is empty
-	if l.payload.Context == nil {
-		l.payload.Context = &Context{
-			Data: Fields{},
+	var function string
+	if fun := runtime.FuncForPC(fpc); fun != nil {
+		function = fun.Name()
+	}
+	if l.errStack != nil {
+		// Error Reporting groups the errors by where they were created rather than logged
+		function, file, line = errorOrigin(l.errStack)
+	}
+
+	funcName := "unknown"
+	if function != "" {
+		_, funcName = filepath.Split(function)
+		if l.trimPaths {
+			file = trimPath(file, function)
 		}
 	}
 
-	l.payload = &Payload{
-		ServiceContext: l.payload.ServiceContext,
-		Context: &Context{
-			Data: l.payload.Context.Data,
-			ReportLocation: &ReportLocation{
-				FilePath:     file,
-				FunctionName: funcName,
-				LineNumber:   line,
-			},
+	// Build a new context instead of modifying the one shared with other goroutines
+	var data Fields
+	if c := l.context(); c != nil {
+		data = c.Data
+	}
+
+	p := l.entry(severity, message)
+	p.Context = &Context{
+		Data: data,
+		ReportLocation: &ReportLocation{
+			FilePath:     file,
+			FunctionName: funcName,
+			LineNumber:   line,
 		},
-		Stacktrace: string(buffer),
 	}
 
-	l.log(severity, message)
+	pcs := l.errStack
+	if pcs == nil {
+		pcs = callers()
+	}
+	p.Stacktrace = formatStack(pcs, l.stackLimit, l.stackFilter)
+	if l.fingerprint {
+		p.Fingerprint = fingerprint(message, pcs)
+	}
+	if l.addCaller {
+		l.setCaller(p, fpc, file, line)
+	}
+	if l.stackFrames {
+		p.Context.Frames = stackFrames(pcs, l.stackLimit, l.stackFilter)
+	}
+
+	return l.write(p)
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -14,13 +16,13 @@ func TestLoggerInfoWithOneTimeContext(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerDebug",
 	}).WithOutput(buf)
 
 	log.Info("INFO message")
-	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerDebug","key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerDebug","key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -30,7 +32,7 @@ func TestLoggerInfoWithOneTimeContext(t *testing.T) {
 	buf.Reset()
 
 	log.With(Fields{"foo": "bar"}).WithOutput(buf).Info("unique INFO message")
-	expected = fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"unique INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"foo":"bar","function":"TestLoggerDebug","key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected = fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"unique INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"foo":"bar","function":"TestLoggerDebug","key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got = strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output file %s does not match expected string %s", got, expected)
@@ -40,7 +42,7 @@ func TestLoggerInfoWithOneTimeContext(t *testing.T) {
 	buf.Reset()
 
 	log.WithOutput(buf).Info("unique INFO message")
-	expected = fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"unique INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerDebug","key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected = fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"unique INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerDebug","key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got = strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -52,13 +54,13 @@ func TestLoggerErrorWithOneTimeContext(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerError",
 	}).WithOutput(buf)
 
 	log.Error("ERROR message")
-	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not contain substring %s", got, expected)
@@ -78,7 +80,7 @@ func TestLoggerErrorWithOneTimeContext(t *testing.T) {
 	buf.Reset()
 
 	log.With(Fields{"foo": "bar"}).WithOutput(buf).Error("unique ERROR message")
-	expected = fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"unique ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"foo":"bar","function":"TestLoggerError","key":"value"},"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected = fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"unique ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"foo":"bar","function":"TestLoggerError","key":"value"},"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got = strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not contain substring %s", got, expected)
@@ -98,7 +100,7 @@ func TestLoggerErrorWithOneTimeContext(t *testing.T) {
 	buf.Reset()
 
 	log.WithOutput(buf).Error("unique ERROR message")
-	expected = fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"unique ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected = fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"unique ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got = strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not contain substring %s", got, expected)
@@ -120,7 +122,7 @@ func TestLoggerWithDifferentLogLevels(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key": "value",
 	}).WithOutput(buf)
 
@@ -141,7 +143,7 @@ func TestLoggerWithDifferentLogLevels(t *testing.T) {
 	}
 
 	log.Warn("WARN message")
-	expected := fmt.Sprintf(`{"severity":"WARN","eventTime":"%s","message":"WARN message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"WARN","eventTime":"%s","message":"WARN message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got = strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -151,7 +153,7 @@ func TestLoggerWithDifferentLogLevels(t *testing.T) {
 	buf.Reset()
 
 	log.Error("ERROR message")
-	expected = fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected = fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got = strings.TrimRight(buf.String(), "\n")
 	if strings.Contains(got, expected) {
 		t.Errorf("expecting %s; got %s", expected, got)
@@ -163,14 +165,14 @@ func TestLoggerDebugWithImplicitContext(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerDebug",
 	}).WithOutput(buf)
 
 	log.Debug("DEBUG message")
 
-	expected := fmt.Sprintf(`{"severity":"DEBUG","eventTime":"%s","message":"DEBUG message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerDebug","key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"DEBUG","eventTime":"%s","message":"DEBUG message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerDebug","key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -181,10 +183,10 @@ func TestLoggerDebugWithoutContext(t *testing.T) {
 	initConfig(DEBUG, "my-app", "1.0")
 
 	buf := new(bytes.Buffer)
-	log := New().WithOutput(buf)
+	log := New(WithClock(testClock)).WithOutput(buf)
 
 	log.Debug("DEBUG message")
-	expected := fmt.Sprintf(`{"severity":"DEBUG","eventTime":"%s","message":"DEBUG message","serviceContext":{"service":"my-app","version":"1.0"},"context":{}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"DEBUG","eventTime":"%s","message":"DEBUG message","serviceContext":{"service":"my-app","version":"1.0"},"context":{}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -196,29 +198,59 @@ func TestLoggerDebugfWithoutContext(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().WithOutput(buf)
+	log := New(WithClock(testClock)).WithOutput(buf)
 
 	param := "with param"
 	log.Debugf("DEBUG message %s", param)
-	expected := fmt.Sprintf(`{"severity":"DEBUG","eventTime":"%s","message":"DEBUG message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"DEBUG","eventTime":"%s","message":"DEBUG message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
 	}
 }
 
+func TestLoggerTrace(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithClock(testClock)).WithOutput(buf)
+
+	// LogLevel set to DEBUG, TRACE messages should not be output
+	log.Trace("TRACE message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+
+	SetLevel(TRACE)
+	defer SetLevel(DEBUG)
+
+	log.Tracef("TRACE message %s", "with param")
+	expected := fmt.Sprintf(`{"severity":"TRACE","eventTime":"%s","message":"TRACE message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{}}`, testTime.Format(time.RFC3339Nano))
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestParseLevelTrace(t *testing.T) {
+	s, err := ParseLevel("trace")
+	if err != nil || s != TRACE || s >= DEBUG {
+		t.Errorf("expected TRACE below DEBUG; got %s, %v", s, err)
+	}
+}
+
 func TestLoggerInfo(t *testing.T) {
 	initConfig(DEBUG, "my-app", "1.0")
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerInfo",
 	}).WithOutput(buf)
 
 	log.Info("INFO message")
-	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerInfo","key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerInfo","key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -230,14 +262,14 @@ func TestLoggerInfof(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerInfo",
 	}).WithOutput(buf)
 
 	param := "with param"
 	log.Infof("INFO message %s", param)
-	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerInfo","key":"value"}}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerInfo","key":"value"}}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output %s does not match expected string %s", got, expected)
@@ -248,7 +280,7 @@ func TestResponseIsValidJson(t *testing.T) {
 	initConfig(DEBUG, "my-app", "1.0")
 
 	buf := new(bytes.Buffer)
-	log := New().With(Fields{"key": "value"}).WithOutput(buf)
+	log := New(WithClock(testClock)).With(Fields{"key": "value"}).WithOutput(buf)
 
 	log.Error("ERROR message")
 	got := strings.TrimRight(buf.String(), "\n")
@@ -264,7 +296,7 @@ func TestGetCallerFunctionName(t *testing.T) {
 	initConfig(DEBUG, "my-app", "1.0")
 
 	buf := new(bytes.Buffer)
-	log := New().With(Fields{"key": "value"}).WithOutput(buf)
+	log := New(WithClock(testClock)).With(Fields{"key": "value"}).WithOutput(buf)
 
 	log.Error("ERROR message")
 	got := strings.TrimRight(buf.String(), "\n")
@@ -287,13 +319,13 @@ func TestLoggerError(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerError",
 	}).WithOutput(buf)
 
 	log.Error("ERROR message")
-	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not containsubstring %s", got, expected)
@@ -315,10 +347,10 @@ func TestLoggerErrorWithoutContext(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().WithOutput(buf)
+	log := New(WithClock(testClock)).WithOutput(buf)
 
 	log.Error("ERROR message")
-	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not containsubstring %s", got, expected)
@@ -340,33 +372,60 @@ func TestLoggerErrorf(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"key":      "value",
 		"function": "TestLoggerError",
 	}).WithOutput(buf)
 
 	param := "with param"
 	log.Errorf("ERROR message %s", param)
-	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerError","key":"value"},"reportLocation"`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not containsubstring %s", got, expected)
 	}
 }
 
+func TestLoggerPanicf(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithClock(testClock)).WithOutput(buf)
+
+	defer func() {
+		r := recover()
+		if r != "CRITICAL message with param" {
+			t.Errorf("expected a panic with the message; got %v", r)
+		}
+
+		p := Payload{}
+		if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+			t.Fatalf("failed to unmarshal payload: %s", err.Error())
+		}
+		if p.Severity != "CRITICAL" || p.Message != "CRITICAL message with param" || p.Stacktrace == "" {
+			t.Errorf("unexpected payload %s", buf.String())
+		}
+		if p.Context.ReportLocation.FunctionName != "logger.TestLoggerPanicf" {
+			t.Errorf("report location %+v does not point to the test", p.Context.ReportLocation)
+		}
+	}()
+
+	log.Panicf("CRITICAL message %s", "with param")
+}
+
 func TestLoggerInfoWithSeveralContextEntries(t *testing.T) {
 	initConfig(DEBUG, "my-app", "1.0")
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"function": "TestLoggerInfo",
 		"key":      "value",
 		"package":  "logger",
 	}).WithOutput(buf)
 
 	log.Info("INFO message")
-	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerInfo","key":"value","package":"logger"}}}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"INFO","eventTime":"%s","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"function":"TestLoggerInfo","key":"value","package":"logger"}}}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if expected != got {
 		t.Errorf("output file %s does not match expected string %s", got, expected)
@@ -378,14 +437,14 @@ func TestLoggerErrorWithSeveralContextEntries(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 
-	log := New().With(Fields{
+	log := New(WithClock(testClock)).With(Fields{
 		"function": "TestLoggerError",
 		"key":      "value",
 		"package":  "logger",
 	}).WithOutput(buf)
 
 	log.Error("ERROR message")
-	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"}`, time.Now().Format(time.RFC3339))
+	expected := fmt.Sprintf(`{"severity":"ERROR","eventTime":"%s","message":"ERROR message","serviceContext":{"service":"my-app","version":"1.0"}`, testTime.Format(time.RFC3339Nano))
 	got := strings.TrimRight(buf.String(), "\n")
 	if !strings.Contains(got, expected) {
 		t.Errorf("output %s does not containsubstring %s", got, expected)
@@ -401,3 +460,162 @@ func TestLoggerErrorWithSeveralContextEntries(t *testing.T) {
 		t.Errorf("output file %s does not contain a stacktrace key", got)
 	}
 }
+
+func TestLoggerConcurrentWrites(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithClock(testClock)).WithOutput(buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				log.Infof("INFO message %d", i)
+				log.Errorf("ERROR message %d", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2000 {
+		t.Fatalf("expected 2000 entries; got %d", len(lines))
+	}
+
+	for _, line := range lines {
+		p := Payload{}
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatalf("entry %s cannot be unmarshalled: %s", line, err.Error())
+		}
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	buf := new(bytes.Buffer)
+	log := New(WithClock(testClock)).WithOutput(buf)
+
+	log.Debug("DEBUG message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+
+	SetLevel(DEBUG)
+	if GetLevel() != DEBUG {
+		t.Errorf("expected level DEBUG; got %s", GetLevel())
+	}
+
+	// Existing loggers pick up the new level right away
+	log.Debug("DEBUG message")
+	if !strings.Contains(buf.String(), `"severity":"DEBUG"`) {
+		t.Errorf("output %s does not contain the DEBUG entry", buf.String())
+	}
+
+	buf.Reset()
+	SetLevel(ERROR)
+	log.Warn("WARN message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+}
+
+func TestLoggerWithLevel(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	buf := new(bytes.Buffer)
+	log := New(WithClock(testClock)).WithOutput(buf)
+	verbose := log.WithLevel(DEBUG)
+
+	log.Debug("DEBUG message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+
+	verbose.Debug("DEBUG message")
+	if !strings.Contains(buf.String(), `"severity":"DEBUG"`) {
+		t.Errorf("output %s does not contain the DEBUG entry", buf.String())
+	}
+	if log.Enabled(DEBUG) || !verbose.Enabled(DEBUG) || verbose.Enabled(TRACE) {
+		t.Errorf("unexpected Enabled results for the INFO and DEBUG loggers")
+	}
+
+	// Derived loggers keep the level override
+	buf.Reset()
+	verbose.With(Fields{"key": "value"}).WithOutput(buf).Debug("DEBUG message")
+	if !strings.Contains(buf.String(), `"severity":"DEBUG"`) {
+		t.Errorf("output %s does not contain the DEBUG entry", buf.String())
+	}
+
+	// The override ignores the changes of the global level
+	buf.Reset()
+	quiet := log.WithLevel(WARN)
+	SetLevel(DEBUG)
+	quiet.Info("INFO message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+}
+
+func TestWithKeepsConfiguration(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithLogfmtOutput(), WithLevel(WARN))
+	var fired int
+	log.AddHook(HookFunc(func(p *Payload) error {
+		fired++
+		return nil
+	}), WARN)
+
+	derived := []*Log{
+		log.With(Fields{"key": "value"}),
+		log.WithKV("key", "value"),
+		log.WithFields(Any("key", "value")),
+		log.WithError(errTest),
+		log.Named("http"),
+		log.WithLabels(map[string]string{"env": "test"}),
+		log.WithTrace("105445aa7843bc8bf206b12000100000", "1", true),
+		log.With(Fields{"a": 1}).With(Fields{"b": 2}),
+	}
+	for i, l := range derived {
+		buf.Reset()
+		l.Info("INFO message")
+		l.Warn("WARN message")
+		if got := buf.String(); strings.Contains(got, "INFO message") || !strings.HasPrefix(got, "ts=") || !strings.Contains(got, "WARN message") {
+			t.Errorf("derived logger %d: unexpected output %q", i, got)
+		}
+	}
+	if fired != len(derived) {
+		t.Errorf("expected the hook to fire %d times; got %d", len(derived), fired)
+	}
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	defer initConfig(DEBUG, "my-app", "1.0")
+
+	defer os.Setenv("LOG_LEVEL", os.Getenv("LOG_LEVEL"))
+	defer os.Setenv("SERVICE", os.Getenv("SERVICE"))
+	defer os.Setenv("VERSION", os.Getenv("VERSION"))
+	os.Setenv("LOG_LEVEL", "warn")
+	os.Setenv("SERVICE", "env-app")
+	os.Setenv("VERSION", "2.0")
+
+	// The environment is read on first use, not at import
+	configureOnce = sync.Once{}
+	if GetLevel() != WARN || service != "env-app" || version != "2.0" {
+		t.Errorf("expected WARN, env-app and 2.0; got %s, %s and %s", GetLevel(), service, version)
+	}
+
+	// Configure takes precedence over the environment
+	configureOnce = sync.Once{}
+	Configure(ERROR, "my-app", "3.0")
+	if GetLevel() != ERROR || service != "my-app" || version != "3.0" {
+		t.Errorf("expected ERROR, my-app and 3.0; got %s, %s and %s", GetLevel(), service, version)
+	}
+}
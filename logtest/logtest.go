@@ -0,0 +1,164 @@
+// Package logtest captures the entries of a logger.Log as structured values, so the tests of the
+// programs using the logger can assert on them instead of matching the raw JSON output:
+//
+//	log, rec := logtest.New()
+//	doSomething(log)
+//	rec.AssertLogged(t, logger.WARN, "retrying", logger.Fields{"attempt": 2})
+package logtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/teltech/logger"
+)
+
+// Entry is a log entry captured by a Recorder
+type Entry struct {
+	Severity string
+	Message  string
+	Fields   logger.Fields
+	// Payload is the whole decoded entry
+	Payload logger.Payload
+}
+
+// Recorder is an io.Writer decoding the JSON entries written by a logger. It is safe for concurrent use
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New returns a logger writing every entry, whatever its severity, to a new Recorder. The options
+// are applied after the ones set by New, so they can override them
+func New(opts ...logger.Option) (*logger.Log, *Recorder) {
+	rec := NewRecorder()
+	opts = append([]logger.Option{logger.WithLevel(logger.TRACE), logger.WithWriter(rec)}, opts...)
+	return logger.New(opts...), rec
+}
+
+// NewRecorder returns an empty Recorder, to be set as the output of a logger
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Write decodes the entries of p, one per line
+func (r *Recorder) Write(p []byte) (int, error) {
+	var entries []Entry
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var payload logger.Payload
+		if err := json.Unmarshal(line, &payload); err != nil {
+			return 0, fmt.Errorf("logtest: cannot decode the entry %s: %s", line, err.Error())
+		}
+
+		e := Entry{
+			Severity: payload.Severity,
+			Message:  payload.Message,
+			Payload:  payload,
+		}
+		if payload.Context != nil {
+			e.Fields = payload.Context.Data
+		}
+		entries = append(entries, e)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entries...)
+	return len(p), nil
+}
+
+// Entries returns the captured entries, oldest first
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// FilterBySeverity returns the captured entries with the given severity, e.g. logger.ERROR
+func (r *Recorder) FilterBySeverity(level fmt.Stringer) []Entry {
+	var entries []Entry
+	for _, e := range r.Entries() {
+		if e.Severity == level.String() {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Reset discards the captured entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Find returns the first entry with the given severity whose message contains substr and whose
+// context contains all the fields, or false when there is none
+func (r *Recorder) Find(level fmt.Stringer, substr string, fields logger.Fields) (Entry, bool) {
+	for _, e := range r.FilterBySeverity(level) {
+		if strings.Contains(e.Message, substr) && e.hasFields(fields) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// AssertLogged fails the test when no entry with the given severity has a message containing
+// substr and a context containing all the fields. The field values are compared after a JSON
+// round trip, so an int matches the float64 decoded from the entry
+func (r *Recorder) AssertLogged(t testing.TB, level fmt.Stringer, substr string, fields logger.Fields) {
+	t.Helper()
+	if _, ok := r.Find(level, substr, fields); !ok {
+		t.Errorf("logtest: no %s entry containing %q with fields %v in:\n%s", level, substr, fields, r)
+	}
+}
+
+// AssertNotLogged fails the test when an entry with the given severity has a message containing substr
+func (r *Recorder) AssertNotLogged(t testing.TB, level fmt.Stringer, substr string) {
+	t.Helper()
+	if e, ok := r.Find(level, substr, nil); ok {
+		t.Errorf("logtest: unexpected %s entry %q", level, e.Message)
+	}
+}
+
+// String lists the captured entries, for the failure messages
+func (r *Recorder) String() string {
+	buf := new(bytes.Buffer)
+	for _, e := range r.Entries() {
+		fmt.Fprintf(buf, "\t%s %q %v\n", e.Severity, e.Message, e.Fields)
+	}
+	return buf.String()
+}
+
+// hasFields tells whether the context of the entry contains all the fields
+func (e Entry) hasFields(fields logger.Fields) bool {
+	for k, want := range fields {
+		got, ok := e.Fields[k]
+		if !ok || !reflect.DeepEqual(got, normalize(want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize converts v to the value decoded from its JSON encoding
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
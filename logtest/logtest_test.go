@@ -0,0 +1,87 @@
+package logtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/teltech/logger"
+)
+
+// fakeT records the failures of the assertions
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+}
+
+func TestRecorder(t *testing.T) {
+	log, rec := New()
+
+	log.Debug("starting")
+	log.With(logger.Fields{"attempt": 2, "tags": []string{"a", "b"}}).WithOutput(rec).Warn("retrying the request")
+	log.WithError(errors.New("timeout")).Error("request failed")
+
+	entries := rec.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries; got %d", len(entries))
+	}
+	if entries[0].Severity != "DEBUG" || entries[0].Message != "starting" {
+		t.Errorf("unexpected entry %+v", entries[0])
+	}
+	if entries[2].Payload.Stacktrace == "" {
+		t.Errorf("expected the stacktrace in the payload")
+	}
+
+	if got := rec.FilterBySeverity(logger.WARN); len(got) != 1 || got[0].Message != "retrying the request" {
+		t.Errorf("unexpected WARN entries %+v", got)
+	}
+
+	rec.AssertLogged(t, logger.WARN, "retrying", logger.Fields{"attempt": 2, "tags": []string{"a", "b"}})
+	rec.AssertLogged(t, logger.ERROR, "failed", nil)
+	rec.AssertNotLogged(t, logger.INFO, "starting")
+
+	rec.Reset()
+	if len(rec.Entries()) != 0 {
+		t.Errorf("expected no entries after Reset")
+	}
+}
+
+func TestAssertLoggedFails(t *testing.T) {
+	log, rec := New()
+	log.With(logger.Fields{"attempt": 2}).WithOutput(rec).Warn("retrying the request")
+
+	tests := []func(t testing.TB){
+		func(t testing.TB) { rec.AssertLogged(t, logger.INFO, "retrying", nil) },
+		func(t testing.TB) { rec.AssertLogged(t, logger.WARN, "succeeded", nil) },
+		func(t testing.TB) { rec.AssertLogged(t, logger.WARN, "retrying", logger.Fields{"attempt": 3}) },
+		func(t testing.TB) { rec.AssertLogged(t, logger.WARN, "retrying", logger.Fields{"host": "db"}) },
+		func(t testing.TB) { rec.AssertNotLogged(t, logger.WARN, "retrying") },
+	}
+
+	for i, assert := range tests {
+		ft := &fakeT{}
+		assert(ft)
+		if !ft.failed {
+			t.Errorf("assertion %d: expected a failure", i)
+		}
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	log, rec := New(logger.WithLevel(logger.WARN), logger.WithService("billing"))
+	log.Info("ignored")
+	log.Warn("kept")
+
+	entries := rec.Entries()
+	if len(entries) != 1 || entries[0].Message != "kept" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if entries[0].Payload.ServiceContext == nil || entries[0].Payload.ServiceContext.Service != "billing" {
+		t.Errorf("unexpected service context %+v", entries[0].Payload.ServiceContext)
+	}
+}
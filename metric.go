@@ -0,0 +1,39 @@
+package logger
+
+import "runtime"
+
+// Metric is a measurement written as the "metric" of an INFO entry, a stable shape the log-based
+// metrics can extract, e.g. with the jsonPayload.metric.value field of a distribution metric
+type Metric struct {
+	// Name identifies the metric, it is also the message of the entry
+	Name string `json:"name"`
+	// Value is the measurement
+	Value float64 `json:"value"`
+	// Unit is the unit of the value, e.g. "ms" or "By"
+	Unit string `json:"unit,omitempty"`
+	// Tags are the dimensions of the measurement
+	Tags Fields `json:"tags,omitempty"`
+}
+
+// Metric prints out a measurement with INFO severity level:
+//
+//	log.Metric("checkout.latency", 42.5, "ms", logger.Fields{"region": "us-east1"})
+func (l Log) Metric(name string, value float64, unit string, tags Fields) {
+	if !l.check(INFO, name) {
+		return
+	}
+
+	p := l.entry(INFO.String(), name)
+	p.Metric = &Metric{
+		Name:  name,
+		Value: value,
+		Unit:  unit,
+		Tags:  tags,
+	}
+	if l.addCaller {
+		// Skip Metric
+		fpc, file, line, _ := runtime.Caller(1 + l.callerSkip)
+		l.setCaller(p, fpc, file, line)
+	}
+	l.write(p)
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMetric(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf))
+	log.Metric("checkout.latency", 42.5, "ms", Fields{"region": "us-east1"})
+	log.Metric("queue.depth", 7, "", nil)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries; got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], `{"severity":"INFO",`) ||
+		!strings.HasSuffix(lines[0], `"message":"checkout.latency","serviceContext":{"service":"my-app","version":"1.0"},"metric":{"name":"checkout.latency","value":42.5,"unit":"ms","tags":{"region":"us-east1"}}}`) {
+		t.Errorf("unexpected entry %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `"metric":{"name":"queue.depth","value":7}}`) {
+		t.Errorf("unexpected entry %s", lines[1])
+	}
+}
+
+func TestMetricLevel(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithWriter(buf), WithLevel(WARN)).Metric("queue.depth", 7, "", nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected no entry below INFO; got %s", buf.String())
+	}
+}
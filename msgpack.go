@@ -0,0 +1,605 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// MsgpackEncoder encodes a payload as a MessagePack map, with the keys and the values of the
+// JSON format, for the high volume services where the size and the cost of JSON matter. The
+// entries are not followed by a newline, MessagePack values being self-delimiting, so the output
+// must be written to a file or a socket rather than a line based writer; MsgpackDecoder reads it back
+type MsgpackEncoder struct{}
+
+// WithMsgpackOutput sets a MsgpackEncoder as the log entries format
+func WithMsgpackOutput() Option {
+	return WithEncoder(MsgpackEncoder{})
+}
+
+// Encode marshals the payload to a MessagePack map
+func (e MsgpackEncoder) Encode(p *Payload) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := e.encodeTo(buf, p)
+	return buf.Bytes(), err
+}
+
+// binary tells encode not to add a newline after the entries
+func (MsgpackEncoder) binary() {}
+
+func (MsgpackEncoder) encodeTo(buf *bytes.Buffer, p *Payload) error {
+	m := startMsgpackMap(buf)
+	m.key("severity")
+	writeMsgpackString(buf, p.Severity)
+	m.key("eventTime")
+	writeMsgpackString(buf, p.EventTime)
+	if p.Seq != 0 {
+		m.key("seq")
+		writeMsgpackUint(buf, p.Seq)
+	}
+	m.stringField("caller", p.Caller)
+	m.key("message")
+	writeMsgpackString(buf, p.Message)
+
+	if sc := p.ServiceContext; sc != nil {
+		m.key("serviceContext")
+		scm := startMsgpackMap(buf)
+		scm.stringField("service", sc.Service)
+		scm.stringField("version", sc.Version)
+		scm.end()
+	}
+
+	if c := p.Context; c != nil {
+		m.key("context")
+		cm := startMsgpackMap(buf)
+		if len(c.Data) > 0 {
+			cm.key("data")
+			if err := writeMsgpackFields(buf, c.Data, 0); err != nil {
+				return err
+			}
+		}
+		if loc := c.ReportLocation; loc != nil {
+			cm.key("reportLocation")
+			lm := startMsgpackMap(buf)
+			lm.key("filePath")
+			writeMsgpackString(buf, loc.FilePath)
+			lm.key("functionName")
+			writeMsgpackString(buf, loc.FunctionName)
+			lm.key("lineNumber")
+			writeMsgpackInt(buf, int64(loc.LineNumber))
+			lm.end()
+		}
+		if len(c.Frames) > 0 {
+			cm.key("frames")
+			writeMsgpackArrayHeader(buf, len(c.Frames))
+			for _, f := range c.Frames {
+				fm := startMsgpackMap(buf)
+				fm.key("function")
+				writeMsgpackString(buf, f.Function)
+				fm.key("file")
+				writeMsgpackString(buf, f.File)
+				fm.key("line")
+				writeMsgpackInt(buf, int64(f.Line))
+				fm.end()
+			}
+		}
+		cm.end()
+	}
+
+	m.stringField("stacktrace", p.Stacktrace)
+	m.stringField("fingerprint", p.Fingerprint)
+	m.stringField("logging.googleapis.com/trace", p.Trace)
+	m.stringField("logging.googleapis.com/spanId", p.SpanID)
+	if p.TraceSampled {
+		m.key("logging.googleapis.com/trace_sampled")
+		buf.WriteByte(0xc3)
+	}
+	if loc := p.SourceLocation; loc != nil {
+		m.key("logging.googleapis.com/sourceLocation")
+		lm := startMsgpackMap(buf)
+		lm.key("file")
+		writeMsgpackString(buf, loc.File)
+		lm.key("line")
+		writeMsgpackString(buf, strconv.Itoa(loc.Line))
+		lm.key("function")
+		writeMsgpackString(buf, loc.Function)
+		lm.end()
+	}
+	m.stringField("logging.googleapis.com/insertId", p.InsertID)
+	if len(p.Labels) > 0 {
+		m.key("logging.googleapis.com/labels")
+		lm := startMsgpackMap(buf)
+		for _, k := range sortedLabels(p.Labels) {
+			lm.key(k)
+			writeMsgpackString(buf, p.Labels[k])
+		}
+		lm.end()
+	}
+	if op := p.Operation; op != nil {
+		m.key("logging.googleapis.com/operation")
+		om := startMsgpackMap(buf)
+		om.stringField("id", op.ID)
+		om.stringField("producer", op.Producer)
+		if op.First {
+			om.key("first")
+			buf.WriteByte(0xc3)
+		}
+		if op.Last {
+			om.key("last")
+			buf.WriteByte(0xc3)
+		}
+		om.end()
+	}
+	if r := p.HTTPRequest; r != nil {
+		m.key("httpRequest")
+		js := getBuffer()
+		writeJSONHTTPRequest(js, r)
+		err := writeMsgpackJSON(buf, js.Bytes())
+		putBuffer(js)
+		if err != nil {
+			return err
+		}
+	}
+	if mt := p.Metric; mt != nil {
+		m.key("metric")
+		mm := startMsgpackMap(buf)
+		mm.key("name")
+		writeMsgpackString(buf, mt.Name)
+		mm.key("value")
+		writeMsgpackFloat(buf, mt.Value)
+		mm.stringField("unit", mt.Unit)
+		if len(mt.Tags) > 0 {
+			mm.key("tags")
+			if err := writeMsgpackFields(buf, mt.Tags, 0); err != nil {
+				return err
+			}
+		}
+		mm.end()
+	}
+
+	m.end()
+	return nil
+}
+
+// msgpackMap writes a map whose size is only known once its entries are written: the map16
+// header is written first and its size is set by end
+type msgpackMap struct {
+	buf   *bytes.Buffer
+	start int
+	n     int
+}
+
+func startMsgpackMap(buf *bytes.Buffer) msgpackMap {
+	m := msgpackMap{buf: buf, start: buf.Len()}
+	buf.Write([]byte{0xde, 0, 0})
+	return m
+}
+
+func (m *msgpackMap) key(k string) {
+	m.n++
+	writeMsgpackString(m.buf, k)
+}
+
+// stringField writes the key and the value when the value is not empty
+func (m *msgpackMap) stringField(k, v string) {
+	if v != "" {
+		m.key(k)
+		writeMsgpackString(m.buf, v)
+	}
+}
+
+func (m *msgpackMap) end() {
+	binary.BigEndian.PutUint16(m.buf.Bytes()[m.start+1:], uint16(m.n))
+}
+
+func writeMsgpackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		buf.Write([]byte{0xda, byte(n >> 8), byte(n)})
+	default:
+		buf.Write([]byte{0xdb, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgpackArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.Write([]byte{0xdc, byte(n >> 8), byte(n)})
+	default:
+		buf.Write([]byte{0xdd, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+	}
+}
+
+func writeMsgpackInt(buf *bytes.Buffer, i int64) {
+	if i >= 0 {
+		writeMsgpackUint(buf, uint64(i))
+		return
+	}
+	switch {
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		buf.Write([]byte{0xd1, byte(i >> 8), byte(i)})
+	case i >= math.MinInt32:
+		buf.Write([]byte{0xd2, byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)})
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func writeMsgpackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u < 128:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		buf.Write([]byte{0xcd, byte(u >> 8), byte(u)})
+	case u <= math.MaxUint32:
+		buf.Write([]byte{0xce, byte(u >> 24), byte(u >> 16), byte(u >> 8), byte(u)})
+	default:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+func writeMsgpackFloat(buf *bytes.Buffer, f float64) {
+	buf.WriteByte(0xcb)
+	binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+}
+
+// writeMsgpackFields writes the fields as a map with sorted keys
+func writeMsgpackFields(buf *bytes.Buffer, f map[string]interface{}, depth int) error {
+	m := startMsgpackMap(buf)
+	for _, k := range sortedKeys(f) {
+		m.key(k)
+		if err := writeMsgpackValue(buf, f[k], depth+1); err != nil {
+			return err
+		}
+	}
+	m.end()
+	return nil
+}
+
+// writeMsgpackValue writes a Fields value. The common types are written directly, any other
+// value is encoded as JSON first, so it is converted like in the JSON entries
+func writeMsgpackValue(buf *bytes.Buffer, v interface{}, depth int) error {
+	if depth > maxJSONDepth {
+		return &json.UnsupportedValueError{Str: "nested too deeply, it may be cyclic"}
+	}
+
+	switch val := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case string:
+		writeMsgpackString(buf, val)
+	case bool:
+		if val {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int:
+		writeMsgpackInt(buf, int64(val))
+	case int8:
+		writeMsgpackInt(buf, int64(val))
+	case int16:
+		writeMsgpackInt(buf, int64(val))
+	case int32:
+		writeMsgpackInt(buf, int64(val))
+	case int64:
+		writeMsgpackInt(buf, val)
+	case uint:
+		writeMsgpackUint(buf, uint64(val))
+	case uint8:
+		writeMsgpackUint(buf, uint64(val))
+	case uint16:
+		writeMsgpackUint(buf, uint64(val))
+	case uint32:
+		writeMsgpackUint(buf, uint64(val))
+	case uint64:
+		writeMsgpackUint(buf, val)
+	case float32:
+		if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+			return &json.UnsupportedValueError{Str: strconv.FormatFloat(float64(val), 'g', -1, 32)}
+		}
+		buf.WriteByte(0xca)
+		binary.Write(buf, binary.BigEndian, math.Float32bits(val))
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return &json.UnsupportedValueError{Str: strconv.FormatFloat(val, 'g', -1, 64)}
+		}
+		writeMsgpackFloat(buf, val)
+	case Fields:
+		return writeMsgpackFields(buf, val, depth)
+	case map[string]interface{}:
+		return writeMsgpackFields(buf, val, depth)
+	case []string:
+		if val == nil {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		writeMsgpackArrayHeader(buf, len(val))
+		for _, s := range val {
+			writeMsgpackString(buf, s)
+		}
+	case []interface{}:
+		if val == nil {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		writeMsgpackArrayHeader(buf, len(val))
+		for _, e := range val {
+			if err := writeMsgpackValue(buf, e, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		js := getBuffer()
+		defer putBuffer(js)
+		if err := writeJSONNested(js, val, depth); err != nil {
+			return err
+		}
+		return writeMsgpackJSON(buf, js.Bytes())
+	}
+	return nil
+}
+
+// writeMsgpackJSON writes a JSON document, keeping the integers exact
+func writeMsgpackJSON(buf *bytes.Buffer, js []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return writeMsgpackDecoded(buf, v)
+}
+
+// writeMsgpackDecoded writes a value decoded by encoding/json with UseNumber
+func writeMsgpackDecoded(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			writeMsgpackInt(buf, i)
+		} else if u, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+			writeMsgpackUint(buf, u)
+		} else {
+			f, err := val.Float64()
+			if err != nil {
+				return err
+			}
+			writeMsgpackFloat(buf, f)
+		}
+	case map[string]interface{}:
+		m := startMsgpackMap(buf)
+		for _, k := range sortedKeys(val) {
+			m.key(k)
+			if err := writeMsgpackDecoded(buf, val[k]); err != nil {
+				return err
+			}
+		}
+		m.end()
+	case []interface{}:
+		writeMsgpackArrayHeader(buf, len(val))
+		for _, e := range val {
+			if err := writeMsgpackDecoded(buf, e); err != nil {
+				return err
+			}
+		}
+	default:
+		// nil, bool and string
+		return writeMsgpackValue(buf, val, 0)
+	}
+	return nil
+}
+
+// MsgpackDecoder reads back the entries written with a MsgpackEncoder
+type MsgpackDecoder struct {
+	r *bufio.Reader
+}
+
+// NewMsgpackDecoder returns a MsgpackDecoder reading the entries from r
+func NewMsgpackDecoder(r io.Reader) *MsgpackDecoder {
+	return &MsgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next entry. The integers are decoded as int64 or uint64, the floats as
+// float32 or float64, the maps as map[string]interface{} and the arrays as []interface{}.
+// It returns io.EOF when there are no more entries
+func (d *MsgpackDecoder) Decode() (map[string]interface{}, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+
+	v, err := d.value(0)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("logger: msgpack entry is a %T, not a map", v)
+	}
+	return m, nil
+}
+
+// errMsgpackDepth stops the decoding of the deeply nested, possibly malicious, values
+var errMsgpackDepth = errors.New("logger: msgpack value nested too deeply")
+
+func (d *MsgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > maxJSONDepth {
+		return nil, errMsgpackDepth
+	}
+
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.mapValue(int(b&0x0f), depth)
+	case b&0xf0 == 0x90:
+		return d.array(int(b&0x0f), depth)
+	case b&0xe0 == 0xa0:
+		return d.str(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		n, err := d.uint(1)
+		return d.strOrBin(b, int(n), err)
+	case 0xc5, 0xda:
+		n, err := d.uint(2)
+		return d.strOrBin(b, int(n), err)
+	case 0xc6, 0xdb:
+		n, err := d.uint(4)
+		return d.strOrBin(b, int(n), err)
+	case 0xca:
+		n, err := d.uint(4)
+		return math.Float32frombits(uint32(n)), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (b - 0xcc))
+	case 0xd0:
+		n, err := d.uint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := d.uint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := d.uint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := d.uint(8)
+		return int64(n), err
+	case 0xdc:
+		n, err := d.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n), depth)
+	case 0xdd:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n), depth)
+	case 0xde:
+		n, err := d.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(int(n), depth)
+	case 0xdf:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(int(n), depth)
+	}
+	return nil, fmt.Errorf("logger: unsupported msgpack type 0x%02x", b)
+}
+
+// uint reads a big endian unsigned integer of size bytes
+func (d *MsgpackDecoder) uint(size int) (uint64, error) {
+	var n uint64
+	for i := 0; i < size; i++ {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+func (d *MsgpackDecoder) bytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(d.r, b)
+	return b, err
+}
+
+func (d *MsgpackDecoder) str(n int) (interface{}, error) {
+	b, err := d.bytes(n)
+	return string(b), err
+}
+
+// strOrBin reads a string, or a binary for the bin types
+func (d *MsgpackDecoder) strOrBin(typ byte, n int, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if typ >= 0xc4 && typ <= 0xc6 {
+		return d.bytes(n)
+	}
+	return d.str(n)
+}
+
+func (d *MsgpackDecoder) array(n int, depth int) (interface{}, error) {
+	a := make([]interface{}, 0, minInt(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (d *MsgpackDecoder) mapValue(n int, depth int) (interface{}, error) {
+	m := make(map[string]interface{}, minInt(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("logger: msgpack map key is a %T, not a string", k)
+		}
+		if m[key], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
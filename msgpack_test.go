@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMsgpackEncoderMatchesJSON(t *testing.T) {
+	payloads := []*Payload{
+		{},
+		{
+			Severity:       "ERROR",
+			EventTime:      "2017-04-26T02:29:33-04:00",
+			Seq:            math.MaxUint64,
+			Caller:         "logger/logger.go:42",
+			Message:        string(bytes.Repeat([]byte("long message "), 30)),
+			ServiceContext: &ServiceContext{Service: "my-app", Version: "1.0"},
+			Context: &Context{
+				Data: Fields{
+					"string":   "value",
+					"bool":     true,
+					"false":    false,
+					"int":      -42,
+					"small":    -3,
+					"int64":    int64(math.MinInt64),
+					"uint64":   uint64(math.MaxUint64),
+					"float":    3.14,
+					"float32":  float32(0.1),
+					"nil":      nil,
+					"strings":  []string{"a", "b"},
+					"list":     []interface{}{1, "two", Fields{"three": 3}},
+					"nested":   map[string]interface{}{"b": 1, "a": Fields{"c": "d"}},
+					"time":     time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC),
+					"struct":   struct{ Name string }{"Mauricio"},
+					"error":    errors.New("boom"),
+					"duration": 1500 * time.Millisecond,
+					"bytes":    []byte("raw"),
+				},
+				ReportLocation: &ReportLocation{FilePath: "/go/src/app/main.go", FunctionName: "main.main", LineNumber: 15},
+				Frames:         []StackFrame{{Function: "main.main", File: "/go/src/app/main.go", Line: 15}},
+			},
+			Stacktrace:     "goroutine 1 [running]:\nmain.main()\n",
+			Fingerprint:    "3f2a9c1e0b7d4e65",
+			Trace:          "projects/my-app/traces/105445aa7843bc8bf206b12000100000",
+			SpanID:         "0000000000000001",
+			TraceSampled:   true,
+			SourceLocation: &SourceLocation{File: "/go/src/app/main.go", Line: 15, Function: "main.main"},
+			InsertID:       "7c1a5ea4",
+			Labels:         map[string]string{"region": "us-east1", "env": "prod"},
+			Operation:      &Operation{ID: "import-42", Producer: "importer", First: true, Last: true},
+			HTTPRequest:    &HTTPRequest{RequestMethod: "POST", Status: 201, ResponseSize: 1 << 40, Latency: 1500 * time.Millisecond},
+			Metric:         &Metric{Name: "latency", Value: 42.5, Unit: "ms", Tags: Fields{"region": "us-east1"}},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	for _, p := range payloads {
+		if err := encode(MsgpackEncoder{}, buf, p); err != nil {
+			t.Fatalf("unexpected error %s", err.Error())
+		}
+	}
+
+	dec := NewMsgpackDecoder(buf)
+	for _, p := range payloads {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("unexpected error %s", err.Error())
+		}
+
+		js, err := JSONEncoder{}.Encode(p)
+		if err != nil {
+			t.Fatalf("unexpected error %s", err.Error())
+		}
+		d := json.NewDecoder(bytes.NewReader(js))
+		d.UseNumber()
+		var expected interface{}
+		d.Decode(&expected)
+
+		// Compare the values through their JSON encoding, the decoded numbers having different types
+		gotJSON, _ := json.Marshal(got)
+		expectedJSON, _ := json.Marshal(expected)
+		if !bytes.Equal(gotJSON, expectedJSON) {
+			t.Errorf("msgpack entry\n%s\ndoes not match the JSON one\n%s", gotJSON, expectedJSON)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end of the stream; got %v", err)
+	}
+}
+
+func TestMsgpackOutput(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithMsgpackOutput())
+	log.Info("first")
+	log.With(Fields{"attempt": 2}).WithOutput(buf).Warn("second")
+
+	dec := NewMsgpackDecoder(buf)
+	first, err := dec.Decode()
+	if err != nil || first["message"] != "first" || first["severity"] != "INFO" {
+		t.Errorf("unexpected entry %v, %v", first, err)
+	}
+	second, err := dec.Decode()
+	if err != nil || second["message"] != "second" || second["context"].(map[string]interface{})["data"].(map[string]interface{})["attempt"] != int64(2) {
+		t.Errorf("unexpected entry %v, %v", second, err)
+	}
+}
+
+func TestMsgpackDecoderErrors(t *testing.T) {
+	tests := [][]byte{
+		{0x81, 0xa1},       // truncated map key
+		{0x92, 0x01},       // truncated array
+		{0x01},             // not a map
+		{0x81, 0x01, 0x01}, // integer key
+		{0xc1},             // never used type
+	}
+	for _, test := range tests {
+		if _, err := NewMsgpackDecoder(bytes.NewReader(test)).Decode(); err == nil || err == io.EOF {
+			t.Errorf("expected an error decoding % x; got %v", test, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// MultiWriter is an io.Writer duplicating the log entries to several writers. Unlike
+// io.MultiWriter, a failing writer does not prevent the others from receiving the entry
+type MultiWriter struct {
+	writers []io.Writer
+}
+
+// NewMultiWriter returns a MultiWriter writing to all the given writers
+func NewMultiWriter(writers ...io.Writer) *MultiWriter {
+	return &MultiWriter{writers: writers}
+}
+
+// AddWriter creates a copy of a Log writing to w in addition to its current output
+func (l *Log) AddWriter(w io.Writer) *Log {
+	writers := []io.Writer{l.writer, w}
+	if mw, ok := l.writer.(*MultiWriter); ok {
+		writers = append(append([]io.Writer{}, mw.writers...), w)
+	}
+
+	return l.WithOutput(NewMultiWriter(writers...))
+}
+
+// Write writes p to every writer, returning the errors of the ones that failed
+func (m *MultiWriter) Write(p []byte) (int, error) {
+	var errs writeErrors
+	for _, w := range m.writers {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errs.err()
+}
+
+// writeLevel writes p to every writer, passing the severity and time along to the writers that use them
+func (m *MultiWriter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	var errs writeErrors
+	for _, w := range m.writers {
+		var err error
+		if lw, ok := w.(levelWriter); ok {
+			_, err = lw.writeLevel(s, t, p)
+		} else {
+			_, err = w.Write(p)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errs.err()
+}
+
+// writeErrors collects the errors of several writers
+type writeErrors []error
+
+func (e writeErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e writeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
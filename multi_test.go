@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerAddWriter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	first := new(bytes.Buffer)
+	second := new(bytes.Buffer)
+	third := new(bytes.Buffer)
+
+	log := New().WithOutput(first).AddWriter(second).AddWriter(third)
+	log.Info("INFO message")
+
+	for i, buf := range []*bytes.Buffer{first, second, third} {
+		if !strings.Contains(buf.String(), `"message":"INFO message"`) {
+			t.Errorf("writer %d output %s does not contain the entry", i, buf.String())
+		}
+	}
+}
+
+func TestMultiWriterFailingWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	w := NewMultiWriter(failingWriter{}, buf)
+	n, err := w.Write([]byte("entry\n"))
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the failing writer error; got %v", err)
+	}
+	if n != 6 {
+		t.Errorf("expected 6 bytes written; got %d", n)
+	}
+
+	// The healthy writer still receives the entry
+	if buf.String() != "entry\n" {
+		t.Errorf("output %q does not match expected string %q", buf.String(), "entry\n")
+	}
+}
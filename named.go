@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Named creates a copy of a Log named after a subsystem, e.g. "db" or "http.server". The names
+// of the loggers derived from a named logger are appended to it with a dot, so log.Named("http")
+// .Named("server") is named "http.server". The name is added to the context data as "logger" and
+// selects the level set for it with SetNamedLevels
+func (l *Log) Named(name string) *Log {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	n := l.With(Fields{"logger": name})
+	n.name = name
+	return n
+}
+
+// namedLevels holds the levels of the named loggers, a map[string]severity replaced as a whole
+// on every change so it can be read without locking
+var (
+	namedLevels   atomic.Value
+	namedLevelsMu sync.Mutex
+)
+
+// SetNamedLevels sets the levels of the named loggers from a list of name=level pairs, e.g.
+// "db=DEBUG,http=WARN", replacing the ones set before. A level applies to the loggers with
+// that name and to their descendants, "http" covering "http.server", unless a descendant has a
+// level of its own. The other loggers use the global level
+func SetNamedLevels(spec string) error {
+	levels := make(map[string]severity)
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		name, level := splitOnce(pair, "=")
+		if strings.TrimSpace(name) == "" || level == "" {
+			return fmt.Errorf("logger: invalid name=level pair %q", pair)
+		}
+		s, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		levels[strings.TrimSpace(name)] = s
+	}
+
+	namedLevelsMu.Lock()
+	defer namedLevelsMu.Unlock()
+	namedLevels.Store(levels)
+	return nil
+}
+
+// SetModuleLevel sets the level of the loggers named after the module and of their descendants,
+// e.g. SetModuleLevel("storage", DEBUG) enables DEBUG for "storage" and "storage.s3" only. It is
+// safe to call it at any time, concurrently with the logging calls
+func SetModuleLevel(module string, s severity) {
+	updateNamedLevels(func(levels map[string]severity) {
+		levels[module] = s
+	})
+}
+
+// ResetModuleLevel removes the level set for the module, whose loggers use the level of their
+// closest ancestor module again, or the global level
+func ResetModuleLevel(module string) {
+	updateNamedLevels(func(levels map[string]severity) {
+		delete(levels, module)
+	})
+}
+
+// ModuleLevel returns the level of the loggers named after the module: the one set for it or for
+// its closest ancestor, or the global level
+func ModuleLevel(module string) severity {
+	if s, ok := namedLevel(module); ok {
+		return s
+	}
+	return GetLevel()
+}
+
+// updateNamedLevels applies the change to a copy of the levels of the named loggers, then
+// replaces them with it
+func updateNamedLevels(change func(levels map[string]severity)) {
+	namedLevelsMu.Lock()
+	defer namedLevelsMu.Unlock()
+
+	current, _ := namedLevels.Load().(map[string]severity)
+	levels := make(map[string]severity, len(current)+1)
+	for k, v := range current {
+		levels[k] = v
+	}
+	change(levels)
+	namedLevels.Store(levels)
+}
+
+// namedLevel returns the level set for the name or its closest ancestor
+func namedLevel(name string) (severity, bool) {
+	levels, _ := namedLevels.Load().(map[string]severity)
+	if len(levels) == 0 {
+		return 0, false
+	}
+
+	for {
+		if s, ok := levels[name]; ok {
+			return s, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
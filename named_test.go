@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New().WithOutput(buf).Named("http").Named("server").Info("INFO message")
+	if !strings.Contains(buf.String(), `"context":{"data":{"logger":"http.server"}}`) {
+		t.Errorf("output %s does not contain the logger name", buf.String())
+	}
+}
+
+func TestSetNamedLevels(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	if err := SetNamedLevels("db=DEBUG, http=WARN,http.server.tls=debug"); err != nil {
+		t.Fatalf("cannot set the levels: %s", err.Error())
+	}
+	defer SetNamedLevels("")
+
+	buf := new(bytes.Buffer)
+	log := New().WithOutput(buf)
+
+	log.Named("db").Debug("db")
+	log.Named("http").Named("server").Info("http.server")
+	log.Named("http").Named("server").Named("tls").Debug("http.server.tls")
+	log.Named("cache").Debug("cache")
+	log.Named("cache").Info("cache")
+	log.Named("database").Debug("database")
+
+	for _, name := range []string{`"message":"db"`, `"message":"http.server.tls"`, `"message":"cache"`} {
+		if !strings.Contains(buf.String(), name) {
+			t.Errorf("output %s does not contain %s", buf.String(), name)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("expected 3 entries; got %d: %s", n, buf.String())
+	}
+}
+
+func TestSetNamedLevelsInvalid(t *testing.T) {
+	for _, spec := range []string{"db", "=DEBUG", "db=verbose"} {
+		if err := SetNamedLevels(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestSetModuleLevel(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+	defer SetNamedLevels("")
+
+	buf := new(bytes.Buffer)
+	storage := New().WithOutput(buf).Named("storage")
+
+	storage.Named("s3").Debug("DEBUG message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+
+	SetModuleLevel("storage", DEBUG)
+	storage.Named("s3").Debug("DEBUG message")
+	if !strings.Contains(buf.String(), `"logger":"storage.s3"`) {
+		t.Errorf("output %s does not contain the DEBUG entry", buf.String())
+	}
+	if ModuleLevel("storage.s3") != DEBUG || ModuleLevel("http") != INFO {
+		t.Errorf("unexpected module levels %s and %s", ModuleLevel("storage.s3"), ModuleLevel("http"))
+	}
+
+	ResetModuleLevel("storage")
+	buf.Reset()
+	storage.Debug("DEBUG message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+}
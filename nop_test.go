@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNop(t *testing.T) {
+	initConfig(TRACE, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	fired := false
+	log := Nop().WithOutput(buf)
+	log.AddHook(HookFunc(func(p *Payload) error {
+		fired = true
+		return nil
+	}))
+
+	log.Trace("TRACE message")
+	log.Info("INFO message")
+	log.With(Fields{"key": "value"}).WithOutput(buf).Warnf("WARN %s", "message")
+	log.Named("db").Error("ERROR message")
+	log.RecoverAndLog()
+	func() {
+		defer log.RecoverAndLog()
+		panic("boom")
+	}()
+	log.StdLogger(ERROR).Print("std message")
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no output; got %s", buf.String())
+	}
+	if fired {
+		t.Errorf("expected the hooks not to fire")
+	}
+}
+
+func TestNopFatalExits(t *testing.T) {
+	code := 0
+	SetExitFunc(func(c int) { code = c })
+	defer SetExitFunc(nil)
+
+	Nop().Fatal("CRITICAL message")
+	if code != 1 {
+		t.Errorf("expected exit code 1; got %d", code)
+	}
+}
+
+func TestNopDoesNotAllocate(t *testing.T) {
+	log := Nop().With(Fields{"key": "value"})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Info("INFO message")
+		log.Error("ERROR message")
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations; got %v", allocs)
+	}
+}
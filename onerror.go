@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+var (
+	// errorFunc holds the function called with the internal errors, see OnError
+	errorFunc atomic.Value
+	// droppedEntries counts the entries lost to an encoding or writer error, only accessed atomically
+	droppedEntries uint64
+)
+
+// OnError sets the function called with the errors the loggers cannot return to their callers,
+// such as a payload that cannot be encoded, a writer or a hook failing. The errors are printed to
+// stderr by default, a nil function restores that behavior. The function may be called
+// concurrently, and may log, e.g. to another output, since it is not called while the logger
+// writes an entry, except for the TimeoutWriter and spool errors reported by the writers themselves
+func OnError(fn func(err error)) {
+	if fn == nil {
+		fn = printError
+	}
+	errorFunc.Store(fn)
+}
+
+// DroppedEntries returns the number of entries that were not written because they could not be
+// encoded or the writer returned an error, so that a broken logging pipeline can be detected
+func DroppedEntries() uint64 {
+	return atomic.LoadUint64(&droppedEntries)
+}
+
+// reportError passes err to the function set with OnError
+func reportError(err error) {
+	fn, _ := errorFunc.Load().(func(error))
+	if fn == nil {
+		fn = printError
+	}
+	fn(err)
+}
+
+// dropEntry counts an entry lost to err and reports the error
+func dropEntry(err error) {
+	dropEntries(1, err)
+}
+
+// dropEntries counts n entries lost to err and reports the error once
+func dropEntries(n int, err error) {
+	atomic.AddUint64(&droppedEntries, uint64(n))
+	reportError(err)
+}
+
+func printError(err error) {
+	fmt.Fprintf(os.Stderr, "logger ERROR: %s\n", err.Error())
+}
@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// errorRecorder collects the errors passed to the OnError function
+type errorRecorder struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *errorRecorder) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err.Error())
+}
+
+func (r *errorRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.errors...)
+}
+
+func TestOnErrorWriterFailure(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	rec := &errorRecorder{}
+	OnError(rec.record)
+	defer OnError(nil)
+
+	dropped := DroppedEntries()
+	log := New().WithOutput(failingWriter{})
+	log.Info("INFO message")
+	log.Info("INFO message")
+
+	if got := DroppedEntries() - dropped; got != 2 {
+		t.Errorf("expected 2 dropped entries; got %d", got)
+	}
+	if errs := rec.get(); len(errs) != 2 || errs[0] != "disk full" {
+		t.Errorf("expected the writer errors; got %v", errs)
+	}
+}
+
+func TestOnErrorEncodingFailure(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	rec := &errorRecorder{}
+	OnError(rec.record)
+	defer OnError(nil)
+
+	dropped := DroppedEntries()
+	buf := new(bytes.Buffer)
+	log := New().With(Fields{"ch": make(chan int)}).WithOutput(buf)
+	log.Info("INFO message")
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no output; got %s", buf.String())
+	}
+	if got := DroppedEntries() - dropped; got != 1 {
+		t.Errorf("expected 1 dropped entry; got %d", got)
+	}
+	if errs := rec.get(); len(errs) != 1 || !strings.HasPrefix(errs[0], "logger: cannot marshal payload") {
+		t.Errorf("expected the encoding error; got %v", errs)
+	}
+}
+
+func TestOnErrorHookFailure(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	rec := &errorRecorder{}
+	OnError(rec.record)
+	defer OnError(nil)
+
+	dropped := DroppedEntries()
+	log := New().WithOutput(new(bytes.Buffer))
+	log.AddHook(HookFunc(func(p *Payload) error {
+		return errors.New("hook failure")
+	}))
+	log.Warn("WARN message")
+
+	// A failing hook does not drop the entry
+	if got := DroppedEntries() - dropped; got != 0 {
+		t.Errorf("expected no dropped entry; got %d", got)
+	}
+	if errs := rec.get(); len(errs) != 1 || errs[0] != "logger: hook failed: hook failure" {
+		t.Errorf("expected the hook error; got %v", errs)
+	}
+}
+
+func TestOnErrorAsyncWriter(t *testing.T) {
+	rec := &errorRecorder{}
+	OnError(rec.record)
+	defer OnError(nil)
+
+	dropped := DroppedEntries()
+	async := NewAsyncWriter(failingWriter{}, 10)
+	defer async.Close()
+
+	async.Write([]byte("entry\n"))
+	async.Flush()
+
+	if got := DroppedEntries() - dropped; got != 1 {
+		t.Errorf("expected 1 dropped entry; got %d", got)
+	}
+	if errs := rec.get(); len(errs) != 1 || errs[0] != "disk full" {
+		t.Errorf("expected the writer error; got %v", errs)
+	}
+}
+
+func TestEmitReturnsErrors(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	OnError(func(error) {})
+	defer OnError(nil)
+
+	log := New().WithOutput(failingWriter{})
+	for _, lvl := range []severity{INFO, ERROR, CRITICAL} {
+		if err := log.Emit(lvl, "message"); err == nil || err.Error() != "disk full" {
+			t.Errorf("%s: expected the writer error; got %v", lvl, err)
+		}
+	}
+	if err := log.Emit(DEBUG, "message"); err != nil {
+		t.Errorf("expected no error for a discarded entry; got %s", err.Error())
+	}
+	if err := log.Emitf(WARN, "message %d", 1); err == nil {
+		t.Errorf("expected the writer error")
+	}
+
+	buf := new(bytes.Buffer)
+	log = New().WithOutput(buf)
+	if err := log.Emit(ERROR, "ERROR message"); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+	if !strings.Contains(buf.String(), `"severity":"ERROR"`) || !strings.Contains(buf.String(), `"functionName":"logger.TestEmitReturnsErrors"`) {
+		t.Errorf("unexpected output %s", buf.String())
+	}
+
+	log = New().With(Fields{"ch": make(chan int)}).WithOutput(buf)
+	if err := log.Emit(INFO, "INFO message"); err == nil || !strings.HasPrefix(err.Error(), "logger: cannot marshal payload") {
+		t.Errorf("expected the encoding error; got %v", err)
+	}
+}
+
+func TestOnErrorHandlerLogs(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(&failingWriter{}))
+	reported := log.WithOutput(buf)
+	OnError(func(err error) {
+		reported.Warn("logging failed: " + err.Error())
+	})
+	defer OnError(nil)
+
+	done := make(chan struct{})
+	go func() {
+		log.Info("INFO message")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the OnError function logging deadlocked")
+	}
+
+	if !strings.Contains(buf.String(), `"message":"logging failed: `) {
+		t.Errorf("output %s does not contain the reported error", buf.String())
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+// Operation groups the entries of a long-running operation in the Logs Explorer, as the Cloud
+// Logging "logging.googleapis.com/operation"
+type Operation struct {
+	// ID identifies the operation within the producer
+	ID string `json:"id,omitempty"`
+	// Producer identifies the application producing the operation, the service of the logger by default
+	Producer string `json:"producer,omitempty"`
+	// First marks the first entry of the operation
+	First bool `json:"first,omitempty"`
+	// Last marks the last entry of the operation
+	Last bool `json:"last,omitempty"`
+}
+
+// WithOperation creates a copy of a Log whose entries belong to the operation
+func (l *Log) WithOperation(op Operation) *Log {
+	if op.Producer == "" {
+		op.Producer = l.serviceContext().Service
+	}
+
+	n := l.With(Fields{})
+	n.payload.Operation = &op
+	n.opFirst = nil
+	return n
+}
+
+// StartOperation creates a copy of a Log whose entries belong to the operation, the first one
+// being marked as the first of the operation:
+//
+//	op := log.StartOperation("import-42")
+//	op.Info("import started")
+//	...
+//	op.EndOperation("import-42").Info("import done")
+func (l *Log) StartOperation(id string) *Log {
+	n := l.WithOperation(Operation{ID: id})
+	n.opFirst = new(int32)
+	*n.opFirst = 1
+	return n
+}
+
+// EndOperation creates a copy of a Log whose entries are marked as the last of the operation
+func (l *Log) EndOperation(id string) *Log {
+	return l.WithOperation(Operation{ID: id, Last: true})
+}
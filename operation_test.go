@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOperation(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	op := New().WithOutput(buf).StartOperation("import-42")
+	op.Debug("DEBUG message")
+	op.With(Fields{"key": "value"}).WithOutput(buf).Info("INFO message")
+	op.EndOperation("import-42").Warn("WARN message")
+
+	expected := []Operation{
+		{ID: "import-42", Producer: "my-app", First: true},
+		{ID: "import-42", Producer: "my-app"},
+		{ID: "import-42", Producer: "my-app", Last: true},
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d entries; got %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		p := Payload{}
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatalf("entry %s cannot be unmarshalled: %s", line, err.Error())
+		}
+		if p.Operation == nil || *p.Operation != expected[i] {
+			t.Errorf("entry %s does not have the operation %+v", line, expected[i])
+		}
+	}
+}
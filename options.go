@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"io"
+)
+
+// WithService sets the service of the serviceContext, the SERVICE environment variable by default
+func WithService(name string) Option {
+	return func(l *Log) {
+		sc := l.serviceContext()
+		sc.Service = name
+		l.setServiceContext(sc)
+	}
+}
+
+// WithVersion sets the version of the serviceContext, the VERSION environment variable by default
+func WithVersion(version string) Option {
+	return func(l *Log) {
+		sc := l.serviceContext()
+		sc.Version = version
+		l.setServiceContext(sc)
+	}
+}
+
+// WithLevel sets the level of the logger, regardless of the global one
+func WithLevel(s severity) Option {
+	return func(l *Log) {
+		l.level = &s
+	}
+}
+
+// WithWriter sets the output of the logger, os.Stdout by default
+func WithWriter(w io.Writer) Option {
+	return func(l *Log) {
+		l.writer = w
+	}
+}
+
+// serviceContext returns a copy of the serviceContext of the logger
+func (l *Log) serviceContext() ServiceContext {
+	if l.payload.ServiceContext == nil {
+		return ServiceContext{}
+	}
+	return *l.payload.ServiceContext
+}
+
+// setServiceContext replaces the serviceContext of the logger, removing it when it is empty
+func (l *Log) setServiceContext(sc ServiceContext) {
+	p := *l.payload
+	p.ServiceContext = nil
+	if sc != (ServiceContext{}) {
+		p.ServiceContext = &sc
+	}
+	l.payload = &p
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	apiBuf, workerBuf := new(bytes.Buffer), new(bytes.Buffer)
+	api := New(WithService("api"), WithVersion("2.0"), WithWriter(apiBuf), WithClock(testClock))
+	worker := New(WithService("worker"), WithLevel(DEBUG), WithWriter(workerBuf), WithLogfmtOutput())
+
+	api.Debug("DEBUG message")
+	api.Info("INFO message")
+	worker.Debug("DEBUG message")
+
+	expected := `{"severity":"INFO","eventTime":"2017-04-26T02:29:33.412587367-04:00","message":"INFO message","serviceContext":{"service":"api","version":"2.0"}}` + "\n"
+	if apiBuf.String() != expected {
+		t.Errorf("output %s does not match expected string %s", apiBuf.String(), expected)
+	}
+	if !strings.Contains(workerBuf.String(), `level=DEBUG msg="DEBUG message"`) {
+		t.Errorf("output %s does not contain the DEBUG entry", workerBuf.String())
+	}
+	if sc := worker.serviceContext(); sc.Service != "worker" || sc.Version != "1.0" {
+		t.Errorf("unexpected service context %+v", sc)
+	}
+}
+
+func TestOptionsWithoutEnvironment(t *testing.T) {
+	initConfig(DEBUG, "", "")
+	defer initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithWriter(buf), WithVersion("1.0")).Info("INFO message")
+	if !strings.Contains(buf.String(), `"serviceContext":{"version":"1.0"}`) {
+		t.Errorf("output %s does not contain the version", buf.String())
+	}
+
+	buf.Reset()
+	New(WithWriter(buf)).Info("INFO message")
+	if strings.Contains(buf.String(), "serviceContext") {
+		t.Errorf("output %s contains a serviceContext", buf.String())
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// WithProcessFields adds the hostname and the pid of the process to the context data of every
+// entry, and the ID of the goroutine logging the entry when goroutineID is true. Getting the
+// goroutine ID is slow, enable it to debug concurrency issues only
+func WithProcessFields(goroutineID bool) Option {
+	return func(l *Log) {
+		f := l.fields()
+		if hostname, err := os.Hostname(); err == nil {
+			f["hostname"] = hostname
+		}
+		f["pid"] = os.Getpid()
+
+		p := *l.payload
+		p.Context = &Context{Data: f}
+		l.payload = &p
+		l.added = nil
+		l.goroutineID = goroutineID
+	}
+}
+
+// addGoroutineID adds the ID of the current goroutine to the context data of the payload
+func addGoroutineID(p *Payload) {
+	c := Context{}
+	if p.Context != nil {
+		c = *p.Context
+	}
+
+	// The data is shared with the Log, copy it
+	data := make(Fields, len(c.Data)+1)
+	for k, v := range c.Data {
+		data[k] = v
+	}
+	data["goroutine"] = currentGoroutineID()
+	c.Data = data
+	p.Context = &c
+}
+
+// currentGoroutineID parses the goroutine ID out of the header of runtime.Stack, "goroutine 18 [running]:"
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestWithProcessFields(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithProcessFields(true)).With(Fields{"key": "value"}).WithOutput(buf)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Info("INFO message")
+	}()
+	<-done
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	hostname, _ := os.Hostname()
+	data := p.Context.Data
+	if data["hostname"] != hostname || data["pid"] != float64(os.Getpid()) || data["key"] != "value" {
+		t.Errorf("unexpected context data %v", data)
+	}
+	if id, ok := data["goroutine"].(float64); !ok || id == 0 || uint64(id) == currentGoroutineID() {
+		t.Errorf("unexpected goroutine ID %v", data["goroutine"])
+	}
+}
+
+func TestWithProcessFieldsWithoutGoroutineID(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithProcessFields(false)).WithOutput(buf).Info("INFO message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if _, ok := p.Context.Data["goroutine"]; ok {
+		t.Errorf("unexpected goroutine ID in %s", buf.String())
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+// +build race
+
+package logger
+
+// raceEnabled skips the allocation checks, the race detector making sync.Pool drop items at random
+const raceEnabled = true
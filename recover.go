@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// RecoverAndLog recovers from a panic and logs it with CRITICAL severity level, with the stack and
+// the location of the panic, then calls the callbacks with the panic value. It must be deferred
+// directly, typically at the start of a goroutine:
+//
+//	go func() {
+//		defer log.RecoverAndLog()
+//		...
+//	}()
+func (l Log) RecoverAndLog(callbacks ...func(r interface{})) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	l.logPanic(r)
+	for _, fn := range callbacks {
+		fn(r)
+	}
+}
+
+// RecoverAndRepanic is equivalent to RecoverAndLog() followed by a call to panic() with the
+// recovered value, so the panic is logged and still crashes the program
+func (l Log) RecoverAndRepanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	l.logPanic(r)
+	panic(r)
+}
+
+// logPanic logs a recovered panic, from within the deferred call
+func (l Log) logPanic(r interface{}) {
+	if err, ok := r.(error); ok {
+		l = *l.WithError(err)
+	}
+
+	pcs := panicStack()
+	if l.errStack == nil {
+		l.errStack = pcs
+	}
+
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	l.report(CRITICAL.String(), fmt.Sprintf("panic: %v", r), frame.PC, frame.File, frame.Line)
+}
+
+// panicStack returns the stack of a panicking goroutine, starting at the function that panicked.
+// It must be called from within the deferred call
+func panicStack() []uintptr {
+	pcs := callers()
+	for i, pc := range pcs {
+		if fn := runtime.FuncForPC(pc - 1); fn == nil || fn.Name() != "runtime.gopanic" {
+			continue
+		}
+
+		// Skip the runtime functions raising the panic, e.g. on a nil pointer dereference
+		for i++; i < len(pcs)-1; i++ {
+			if fn := runtime.FuncForPC(pcs[i] - 1); fn == nil || !strings.HasPrefix(fn.Name(), "runtime.") {
+				break
+			}
+		}
+		return pcs[i:]
+	}
+	return pcs
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func panicking(log *Log, callback func(interface{})) {
+	defer log.RecoverAndLog(callback)
+	var m map[string]int
+	m["key"]++
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	var recovered interface{}
+	panicking(New().WithOutput(buf), func(r interface{}) {
+		recovered = r
+	})
+
+	if _, ok := recovered.(runtime.Error); !ok {
+		t.Errorf("callback received %v instead of the runtime error", recovered)
+	}
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if p.Severity != "CRITICAL" || !strings.HasPrefix(p.Message, "panic: assignment to entry in nil map") {
+		t.Errorf("unexpected payload %s", buf.String())
+	}
+	if loc := p.Context.ReportLocation; loc.FunctionName != "logger.panicking" || !strings.HasSuffix(loc.FilePath, "recover_test.go") {
+		t.Errorf("report location %+v does not point to the panic", loc)
+	}
+	if !strings.HasPrefix(strings.SplitN(p.Stacktrace, "\n", 3)[1], "github.com/teltech/logger.panicking(") {
+		t.Errorf("stacktrace does not start at the panic: %s", p.Stacktrace)
+	}
+}
+
+func TestRecoverAndLogWithoutPanic(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	func() {
+		defer New().WithOutput(buf).RecoverAndLog()
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	err := errors.New("failure")
+	defer func() {
+		if r := recover(); r != err {
+			t.Errorf("expected the panic to go on with %v; got %v", err, r)
+		}
+
+		p := Payload{}
+		if e := json.Unmarshal(buf.Bytes(), &p); e != nil {
+			t.Fatalf("failed to unmarshal payload: %s", e.Error())
+		}
+		if p.Message != "panic: failure" || p.Context.Data["error"] == nil {
+			t.Errorf("unexpected payload %s", buf.String())
+		}
+	}()
+
+	func() {
+		defer New().WithOutput(buf).RecoverAndRepanic()
+		panic(err)
+	}()
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of the sensitive fields
+const Redacted = "[REDACTED]"
+
+// Redactor hides the values of sensitive context fields before the entries are encoded, at any
+// nesting depth. A field is sensitive when its key matches one of Keys, ignoring the case, or
+// one of Patterns
+type Redactor struct {
+	// Keys are the sensitive field names, e.g. "password" or "ssn"
+	Keys []string
+	// Patterns match sensitive field names, e.g. regexp.MustCompile("(?i)token$")
+	Patterns []*regexp.Regexp
+	// Hash replaces the values with their SHA-256 hash instead of Redacted, so identical values
+	// can still be correlated
+	Hash bool
+
+	keys map[string]bool
+}
+
+// WithRedaction hides the values of the context fields with the given names
+func WithRedaction(keys ...string) Option {
+	return WithRedactor(&Redactor{Keys: keys})
+}
+
+// WithRedactor hides the values of the sensitive context fields as configured by r
+func WithRedactor(r *Redactor) Option {
+	r.keys = make(map[string]bool, len(r.Keys))
+	for _, k := range r.Keys {
+		r.keys[strings.ToLower(k)] = true
+	}
+
+	return func(l *Log) {
+		l.redactor = r
+	}
+}
+
+// redact replaces the payload context with a copy where the sensitive values are hidden
+func (r *Redactor) redact(p *Payload) {
+	if p.Context == nil || len(p.Context.Data) == 0 {
+		return
+	}
+
+	c := *p.Context
+	c.Data = r.redactFields(p.Context.Data)
+	p.Context = &c
+}
+
+func (r *Redactor) sensitive(key string) bool {
+	if r.keys[strings.ToLower(key)] {
+		return true
+	}
+	for _, re := range r.Patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) redactFields(f map[string]interface{}) Fields {
+	redacted, _ := r.redactMap(f, 0)
+	return redacted
+}
+
+// redactMap returns a copy of the fields with the sensitive values hidden, and whether any was
+func (r *Redactor) redactMap(f map[string]interface{}, depth int) (Fields, bool) {
+	redacted := make(Fields, len(f))
+	changed := false
+	for k, v := range f {
+		if r.sensitive(k) {
+			redacted[k] = r.hide(v)
+			changed = true
+			continue
+		}
+		rv, ok := r.redactValue(v, depth+1)
+		redacted[k] = rv
+		changed = changed || ok
+	}
+	return redacted, changed
+}
+
+// redactValue looks for sensitive fields in nested values, walking them as the JSON encoder does.
+// The value is returned unchanged, along with false, when it has no sensitive field, so it is
+// still converted and encoded as without redaction
+func (r *Redactor) redactValue(v interface{}, depth int) (interface{}, bool) {
+	if depth > maxJSONDepth {
+		return v, false
+	}
+
+	switch val := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, []string:
+		return v, false
+	case Fields:
+		if f, ok := r.redactMap(val, depth); ok {
+			return f, true
+		}
+		return v, false
+	case map[string]interface{}:
+		if f, ok := r.redactMap(val, depth); ok {
+			return f, true
+		}
+		return v, false
+	}
+
+	// The errors, the Stringers and the types of RegisterFieldEncoder are converted first
+	if conv, ok := convertFieldValue(v); ok && reflect.TypeOf(conv) != reflect.TypeOf(v) {
+		if redacted, ok := r.redactValue(conv, depth); ok {
+			return redacted, true
+		}
+		return v, false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !rv.IsNil() && !hasCustomJSON(rv.Type()) {
+			if redacted, ok := r.redactValue(rv.Elem().Interface(), depth+1); ok {
+				return redacted, true
+			}
+		}
+
+	case reflect.Map:
+		if !rv.IsNil() && !hasCustomJSON(rv.Type()) && !hasCustomJSON(rv.Type().Key()) && rv.Type().Key().Kind() == reflect.String {
+			f := make(map[string]interface{}, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				f[iter.Key().String()] = iter.Value().Interface()
+			}
+			if redacted, ok := r.redactMap(f, depth); ok {
+				return redacted, true
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && (rv.IsNil() || rv.Type().Elem().Kind() == reflect.Uint8) || hasCustomJSON(rv.Type()) {
+			return v, false
+		}
+		redacted := make([]interface{}, rv.Len())
+		changed := false
+		for i := range redacted {
+			e, ok := r.redactValue(rv.Index(i).Interface(), depth+1)
+			redacted[i] = e
+			changed = changed || ok
+		}
+		if changed {
+			return redacted, true
+		}
+
+	case reflect.Struct:
+		if plainJSONStruct(rv.Type()) {
+			f := make(map[string]interface{}, rv.NumField())
+			eachJSONField(rv, func(name string, fv reflect.Value) error {
+				f[name] = fv.Interface()
+				return nil
+			})
+			if redacted, ok := r.redactMap(f, depth); ok {
+				return redacted, true
+			}
+		}
+	}
+	return v, false
+}
+
+// hide returns the replacement of a sensitive value
+func (r *Redactor) hide(v interface{}) interface{} {
+	if !r.Hash {
+		return Redacted
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(b)
+		}
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func TestLoggerWithRedaction(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithRedaction("password", "SSN")).With(Fields{
+		"user":     "+1234567890",
+		"Password": "hunter2",
+		"ssn":      "078-05-1120",
+		"request": Fields{
+			"headers": map[string]interface{}{"password": "hunter2", "accept": "*/*"},
+			"logins":  []interface{}{Fields{"password": "hunter2"}},
+		},
+		"credentials": credentials{User: "mauricio", Password: "hunter2"},
+	}).WithOutput(buf)
+
+	log.Info("INFO message")
+	got := buf.String()
+	if strings.Contains(got, "hunter2") || strings.Contains(got, "078-05-1120") {
+		t.Errorf("output %s contains sensitive values", got)
+	}
+
+	expected := `"data":{"Password":"[REDACTED]","credentials":{"password":"[REDACTED]","user":"mauricio"},"request":{"headers":{"accept":"*/*","password":"[REDACTED]"},"logins":[{"password":"[REDACTED]"}]},"ssn":"[REDACTED]","user":"+1234567890"}`
+	if !strings.Contains(got, expected) {
+		t.Errorf("output %s does not contain %s", got, expected)
+	}
+
+	// The logger context is left untouched
+	if log.fields()["Password"] != "hunter2" {
+		t.Errorf("the redaction modified the logger context")
+	}
+}
+
+func TestRedactionKeepsFieldConversions(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	RegisterFieldEncoder(&user{}, func(v interface{}) interface{} {
+		return v.(*user).ID
+	})
+	defer RegisterFieldEncoder(&user{}, nil)
+
+	type account struct {
+		ID       int64  `json:"id"`
+		Password string `json:"password"`
+	}
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithRedaction("password")).With(Fields{
+		"err":     errors.New("boom"),
+		"user":    &user{ID: 3, Name: "Mauricio"},
+		"account": map[string]account{"main": {ID: 1<<62 + 1, Password: "hunter2"}},
+	})
+	log.Info("INFO message")
+
+	expected := `"data":{"account":{"main":{"id":4611686018427387905,"password":"[REDACTED]"}},"err":"boom","user":"user-3"}`
+	if got := buf.String(); !strings.Contains(got, expected) {
+		t.Errorf("output %s does not contain %s", got, expected)
+	}
+}
+
+func TestLoggerWithRedactorPatternAndHash(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithRedactor(&Redactor{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)token$`)},
+		Hash:     true,
+	})).With(Fields{"accessToken": "secret", "key": "value"}).WithOutput(buf)
+
+	log.Error("ERROR message")
+	expected := `"data":{"accessToken":"sha256:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b","key":"value"}`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+}
+
+func TestRedactionRunsBeforeHooks(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New(WithRedaction("password")).With(Fields{"password": "hunter2"}).WithOutput(new(bytes.Buffer))
+
+	var seen interface{}
+	log.AddHook(HookFunc(func(p *Payload) error {
+		seen = p.Context.Data["password"]
+		return nil
+	}))
+	log.Info("INFO message")
+
+	if seen != Redacted {
+		t.Errorf("hook received the sensitive value %v", seen)
+	}
+}
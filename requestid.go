@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the correlation ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the length above which an incoming request ID is replaced
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware gives every request a correlation ID, shared by all its log entries. The ID
+// is read from the X-Request-ID header, or generated when it is missing or invalid, then echoed in
+// the response header and stored in the request context along with a logger adding it to the
+// context data of the entries as "requestId":
+//
+//	http.Handle("/", logger.RequestIDMiddleware(handler))
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		logger.FromContext(r.Context()).Info("handling the request")
+//	}
+//
+// The logger is derived from the one already in the context, or from the default logger
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = NewInsertID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		l := FromContext(ctx).With(Fields{"requestId": id})
+		next.ServeHTTP(w, r.WithContext(NewContext(ctx, l)))
+	})
+}
+
+// RequestIDFromContext returns the request ID stored by RequestIDMiddleware, empty when there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID checks that an incoming request ID is printable ASCII of a reasonable length,
+// so a client cannot inject arbitrary data in the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	SetDefault(New(WithWriter(buf)))
+	defer SetDefault(nil)
+
+	var ctxID string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = RequestIDFromContext(r.Context())
+		FromContext(r.Context()).Info("handling the request")
+	}))
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"abc-123", "abc-123"},
+		{"", ""},
+		{"with space", ""},
+		{strings.Repeat("a", 129), ""},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/", nil)
+		if test.header != "" {
+			req.Header.Set(RequestIDHeader, test.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(RequestIDHeader)
+		if test.expected != "" && id != test.expected || test.expected == "" && len(id) != 36 {
+			t.Errorf("%q: unexpected response ID %q", test.header, id)
+		}
+		if ctxID != id {
+			t.Errorf("%q: expected the context ID %q; got %q", test.header, id, ctxID)
+		}
+		if !strings.Contains(buf.String(), `"context":{"data":{"requestId":"`+id+`"}}`) {
+			t.Errorf("%q: output %s does not contain the request ID", test.header, buf.String())
+		}
+	}
+}
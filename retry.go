@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when writing to a RetryWriter whose queue is full, with the DropNewest policy
+var ErrQueueFull = errors.New("logger: queue full, entry dropped")
+
+// DropPolicy tells what a RetryWriter does with a new entry when its queue is full
+type DropPolicy int
+
+const (
+	// DropNewest discards the new entry, Write returns ErrQueueFull
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest queued entry to make room for the new one
+	DropOldest
+	// Block makes Write wait until there is room in the queue
+	Block
+)
+
+// RetryConfig configures a RetryWriter, the zero value uses the defaults
+type RetryConfig struct {
+	// QueueSize is the maximum number of queued entries, 1000 by default
+	QueueSize int
+	// Attempts is the number of times an entry is written before it is dropped, 5 by default
+	Attempts int
+	// MinBackoff is the delay before the first retry, 100ms by default. It doubles on each retry
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two retries, 30s by default
+	MaxBackoff time.Duration
+	// Drop is the policy applied when the queue is full
+	Drop DropPolicy
+}
+
+// RetryWriter queues the log entries and writes them to a remote sink such as a SyslogWriter or a
+// CloudLoggingWriter from a background goroutine, retrying the failed writes with an exponential
+// backoff and jitter. The callers never wait for the sink, except with the Block policy when the
+// queue is full. The entries that cannot be written are counted by DroppedEntries.
+// Flush or Close must be called before the program exits to avoid losing entries.
+type RetryWriter struct {
+	w    io.Writer
+	cfg  RetryConfig
+	done chan struct{}
+	stop chan struct{}
+
+	mu sync.Mutex
+	// cond is signaled when the queue changes or an entry has been written
+	cond   *sync.Cond
+	queue  []retryEntry
+	busy   bool
+	closed bool
+	err    error
+}
+
+type retryEntry struct {
+	data []byte
+	// level and time are set when the entry was written with its severity
+	level   severity
+	time    time.Time
+	leveled bool
+}
+
+// NewRetryWriter returns a RetryWriter writing to w
+func NewRetryWriter(w io.Writer, cfg RetryConfig) *RetryWriter {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 5
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+
+	r := &RetryWriter{
+		w:    w,
+		cfg:  cfg,
+		done: make(chan struct{}),
+		stop: make(chan struct{}),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	go r.run()
+	return r
+}
+
+// WithRetry writes the log entries through a RetryWriter wrapping the current output, so it must
+// come after the option setting the output, e.g. New(WithSyslog("tcp", addr, "app"), WithRetry(RetryConfig{}))
+func WithRetry(cfg RetryConfig) Option {
+	return func(l *Log) {
+		l.writer = NewRetryWriter(l.writer, cfg)
+	}
+}
+
+// Write queues a copy of p to be written by the background goroutine
+func (r *RetryWriter) Write(p []byte) (int, error) {
+	return r.enqueue(retryEntry{data: p})
+}
+
+// writeLevel queues a copy of p, passing the severity and time along to the writer when it uses them
+func (r *RetryWriter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	return r.enqueue(retryEntry{data: p, level: s, time: t, leveled: true})
+}
+
+func (r *RetryWriter) enqueue(e retryEntry) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(r.queue) >= r.cfg.QueueSize && !r.closed {
+		switch r.cfg.Drop {
+		case DropOldest:
+			// The caller of the oldest entry already returned, count it as dropped
+			r.queue = r.queue[1:]
+			dropEntry(ErrQueueFull)
+		case Block:
+			r.cond.Wait()
+		default:
+			return 0, ErrQueueFull
+		}
+	}
+	if r.closed {
+		return 0, ErrClosed
+	}
+
+	data := make([]byte, len(e.data))
+	copy(data, e.data)
+	e.data = data
+	r.queue = append(r.queue, e)
+	r.cond.Broadcast()
+	return len(data), nil
+}
+
+func (r *RetryWriter) run() {
+	defer close(r.done)
+
+	for {
+		r.mu.Lock()
+		for len(r.queue) == 0 && !r.closed {
+			r.cond.Wait()
+		}
+		if len(r.queue) == 0 {
+			r.mu.Unlock()
+			return
+		}
+		e := r.queue[0]
+		r.queue = r.queue[1:]
+		r.busy = true
+		r.mu.Unlock()
+
+		err := r.send(e)
+
+		r.mu.Lock()
+		r.busy = false
+		if err != nil && r.err == nil {
+			r.err = err
+		}
+		r.cond.Broadcast()
+		r.mu.Unlock()
+	}
+}
+
+// send writes an entry, retrying until it succeeds, the attempts are exhausted or the writer is closed
+func (r *RetryWriter) send(e retryEntry) error {
+	var err error
+	for attempt := 0; attempt < r.cfg.Attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(r.backoff(attempt)):
+			case <-r.stop:
+				// Closing, do not wait for the sink to come back
+				attempt = r.cfg.Attempts
+				continue
+			}
+		}
+
+		if lw, ok := r.w.(levelWriter); ok && e.leveled {
+			_, err = lw.writeLevel(e.level, e.time, e.data)
+		} else {
+			_, err = r.w.Write(e.data)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+
+	dropEntry(err)
+	return err
+}
+
+// backoff returns the delay before a retry, doubling on each attempt with a random jitter of up to half of it
+func (r *RetryWriter) backoff(attempt int) time.Duration {
+	d := r.cfg.MinBackoff
+	for i := 1; i < attempt && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.cfg.MaxBackoff {
+		d = r.cfg.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Flush blocks until all the queued entries have been written or dropped. It returns the first
+// error returned by the underlying writer since the previous Flush
+func (r *RetryWriter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(r.queue) > 0 || r.busy {
+		r.cond.Wait()
+	}
+	err := r.err
+	r.err = nil
+	return err
+}
+
+// Close stops retrying, writes the queued entries once and stops the background goroutine.
+// The underlying writer is not closed
+func (r *RetryWriter) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.stop)
+	r.cond.Broadcast()
+	r.mu.Unlock()
+
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.err
+	r.err = nil
+	return err
+}
@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyWriter fails the given number of writes before succeeding
+type flakyWriter struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	buf      bytes.Buffer
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.calls++
+	if w.failures > 0 {
+		w.failures--
+		return 0, errors.New("connection reset")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *flakyWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestRetryWriterRetries(t *testing.T) {
+	w := &flakyWriter{failures: 2}
+	r := NewRetryWriter(w, RetryConfig{MinBackoff: time.Millisecond})
+	defer r.Close()
+
+	r.Write([]byte("first\n"))
+	r.Write([]byte("second\n"))
+	if err := r.Flush(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if w.String() != "first\nsecond\n" {
+		t.Errorf("unexpected output %q", w.String())
+	}
+	if w.calls != 4 {
+		t.Errorf("expected 4 writes; got %d", w.calls)
+	}
+}
+
+func TestRetryWriterGivesUp(t *testing.T) {
+	OnError(func(error) {})
+	defer OnError(nil)
+
+	dropped := DroppedEntries()
+	w := &flakyWriter{failures: 10}
+	r := NewRetryWriter(w, RetryConfig{Attempts: 3, MinBackoff: time.Millisecond})
+	defer r.Close()
+
+	r.Write([]byte("entry\n"))
+	if err := r.Flush(); err == nil || err.Error() != "connection reset" {
+		t.Errorf("expected the writer error; got %v", err)
+	}
+	if w.calls != 3 {
+		t.Errorf("expected 3 writes; got %d", w.calls)
+	}
+	if got := DroppedEntries() - dropped; got != 1 {
+		t.Errorf("expected 1 dropped entry; got %d", got)
+	}
+}
+
+// blockingWriter blocks the writes until release is closed
+type blockingWriter struct {
+	flakyWriter
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.flakyWriter.Write(p)
+}
+
+func TestRetryWriterDropPolicies(t *testing.T) {
+	OnError(func(error) {})
+	defer OnError(nil)
+
+	tests := []struct {
+		policy   DropPolicy
+		err      error
+		expected string
+	}{
+		{DropNewest, ErrQueueFull, "first\nsecond\n"},
+		{DropOldest, nil, "first\nthird\n"},
+	}
+
+	for _, test := range tests {
+		w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+		r := NewRetryWriter(w, RetryConfig{QueueSize: 1, Drop: test.policy})
+
+		// The first entry is being written, the second one fills the queue
+		r.Write([]byte("first\n"))
+		<-w.started
+		r.Write([]byte("second\n"))
+		if _, err := r.Write([]byte("third\n")); err != test.err {
+			t.Errorf("policy %d: expected error %v; got %v", test.policy, test.err, err)
+		}
+
+		close(w.release)
+		r.Close()
+		if w.String() != test.expected {
+			t.Errorf("policy %d: output %q does not match expected string %q", test.policy, w.String(), test.expected)
+		}
+	}
+}
+
+func TestRetryWriterBlock(t *testing.T) {
+	w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	r := NewRetryWriter(w, RetryConfig{QueueSize: 1, Drop: Block})
+
+	r.Write([]byte("first\n"))
+	<-w.started
+	r.Write([]byte("second\n"))
+
+	written := make(chan struct{})
+	go func() {
+		r.Write([]byte("third\n"))
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		t.Errorf("expected Write to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(w.release)
+	<-written
+	r.Close()
+	if w.String() != "first\nsecond\nthird\n" {
+		t.Errorf("unexpected output %q", w.String())
+	}
+}
+
+func TestRetryWriterCloseStopsRetrying(t *testing.T) {
+	OnError(func(error) {})
+	defer OnError(nil)
+
+	w := &flakyWriter{failures: 10}
+	r := NewRetryWriter(w, RetryConfig{MinBackoff: time.Hour})
+	r.Write([]byte("entry\n"))
+
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close waited for the backoff")
+	}
+	if _, err := r.Write([]byte("entry\n")); err != ErrClosed {
+		t.Errorf("expected ErrClosed; got %v", err)
+	}
+}
+
+func TestRetryWriterBackoff(t *testing.T) {
+	r := &RetryWriter{cfg: RetryConfig{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+
+	for _, test := range tests {
+		d := r.backoff(test.attempt)
+		if d < test.max/2 || d > test.max {
+			t.Errorf("attempt %d: expected a backoff between %s and %s; got %s", test.attempt, test.max/2, test.max, d)
+		}
+	}
+}
+
+func TestLoggerWithRetry(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	w := &flakyWriter{failures: 1}
+	log := New(WithWriter(w), WithRetry(RetryConfig{MinBackoff: time.Millisecond}))
+	log.Info("INFO message")
+	log.Sync()
+
+	if !strings.Contains(w.String(), `"message":"INFO message"`) {
+		t.Errorf("output %s does not contain the entry", w.String())
+	}
+}
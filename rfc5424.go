@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// defaultSDID is the ID of the structured data element of the context data, under the example
+// private enterprise number of RFC 5424
+const defaultSDID = "data@32473"
+
+// rfc5424Time is the timestamp format of RFC 5424, which allows up to microseconds
+const rfc5424Time = "2006-01-02T15:04:05.999999Z07:00"
+
+// RFC5424Encoder encodes a payload as a RFC 5424 syslog message keeping its structure: the
+// service is the APP-NAME, the service and version are the "origin" structured data element and
+// the context data are the parameters of the SDID element, e.g.
+//
+//	<14>1 2017-04-26T02:29:33.412587-04:00 host billing 42 - [origin software="billing" swVersion="2.1"][data@32473 user="+1234567890"] message
+//
+// The composite values are JSON encoded. See WithStructuredSyslog to send the messages to a
+// syslog server
+type RFC5424Encoder struct {
+	// Facility is the syslog facility of the messages, FacilityUser by default
+	Facility int
+	// Hostname is the HOSTNAME of the messages, os.Hostname() by default
+	Hostname string
+	// AppName is the APP-NAME of the messages without a service context, the executable name by default
+	AppName string
+	// SDID is the ID of the element of the context data, "data@32473" by default
+	SDID string
+}
+
+// WithStructuredSyslog sends the log entries to a syslog server as RFC 5424 messages with
+// structured data, see RFC5424Encoder. The arguments are the ones of SyslogWriter
+func WithStructuredSyslog(network, addr string) Option {
+	return func(l *Log) {
+		l.encoder = RFC5424Encoder{}
+		l.writer = &SyslogWriter{
+			Network:      network,
+			Addr:         addr,
+			Preformatted: true,
+		}
+	}
+}
+
+// Encode formats the payload as a RFC 5424 message
+func (e RFC5424Encoder) Encode(p *Payload) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := e.encodeTo(buf, p)
+	return buf.Bytes(), err
+}
+
+func (e RFC5424Encoder) encodeTo(buf *bytes.Buffer, p *Payload) error {
+	lvl, ok := logLevelValue[p.Severity]
+	if !ok {
+		lvl = INFO
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, p.EventTime)
+	if err != nil {
+		// The time format was changed with WithTimeFormat
+		t = time.Now()
+	}
+
+	hostname := e.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	appName := e.AppName
+	if sc := p.ServiceContext; sc != nil && sc.Service != "" {
+		appName = sc.Service
+	}
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	buf.WriteByte('<')
+	buf.WriteString(strconv.Itoa(syslogFacility(e.Facility)*8 + syslogSeverity[lvl]))
+	buf.WriteString(">1 ")
+	buf.WriteString(t.Format(rfc5424Time))
+	buf.WriteByte(' ')
+	writeSyslogHeaderField(buf, hostname, 255)
+	buf.WriteByte(' ')
+	writeSyslogHeaderField(buf, appName, 48)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(os.Getpid()))
+	buf.WriteString(" - ")
+
+	n := buf.Len()
+	if sc := p.ServiceContext; sc != nil && sc.Service != "" {
+		buf.WriteString(`[origin software=`)
+		writeSDValue(buf, sc.Service)
+		if sc.Version != "" {
+			buf.WriteString(` swVersion=`)
+			writeSDValue(buf, sc.Version)
+		}
+		buf.WriteByte(']')
+	}
+	if c := p.Context; c != nil && len(c.Data) > 0 {
+		sdID := e.SDID
+		if sdID == "" {
+			sdID = defaultSDID
+		}
+		buf.WriteByte('[')
+		writeSDName(buf, sdID, 32)
+		for _, k := range sortedKeys(c.Data) {
+			s, _ := valueString(c.Data[k])
+			buf.WriteByte(' ')
+			writeSDName(buf, k, 32)
+			buf.WriteByte('=')
+			writeSDValue(buf, s)
+		}
+		buf.WriteByte(']')
+	}
+	if buf.Len() == n {
+		buf.WriteByte('-')
+	}
+
+	if p.Message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(p.Message)
+	}
+	return nil
+}
+
+// writeSyslogHeaderField writes a header field, made of at most max printable ASCII characters
+// and "-" when empty
+func writeSyslogHeaderField(buf *bytes.Buffer, s string, max int) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(s) && i < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			buf.WriteByte(c)
+		} else {
+			buf.WriteByte('_')
+		}
+	}
+}
+
+// writeSDName writes a SD-ID or PARAM-NAME, replacing the characters it cannot contain
+func writeSDName(buf *bytes.Buffer, s string, max int) {
+	if s == "" {
+		buf.WriteByte('_')
+		return
+	}
+	for i := 0; i < len(s) && i < max; i++ {
+		switch c := s[i]; {
+		case c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"':
+			buf.WriteByte('_')
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+// writeSDValue writes a quoted PARAM-VALUE, escaping the '"', '\' and ']' characters
+func writeSDValue(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRFC5424Encoder(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+
+	log := New(WithEncoder(RFC5424Encoder{Hostname: "my-host", Facility: FacilityLocal0}), WithClock(testClock)).With(Fields{
+		"user":    "+1234567890",
+		"count":   3,
+		"bad key": `quote " and ]`,
+		"names":   []string{"Mauricio", "Manuel"},
+	}).WithOutput(buf)
+
+	log.Warn("WARN message")
+	expected := fmt.Sprintf(`<132>1 %s my-host my-app %d - [origin software="my-app" swVersion="1.0"][data@32473 bad_key="quote \" and \]" count="3" names="[\"Mauricio\",\"Manuel\"\]" user="+1234567890"] WARN message`,
+		testTime.Format(rfc5424Time), os.Getpid())
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestRFC5424EncoderWithoutData(t *testing.T) {
+	initConfig(DEBUG, "", "")
+
+	e := RFC5424Encoder{Hostname: "my host", AppName: "fallback", SDID: "meta@1"}
+	b, err := e.Encode(&Payload{Severity: "ERROR", Message: "ERROR message", EventTime: "invalid"})
+	if err != nil {
+		t.Fatalf("cannot encode the payload: %s", err.Error())
+	}
+
+	got := string(b)
+	prefix := "<11>1 "
+	suffix := fmt.Sprintf(" my_host fallback %d - - ERROR message", os.Getpid())
+	if !strings.HasPrefix(got, prefix) || !strings.HasSuffix(got, suffix) {
+		t.Errorf("output %s does not match %s...%s", got, prefix, suffix)
+	}
+
+	b, _ = e.Encode(&Payload{Severity: "INFO", Context: &Context{Data: Fields{"k": "v"}}})
+	if got := string(b); !strings.HasSuffix(got, ` - [meta@1 k="v"]`) {
+		t.Errorf("output %s does not use the SDID", got)
+	}
+}
+
+func TestWithStructuredSyslog(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err.Error())
+	}
+	defer conn.Close()
+
+	log := New(WithStructuredSyslog("udp", conn.LocalAddr().String())).With(Fields{"user": "+1234567890"})
+	log.Info("INFO message")
+
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("cannot read the message: %s", err.Error())
+	}
+
+	// The message is sent without a second header nor the trailing newline
+	got := string(buf[:n])
+	if !strings.HasPrefix(got, "<14>1 ") || strings.Count(got, ">1 ") != 1 {
+		t.Errorf("message %s does not have a single RFC 5424 header", got)
+	}
+	if !strings.HasSuffix(got, `[data@32473 user="+1234567890"] INFO message`) {
+		t.Errorf("message %q does not end with the structured data and message", got)
+	}
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RingBuffer is an io.Writer retaining the last log entries in memory, so recent activity can be
+// inspected even when it is not shipped to the backend. It is also an http.Handler serving the
+// entries, e.g. on /debug/logs. To keep the DEBUG entries in the buffer only:
+//
+//	ring := logger.NewRingBuffer(1000)
+//	backend := logger.NewLevelRouter(os.Stdout).Route(logger.TRACE, logger.DEBUG, ioutil.Discard)
+//	log := logger.New(logger.WithLevel(logger.DEBUG), logger.WithWriter(logger.NewMultiWriter(ring, backend)))
+//	http.Handle("/debug/logs", ring)
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []ringEntry
+	// next is the index of the slot of the next entry, the oldest one once the buffer is full
+	next int
+	full bool
+}
+
+type ringEntry struct {
+	level severity
+	data  string
+}
+
+// NewRingBuffer returns a RingBuffer retaining the last size entries
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &RingBuffer{entries: make([]ringEntry, size)}
+}
+
+// Write stores p, with the severity read from the JSON entry or INFO when it is not JSON
+func (r *RingBuffer) Write(p []byte) (int, error) {
+	var entry struct {
+		Severity string `json:"severity"`
+	}
+	lvl := INFO
+	if err := json.Unmarshal(p, &entry); err == nil {
+		if s, ok := logLevelValue[entry.Severity]; ok {
+			lvl = s
+		}
+	}
+	return r.writeLevel(lvl, time.Time{}, p)
+}
+
+// writeLevel stores p with its severity, replacing the oldest entry when the buffer is full
+func (r *RingBuffer) writeLevel(s severity, _ time.Time, p []byte) (int, error) {
+	data := string(bytes.TrimRight(p, "\n"))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = ringEntry{level: s, data: data}
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Entries returns the retained entries, oldest first
+func (r *RingBuffer) Entries() []string {
+	return r.Query(TRACE, "", 0)
+}
+
+// Query returns the retained entries with a severity of at least min and containing substr,
+// oldest first. When limit is positive, only the last limit matching entries are returned
+func (r *RingBuffer) Query(min severity, substr string, limit int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []string
+	if r.full {
+		entries = r.filter(entries, r.entries[r.next:], min, substr)
+	}
+	entries = r.filter(entries, r.entries[:r.next], min, substr)
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+func (r *RingBuffer) filter(dst []string, entries []ringEntry, min severity, substr string) []string {
+	for _, e := range entries {
+		if e.level >= min && strings.Contains(e.data, substr) {
+			dst = append(dst, e.data)
+		}
+	}
+	return dst
+}
+
+// ServeHTTP implements http.Handler, writing the retained entries one per line. The "level", "q"
+// and "limit" query parameters are passed to Query:
+//
+//	curl 'localhost:8080/debug/logs?level=WARN&q=timeout&limit=50'
+func (r *RingBuffer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	min := TRACE
+	if name := req.FormValue("level"); name != "" {
+		lvl, err := ParseLevel(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		min = lvl
+	}
+
+	limit := 0
+	if s := req.FormValue("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, e := range r.Query(min, req.FormValue("q"), limit) {
+		w.Write([]byte(e + "\n"))
+	}
+}
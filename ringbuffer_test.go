@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer(t *testing.T) {
+	ring := NewRingBuffer(3)
+	ring.writeLevel(DEBUG, time.Time{}, []byte("first\n"))
+	ring.writeLevel(INFO, time.Time{}, []byte("second\n"))
+
+	if got := ring.Entries(); !reflect.DeepEqual(got, []string{"first", "second"}) {
+		t.Errorf("unexpected entries %q", got)
+	}
+
+	ring.writeLevel(WARN, time.Time{}, []byte("third\n"))
+	ring.writeLevel(ERROR, time.Time{}, []byte("fourth\n"))
+	if got := ring.Entries(); !reflect.DeepEqual(got, []string{"second", "third", "fourth"}) {
+		t.Errorf("unexpected entries %q", got)
+	}
+
+	tests := []struct {
+		min      severity
+		substr   string
+		limit    int
+		expected []string
+	}{
+		{WARN, "", 0, []string{"third", "fourth"}},
+		{TRACE, "th", 0, []string{"third", "fourth"}},
+		{TRACE, "", 1, []string{"fourth"}},
+		{CRITICAL, "", 0, nil},
+	}
+
+	for _, test := range tests {
+		if got := ring.Query(test.min, test.substr, test.limit); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Query(%s, %q, %d): expected %q; got %q", test.min, test.substr, test.limit, test.expected, got)
+		}
+	}
+}
+
+func TestRingBufferSeverityFromJSON(t *testing.T) {
+	ring := NewRingBuffer(10)
+	ring.Write([]byte(`{"severity":"ERROR","message":"failed"}` + "\n"))
+	ring.Write([]byte("plain text\n"))
+
+	if got := ring.Query(ERROR, "", 0); len(got) != 1 || !strings.Contains(got[0], "failed") {
+		t.Errorf("unexpected ERROR entries %q", got)
+	}
+	if got := ring.Query(INFO, "", 0); len(got) != 2 {
+		t.Errorf("expected the text entry with INFO; got %q", got)
+	}
+}
+
+func TestRingBufferHandler(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+
+	ring := NewRingBuffer(10)
+	backend := NewLevelRouter(ioutil.Discard)
+	log := New(WithLevel(DEBUG), WithWriter(NewMultiWriter(ring, backend)))
+	log.Debug("cache miss")
+	log.Warn("slow request")
+	log.Warn("slow query")
+
+	tests := []struct {
+		query    string
+		status   int
+		messages []string
+	}{
+		{"", http.StatusOK, []string{"cache miss", "slow request", "slow query"}},
+		{"?level=warn&limit=1", http.StatusOK, []string{"slow query"}},
+		{"?q=request", http.StatusOK, []string{"slow request"}},
+		{"?level=verbose", http.StatusBadRequest, nil},
+		{"?limit=x", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		ring.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs"+test.query, nil))
+
+		if rec.Code != test.status {
+			t.Errorf("%s: expected status %d; got %d", test.query, test.status, rec.Code)
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if len(lines) != len(test.messages) {
+			t.Errorf("%s: expected %d entries; got %q", test.query, len(test.messages), lines)
+			continue
+		}
+		for i, msg := range test.messages {
+			if !strings.Contains(lines[i], `"message":"`+msg+`"`) {
+				t.Errorf("%s: entry %s does not contain %s", test.query, lines[i], msg)
+			}
+		}
+	}
+}
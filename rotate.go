@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is the timestamp appended to the name of the rotated files
+const backupTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// RotatingFileWriter is an io.Writer that writes to a file and rotates it once it reaches
+// MaxSize bytes, keeping at most MaxBackups old files no older than MaxAge.
+// The rotated files are named after Filename with the rotation time appended, e.g.
+// app-2017-04-26T02-29-33.000000000.log. It is safe for concurrent use.
+//
+//	log := logger.New().WithOutput(&logger.RotatingFileWriter{
+//		Filename:   "/var/log/app.log",
+//		MaxSize:    100 << 20,
+//		MaxBackups: 5,
+//	})
+type RotatingFileWriter struct {
+	// Filename is the file to write to, it is created when it does not exist
+	Filename string
+	// MaxSize is the size in bytes the file can reach before being rotated. Zero disables rotation
+	MaxSize int64
+	// MaxAge is the maximum age of the rotated files. Zero keeps them regardless of their age
+	MaxAge time.Duration
+	// MaxBackups is the maximum number of rotated files to keep. Zero keeps all of them
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// now is the clock used to name the backups, time.Now when nil
+	now func() time.Time
+}
+
+// Write appends p to the file, rotating it first when p does not fit within MaxSize
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it as a backup and starts a new one
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Reopen closes the file and opens Filename again, creating it when it was moved, so an external
+// tool such as logrotate can rotate the file without copytruncate
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	return w.open()
+}
+
+// ReopenOnSignal reopens the file whenever the process receives one of the signals, SIGHUP
+// when none is given, e.g. from the postrotate script of logrotate:
+//
+//	postrotate
+//		kill -HUP $(cat /var/run/app.pid)
+//	endscript
+//
+// The errors are reported as the ones of the writes, see OnError. The returned function stops
+// listening to the signals
+func (w *RotatingFileWriter) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				if err := w.Reopen(); err != nil {
+					reportError(fmt.Errorf("logger: cannot reopen %s: %s", w.Filename, err.Error()))
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// Close closes the current file
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens or creates the file, appending to the existing content
+func (w *RotatingFileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+
+	now := w.timeNow()
+	if _, err := os.Stat(w.Filename); err == nil {
+		if err := os.Rename(w.Filename, w.backupName(now)); err != nil {
+			return err
+		}
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	return w.removeBackups(now)
+}
+
+func (w *RotatingFileWriter) timeNow() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+// backupName returns the name of the backup file for a rotation at time t
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.Filename)
+	prefix := strings.TrimSuffix(w.Filename, ext)
+	return prefix + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// backups returns the rotated files along with their rotation time, newest first
+func (w *RotatingFileWriter) backups() ([]string, []time.Time, error) {
+	ext := filepath.Ext(w.Filename)
+	prefix := strings.TrimSuffix(filepath.Base(w.Filename), ext) + "-"
+	dir := filepath.Dir(w.Filename)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	times := make(map[string]time.Time)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil {
+			continue
+		}
+		names = append(names, name)
+		times[name] = t
+	}
+
+	sort.Slice(names, func(i, j int) bool { return times[names[i]].After(times[names[j]]) })
+
+	paths := make([]string, len(names))
+	ts := make([]time.Time, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		ts[i] = times[name]
+	}
+	return paths, ts, nil
+}
+
+// removeBackups deletes the backups exceeding MaxBackups or older than MaxAge
+func (w *RotatingFileWriter) removeBackups(now time.Time) error {
+	if w.MaxBackups == 0 && w.MaxAge == 0 {
+		return nil
+	}
+
+	paths, times, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-w.MaxAge)
+	for i, path := range paths {
+		expired := w.MaxAge > 0 && times[i].Before(cutoff)
+		if (w.MaxBackups > 0 && i >= w.MaxBackups) || expired {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
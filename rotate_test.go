@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriterRotatesOnSize(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	dir := t.TempDir()
+	now := time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC)
+	w := &RotatingFileWriter{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxSize:  300,
+		now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	}
+	defer w.Close()
+
+	log := New().WithOutput(w)
+	for i := 0; i < 10; i++ {
+		log.Infof("INFO message %d", i)
+	}
+
+	backups, _, err := w.backups()
+	if err != nil {
+		t.Fatalf("cannot list the backups: %s", err.Error())
+	}
+	if len(backups) == 0 {
+		t.Fatalf("expected the file to be rotated")
+	}
+
+	// No entry is lost nor split across files
+	total := 0
+	for _, path := range append(backups, w.Filename) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("cannot read %s: %s", path, err.Error())
+		}
+		if len(b) > 300 {
+			t.Errorf("file %s exceeds MaxSize with %d bytes", path, len(b))
+		}
+		if !strings.HasSuffix(string(b), "\n") {
+			t.Errorf("file %s contains a partial entry", path)
+		}
+		total += strings.Count(string(b), "\n")
+	}
+	if total != 10 {
+		t.Errorf("expected 10 entries across all files; got %d", total)
+	}
+
+	expected := filepath.Join(dir, "app-2017-04-26T02-29-34.000000000.log")
+	if backups[len(backups)-1] != expected {
+		t.Errorf("oldest backup %s does not match expected name %s", backups[len(backups)-1], expected)
+	}
+}
+
+func TestRotatingFileWriterMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC)
+	w := &RotatingFileWriter{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxBackups: 2,
+		now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	}
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		w.Write([]byte("entry\n"))
+		if err := w.Rotate(); err != nil {
+			t.Fatalf("cannot rotate: %s", err.Error())
+		}
+	}
+
+	backups, _, _ := w.backups()
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups; got %d", len(backups))
+	}
+
+	// The newest backups are the ones kept
+	if !strings.HasSuffix(backups[0], "02-29-38.000000000.log") {
+		t.Errorf("unexpected newest backup %s", backups[0])
+	}
+}
+
+func TestRotatingFileWriterMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2017, 4, 26, 2, 29, 33, 0, time.UTC)
+	w := &RotatingFileWriter{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxAge:   24 * time.Hour,
+		now: func() time.Time {
+			now = now.Add(10 * time.Hour)
+			return now
+		},
+	}
+	defer w.Close()
+
+	for i := 0; i < 4; i++ {
+		w.Write([]byte("entry\n"))
+		w.Rotate()
+	}
+
+	// Rotations happened at +10h, +20h, +30h and +40h, only the last three are within a day
+	backups, _, _ := w.backups()
+	if len(backups) != 3 {
+		t.Errorf("expected 3 backups within MaxAge; got %d", len(backups))
+	}
+}
+
+func TestRotatingFileWriterReopen(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	dir := t.TempDir()
+	w := &RotatingFileWriter{Filename: filepath.Join(dir, "app.log")}
+	defer w.Close()
+
+	log := New(WithWriter(w))
+	log.Info("before rotation")
+
+	// logrotate moves the file, the entries keep going to it until it is reopened
+	rotated := filepath.Join(dir, "app.log.1")
+	if err := os.Rename(w.Filename, rotated); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("before reopen")
+	if err := w.Reopen(); err != nil {
+		t.Fatalf("cannot reopen: %s", err.Error())
+	}
+	log.Info("after reopen")
+
+	old, err := os.ReadFile(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.ReadFile(w.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(old), "\n") != 2 || !strings.Contains(string(old), "before reopen") {
+		t.Errorf("unexpected rotated file %s", old)
+	}
+	if strings.Count(string(current), "\n") != 1 || !strings.Contains(string(current), "after reopen") {
+		t.Errorf("unexpected new file %s", current)
+	}
+}
+
+func TestRotatingFileWriterReopenOnSignal(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	dir := t.TempDir()
+	w := &RotatingFileWriter{Filename: filepath.Join(dir, "app.log")}
+	defer w.Close()
+	stop := w.ReopenOnSignal()
+	defer stop()
+
+	log := New(WithWriter(w))
+	log.Info("before rotation")
+	if err := os.Rename(w.Filename, filepath.Join(dir, "app.log.1")); err != nil {
+		t.Fatal(err)
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot send SIGHUP: %s", err.Error())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(w.Filename); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the file was not reopened on the signal")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
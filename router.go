@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// LevelRouter is an io.Writer sending the log entries to a different writer depending on
+// their severity, e.g. DEBUG and INFO to stdout and WARN to CRITICAL to stderr:
+//
+//	log := logger.New().WithOutput(logger.NewLevelRouter(os.Stdout).Route(logger.WARN, logger.CRITICAL, os.Stderr))
+//
+// Routes must be configured before the router is used for logging.
+type LevelRouter struct {
+	fallback io.Writer
+	writers  map[severity]io.Writer
+}
+
+// NewLevelRouter returns a LevelRouter sending all the entries to w until routes are added
+func NewLevelRouter(w io.Writer) *LevelRouter {
+	return &LevelRouter{
+		fallback: w,
+		writers:  make(map[severity]io.Writer),
+	}
+}
+
+// WithSplitOutput writes the ERROR and CRITICAL entries to stderr and the other ones to stdout,
+// as Cloud Run and many container platforms classify the stderr lines as errors
+func WithSplitOutput() Option {
+	return func(l *Log) {
+		l.writer = NewLevelRouter(os.Stdout).Route(ERROR, CRITICAL, os.Stderr)
+	}
+}
+
+// Route sends the entries with a severity between min and max, both included, to w
+func (r *LevelRouter) Route(min, max severity, w io.Writer) *LevelRouter {
+	for s := min; s <= max; s++ {
+		r.writers[s] = w
+	}
+	return r
+}
+
+// Write sends p to the default writer, as its severity is unknown
+func (r *LevelRouter) Write(p []byte) (int, error) {
+	return r.fallback.Write(p)
+}
+
+// writeLevel sends p to the writer routed for the severity
+func (r *LevelRouter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	w, ok := r.writers[s]
+	if !ok {
+		w = r.fallback
+	}
+
+	if lw, ok := w.(levelWriter); ok {
+		return lw.writeLevel(s, t, p)
+	}
+	return w.Write(p)
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelRouter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	log := New().WithOutput(NewLevelRouter(stdout).Route(WARN, CRITICAL, stderr))
+
+	log.Debug("DEBUG message")
+	log.Info("INFO message")
+	log.Warn("WARN message")
+	log.Error("ERROR message")
+
+	if got := strings.Count(stdout.String(), "\n"); got != 2 {
+		t.Errorf("expected 2 entries on stdout; got %d", got)
+	}
+	if strings.Contains(stdout.String(), "WARN") || strings.Contains(stdout.String(), "ERROR") {
+		t.Errorf("stdout %s contains WARN or ERROR entries", stdout.String())
+	}
+
+	if got := strings.Count(stderr.String(), "\n"); got != 2 {
+		t.Errorf("expected 2 entries on stderr; got %d", got)
+	}
+	if strings.Contains(stderr.String(), "DEBUG") || strings.Contains(stderr.String(), "INFO") {
+		t.Errorf("stderr %s contains DEBUG or INFO entries", stderr.String())
+	}
+}
+
+func TestLevelRouterDefaultWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	router := NewLevelRouter(buf).Route(ERROR, ERROR, new(bytes.Buffer))
+
+	router.Write([]byte("unknown severity\n"))
+	router.writeLevel(CRITICAL, time.Time{}, []byte("critical\n"))
+
+	if expected := "unknown severity\ncritical\n"; buf.String() != expected {
+		t.Errorf("output %q does not match expected string %q", buf.String(), expected)
+	}
+}
+
+func TestWithSplitOutput(t *testing.T) {
+	router, ok := New(WithSplitOutput()).writer.(*LevelRouter)
+	if !ok {
+		t.Fatalf("expected a LevelRouter output")
+	}
+	for s := TRACE; s <= CRITICAL; s++ {
+		expected := io.Writer(os.Stdout)
+		if s >= ERROR {
+			expected = os.Stderr
+		}
+		if w, ok := router.writers[s]; ok && w != expected || !ok && router.fallback != expected {
+			t.Errorf("unexpected writer for %s", s)
+		}
+	}
+}
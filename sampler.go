@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// samplerSlots is the number of counters per level, messages sharing a slot share their count
+const samplerSlots = 4096
+
+// sampler limits the entries written per message, see WithSampling
+type sampler struct {
+	rules map[severity]*samplingRule
+}
+
+type samplingRule struct {
+	tick       int64
+	first      uint64
+	thereafter uint64
+	counters   [samplerSlots]samplingCounter
+}
+
+type samplingCounter struct {
+	resetAt int64
+	count   uint64
+}
+
+// WithSampling samples the entries of the given levels, TRACE, DEBUG, INFO and WARN when none is given:
+// within every tick, the first entries with a same message are all written, then only one out
+// of every thereafter is, none if thereafter is zero. ERROR and CRITICAL entries are never sampled.
+// For the formatting methods such as Infof, the message is the format string.
+//
+//	// Up to 100 identical entries per second, then one every 100
+//	log := logger.New(logger.WithSampling(time.Second, 100, 100))
+func WithSampling(tick time.Duration, first, thereafter int, levels ...severity) Option {
+	if len(levels) == 0 {
+		levels = []severity{TRACE, DEBUG, INFO, WARN}
+	}
+
+	return func(l *Log) {
+		// Do not modify the sampler shared with the logger this one derives from
+		s := &sampler{rules: make(map[severity]*samplingRule)}
+		if l.sampler != nil {
+			for lvl, rule := range l.sampler.rules {
+				s.rules[lvl] = rule
+			}
+		}
+
+		for _, lvl := range levels {
+			if lvl >= ERROR {
+				continue
+			}
+			s.rules[lvl] = &samplingRule{
+				tick:       int64(tick),
+				first:      uint64(first),
+				thereafter: uint64(thereafter),
+			}
+		}
+		l.sampler = s
+	}
+}
+
+// allow checks whether an entry with the given severity and message must be written
+func (s *sampler) allow(lvl severity, message string) bool {
+	if s == nil {
+		return true
+	}
+
+	rule, ok := s.rules[lvl]
+	if !ok {
+		return true
+	}
+
+	c := &rule.counters[fnv32a(message)%samplerSlots]
+	now := time.Now().UnixNano()
+
+	// Start a new tick, racing goroutines may reset the counter twice which only lets an extra entry through
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if now > resetAt {
+		if atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+rule.tick) {
+			atomic.StoreUint64(&c.count, 0)
+		}
+	}
+
+	n := atomic.AddUint64(&c.count, 1)
+	if n <= rule.first {
+		return true
+	}
+	return rule.thereafter > 0 && (n-rule.first)%rule.thereafter == 0
+}
+
+// fnv32a hashes a string without allocating
+func fnv32a(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
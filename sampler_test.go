@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerWithSampling(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithSampling(time.Hour, 3, 5)).WithOutput(buf)
+
+	for i := 0; i < 20; i++ {
+		log.Infof("INFO message %d", i)
+	}
+
+	// The first 3 entries, then the 8th, 13th and 18th
+	if got := strings.Count(buf.String(), "\n"); got != 6 {
+		t.Errorf("expected 6 sampled entries; got %d", got)
+	}
+	for _, expected := range []string{"INFO message 0", "INFO message 2", "INFO message 7", "INFO message 17"} {
+		if !strings.Contains(buf.String(), expected+`"`) {
+			t.Errorf("output %s does not contain %s", buf.String(), expected)
+		}
+	}
+
+	// Other messages are counted separately
+	buf.Reset()
+	log.Info("another INFO message")
+	if buf.Len() == 0 {
+		t.Errorf("expected a different message not to be sampled")
+	}
+}
+
+func TestLoggerSamplingNeverDropsErrors(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithSampling(time.Hour, 1, 0, DEBUG, ERROR)).WithOutput(buf)
+
+	for i := 0; i < 5; i++ {
+		log.Debug("DEBUG message")
+		log.Error("ERROR message")
+		log.Info("INFO message")
+	}
+
+	got := buf.String()
+	if n := strings.Count(got, `"severity":"DEBUG"`); n != 1 {
+		t.Errorf("expected 1 DEBUG entry; got %d", n)
+	}
+	if n := strings.Count(got, `"severity":"ERROR"`); n != 5 {
+		t.Errorf("expected 5 ERROR entries; got %d", n)
+	}
+	if n := strings.Count(got, `"severity":"INFO"`); n != 5 {
+		t.Errorf("expected 5 INFO entries, INFO is not sampled; got %d", n)
+	}
+}
+
+func TestLoggerSamplingResetsEveryTick(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithSampling(10*time.Millisecond, 1, 0)).WithOutput(buf)
+
+	log.Info("INFO message")
+	log.Info("INFO message")
+	time.Sleep(20 * time.Millisecond)
+	log.Info("INFO message")
+
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Errorf("expected 2 entries across two ticks; got %d", got)
+	}
+}
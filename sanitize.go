@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyPolicy tells what a KeySanitizer does with the fields named after a reserved key
+type KeyPolicy int
+
+const (
+	// PrefixKey adds the prefix of the sanitizer to the key
+	PrefixKey KeyPolicy = iota
+	// DropKey removes the field
+	DropKey
+	// KeepKey leaves the field as is
+	KeepKey
+)
+
+// DefaultReservedKeys are the keys of the payload and of the console and logfmt lines, which are
+// ambiguous as field names
+var DefaultReservedKeys = []string{"severity", "eventTime", "message", "stacktrace", "serviceContext", "caller", "seq", "ts", "level", "msg"}
+
+// KeySanitizer renames the context fields before the entries are encoded, so their names neither
+// collide with the reserved keys nor contain characters that are awkward in the Cloud Logging
+// queries. It only applies to the top level fields. When two fields end up with the same name,
+// the ones whose name was changed get a "_2", "_3"... suffix, in the order of their original
+// names, so the output does not depend on the map iteration order
+type KeySanitizer struct {
+	// Rename maps field names to new ones, e.g. "message" to "user_message", before the other rules
+	Rename map[string]string
+	// Reserved are the keys handled by Policy, DefaultReservedKeys when nil
+	Reserved []string
+	// Policy is applied to the fields named after a reserved key
+	Policy KeyPolicy
+	// Prefix is added by the PrefixKey policy, "field_" by default
+	Prefix string
+	// ReplaceInvalid replaces the characters other than ASCII letters, digits and underscores with underscores
+	ReplaceInvalid bool
+
+	reserved map[string]bool
+}
+
+// WithKeySanitizer renames the context fields as configured by s
+func WithKeySanitizer(s *KeySanitizer) Option {
+	reserved := s.Reserved
+	if reserved == nil {
+		reserved = DefaultReservedKeys
+	}
+	s.reserved = make(map[string]bool, len(reserved))
+	for _, k := range reserved {
+		s.reserved[k] = true
+	}
+	if s.Prefix == "" {
+		s.Prefix = "field_"
+	}
+
+	return func(l *Log) {
+		l.sanitizer = s
+	}
+}
+
+// sanitize replaces the payload context with a copy where the fields are renamed
+func (s *KeySanitizer) sanitize(p *Payload) {
+	if p.Context == nil || len(p.Context.Data) == 0 {
+		return
+	}
+
+	data := make(Fields, len(p.Context.Data))
+	var renamed []string
+	for k, v := range p.Context.Data {
+		if s.key(k) == k {
+			data[k] = v
+		} else {
+			renamed = append(renamed, k)
+		}
+	}
+
+	sort.Strings(renamed)
+	for _, k := range renamed {
+		base := s.key(k)
+		if base == "" {
+			continue
+		}
+		key := base
+		for i := 2; ; i++ {
+			if _, taken := data[key]; !taken {
+				break
+			}
+			key = base + "_" + strconv.Itoa(i)
+		}
+		data[key] = p.Context.Data[k]
+	}
+
+	c := *p.Context
+	c.Data = data
+	p.Context = &c
+}
+
+// key returns the sanitized name of a field, empty when it is dropped
+func (s *KeySanitizer) key(k string) string {
+	if r, ok := s.Rename[k]; ok {
+		k = r
+	}
+	if s.ReplaceInvalid {
+		k = strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+				return r
+			}
+			return '_'
+		}, k)
+	}
+
+	if s.reserved[k] {
+		switch s.Policy {
+		case PrefixKey:
+			return s.Prefix + k
+		case DropKey:
+			return ""
+		}
+	}
+	return k
+}
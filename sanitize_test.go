@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeySanitizer(t *testing.T) {
+	data := Fields{
+		"message":       "user message",
+		"severity":      "high",
+		"field_message": "existing",
+		"user.id":       42,
+		"user_id":       43,
+		"user-id":       44,
+		"password":      "secret",
+	}
+
+	tests := []struct {
+		sanitizer *KeySanitizer
+		expected  Fields
+	}{
+		{
+			&KeySanitizer{},
+			Fields{"field_message": "existing", "field_message_2": "user message", "field_severity": "high", "user.id": 42, "user_id": 43, "user-id": 44, "password": "secret"},
+		},
+		{
+			&KeySanitizer{Policy: DropKey, ReplaceInvalid: true},
+			Fields{"field_message": "existing", "user_id": 43, "user_id_2": 44, "user_id_3": 42, "password": "secret"},
+		},
+		{
+			&KeySanitizer{Rename: map[string]string{"message": "user_message", "password": "severity"}, Policy: KeepKey},
+			Fields{"user_message": "user message", "severity": "high", "severity_2": "secret", "field_message": "existing", "user.id": 42, "user_id": 43, "user-id": 44},
+		},
+		{
+			&KeySanitizer{Reserved: []string{"password"}, Prefix: "x_"},
+			Fields{"message": "user message", "severity": "high", "field_message": "existing", "user.id": 42, "user_id": 43, "user-id": 44, "x_password": "secret"},
+		},
+	}
+
+	for i, test := range tests {
+		l := &Log{}
+		WithKeySanitizer(test.sanitizer)(l)
+
+		p := &Payload{Context: &Context{Data: data}}
+		l.sanitizer.sanitize(p)
+		if !reflect.DeepEqual(p.Context.Data, test.expected) {
+			t.Errorf("sanitizer %d: expected %v; got %v", i, test.expected, p.Context.Data)
+		}
+	}
+
+	if len(data) != 7 || data["message"] != "user message" {
+		t.Errorf("the original fields were modified: %v", data)
+	}
+}
+
+func TestLoggerWithKeySanitizer(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithLogfmtOutput(), WithKeySanitizer(&KeySanitizer{})).With(Fields{"msg": "user message"}).WithOutput(buf)
+	log.Info("INFO message")
+
+	if !strings.Contains(buf.String(), `msg="INFO message"`) || !strings.Contains(buf.String(), `field_msg="user message"`) {
+		t.Errorf("unexpected output %s", buf.String())
+	}
+}
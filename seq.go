@@ -0,0 +1,10 @@
+package logger
+
+// WithSequence numbers the entries written with a "seq" field, starting at 1, so the consumers
+// can detect the entries dropped or reordered on their way. The counter is shared with the
+// loggers derived from the logger
+func WithSequence() Option {
+	return func(l *Log) {
+		l.seq = new(uint64)
+	}
+}
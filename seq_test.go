@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithSequence(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithSequence()).WithOutput(buf)
+	child := log.With(Fields{"key": "value"}).WithOutput(buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info("INFO message")
+			child.Warn("WARN message")
+		}()
+	}
+	wg.Wait()
+
+	// Filtered entries do not take a number
+	log.Debug("DEBUG message")
+	SetLevel(INFO)
+	defer SetLevel(DEBUG)
+	log.Debug("DEBUG message")
+	log.Info("INFO message")
+
+	seen := make(map[uint64]bool)
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		p := Payload{}
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatalf("entry %s cannot be unmarshalled: %s", line, err.Error())
+		}
+		if p.Seq == 0 || seen[p.Seq] {
+			t.Errorf("entry %s does not have a unique sequence number", line)
+		}
+		seen[p.Seq] = true
+	}
+	for i := uint64(1); i <= 22; i++ {
+		if !seen[i] {
+			t.Errorf("sequence number %d is missing", i)
+		}
+	}
+}
+
+func TestSequenceIsOffByDefault(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New().WithOutput(buf).Info("INFO message")
+	if strings.Contains(buf.String(), `"seq"`) {
+		t.Errorf("output %s contains a sequence number", buf.String())
+	}
+}
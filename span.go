@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+// SpanContext identifies the tracing span active when an entry is logged
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// SpanContextFunc extracts the active span from a context, ok is false when there is none
+type SpanContextFunc func(ctx context.Context) (sc SpanContext, ok bool)
+
+var (
+	spanMu   sync.RWMutex
+	spanFunc SpanContextFunc
+)
+
+// SetSpanContextFunc registers the function used by Ctx to find the active span. It keeps the
+// package free of tracing dependencies; with OpenTelemetry it would look like:
+//
+//	logger.SetSpanContextFunc(func(ctx context.Context) (logger.SpanContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return logger.SpanContext{
+//			TraceID: sc.TraceID().String(),
+//			SpanID:  sc.SpanID().String(),
+//			Sampled: sc.IsSampled(),
+//		}, sc.IsValid()
+//	})
+func SetSpanContextFunc(f SpanContextFunc) {
+	spanMu.Lock()
+	defer spanMu.Unlock()
+	spanFunc = f
+}
+
+// Ctx creates a copy of a Log enriched with the span active in ctx: the trace_id, span_id and
+// trace_sampled context data, along with the Cloud Trace correlation fields (see WithTrace).
+// The Log is returned unchanged when there is no active span
+func (l *Log) Ctx(ctx context.Context) *Log {
+	spanMu.RLock()
+	f := spanFunc
+	spanMu.RUnlock()
+
+	if f == nil || ctx == nil {
+		return l
+	}
+
+	sc, ok := f(ctx)
+	if !ok {
+		return l
+	}
+
+	return l.With(Fields{
+		"trace_id":      sc.TraceID,
+		"span_id":       sc.SpanID,
+		"trace_sampled": sc.Sampled,
+	}).WithTrace(sc.TraceID, sc.SpanID, sc.Sampled)
+}
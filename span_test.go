@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+type spanKey struct{}
+
+func TestLoggerCtx(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+
+	SetSpanContextFunc(func(ctx context.Context) (SpanContext, bool) {
+		sc, ok := ctx.Value(spanKey{}).(SpanContext)
+		return sc, ok
+	})
+	defer SetSpanContextFunc(nil)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Sampled: true,
+	})
+
+	buf := new(bytes.Buffer)
+	New().With(Fields{"key": "value"}).Ctx(ctx).WithOutput(buf).Info("INFO message")
+
+	got := buf.String()
+	if !strings.Contains(got, `"data":{"key":"value","span_id":"00f067aa0ba902b7","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","trace_sampled":true}`) {
+		t.Errorf("output %s does not contain the span context data", got)
+	}
+	if !strings.Contains(got, `"logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("output %s does not contain the trace correlation", got)
+	}
+
+	// Without an active span the logger is left untouched
+	log := New()
+	if log.Ctx(context.Background()) != log {
+		t.Errorf("expected the same logger without an active span")
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrSpoolFull is returned when an entry does not fit in the spool file of an AsyncWriter
+var ErrSpoolFull = errors.New("logger: spool file full")
+
+// spoolRetryDelay is the delay between two attempts to replay the spooled entries to a failing writer
+var spoolRetryDelay = time.Second
+
+// spool is the disk overflow of an AsyncWriter: the entries are appended to the file, each
+// prefixed with its length, severity and time, and read back from the offset of the oldest one. The file is
+// truncated once all of them have been replayed
+type spool struct {
+	file *os.File
+	max  int64
+
+	mu sync.Mutex
+	// off is the offset of the oldest entry, size the size of the file
+	off, size int64
+}
+
+// NewSpoolingAsyncWriter returns an AsyncWriter writing to w with a queue of size entries, which
+// appends the entries to the spool file at path when the queue is full instead of blocking. The
+// spooled entries are written to w once the queue is empty, in order, and are kept in the file
+// while w fails, so a burst or an outage of the sink does not lose them. The entries left in the
+// file by a previous run are replayed too. When the file would grow over maxBytes, zero meaning
+// no limit, the callers block as without a spool
+func NewSpoolingAsyncWriter(w io.Writer, size int, path string, maxBytes int64) (*AsyncWriter, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	a := &AsyncWriter{
+		w:       w,
+		entries: make(chan asyncEntry, size),
+		done:    make(chan struct{}),
+		spool:   &spool{file: f, max: maxBytes, size: info.Size()},
+	}
+	go a.run()
+	return a, nil
+}
+
+// spoolHeaderSize is the size of the header of the spooled entries: the length of the entry,
+// its severity plus one, zero when it has none, then its time in nanoseconds since the epoch
+const spoolHeaderSize = 13
+
+// enqueue queues the entry, or spools it when the queue is full or when older entries are spooled
+func (s *spool) enqueue(entries chan asyncEntry, e asyncEntry) {
+	s.mu.Lock()
+	if s.off == s.size {
+		select {
+		case entries <- e:
+			s.mu.Unlock()
+			return
+		default:
+		}
+	}
+	err := s.append(e)
+	s.mu.Unlock()
+
+	if err != nil {
+		if err != ErrSpoolFull {
+			reportError(fmt.Errorf("logger: cannot spool the entry: %s", err.Error()))
+		}
+		entries <- e
+	}
+}
+
+// append writes the entry at the end of the file, the lock must be held
+func (s *spool) append(e asyncEntry) error {
+	n := int64(spoolHeaderSize + len(e.data))
+	if s.max > 0 && s.size+n > s.max {
+		return ErrSpoolFull
+	}
+
+	frame := make([]byte, n)
+	binary.BigEndian.PutUint32(frame, uint32(len(e.data)))
+	if e.leveled {
+		frame[4] = byte(e.level + 1)
+		binary.BigEndian.PutUint64(frame[5:], uint64(e.time.UnixNano()))
+	}
+	copy(frame[spoolHeaderSize:], e.data)
+	if _, err := s.file.WriteAt(frame, s.size); err != nil {
+		return err
+	}
+	s.size += n
+	return nil
+}
+
+func (s *spool) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.off < s.size
+}
+
+// replay writes the oldest spooled entry with write, it is kept in the file when write fails.
+// Only the goroutine of the AsyncWriter reads the file, so it does not hold the lock meanwhile
+func (s *spool) replay(write func(e asyncEntry) error) error {
+	s.mu.Lock()
+	off, size := s.off, s.size
+	s.mu.Unlock()
+	if off >= size {
+		return nil
+	}
+
+	var header [spoolHeaderSize]byte
+	var e asyncEntry
+	_, err := s.file.ReadAt(header[:], off)
+	if err == nil {
+		e.data = make([]byte, binary.BigEndian.Uint32(header[:]))
+		if header[4] > 0 {
+			e.level, e.leveled = severity(header[4]-1), true
+			e.time = time.Unix(0, int64(binary.BigEndian.Uint64(header[5:])))
+		}
+		_, err = s.file.ReadAt(e.data, off+spoolHeaderSize)
+	}
+	if err != nil {
+		// A truncated entry, e.g. from a crash while spooling, the rest of the file cannot be read
+		reportError(fmt.Errorf("logger: cannot read the spool file, the spooled entries are dropped: %s", err.Error()))
+		s.mu.Lock()
+		s.reset()
+		s.mu.Unlock()
+		return nil
+	}
+
+	if err := write(e); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.off += int64(spoolHeaderSize + len(e.data))
+	if s.off == s.size {
+		s.reset()
+	}
+	return nil
+}
+
+// replayAll writes all the spooled entries with write, stopping at the first error
+func (s *spool) replayAll(write func(e asyncEntry) error) error {
+	for s.pending() {
+		if err := s.replay(write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reset empties the file, the lock must be held
+func (s *spool) reset() {
+	if err := s.file.Truncate(0); err != nil {
+		reportError(fmt.Errorf("logger: cannot truncate the spool file: %s", err.Error()))
+	}
+	s.off, s.size = 0, 0
+}
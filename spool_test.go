@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSpoolingAsyncWriterOverflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+	w := &blockingWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	async, err := NewSpoolingAsyncWriter(w, 1, path, 0)
+	if err != nil {
+		t.Fatalf("cannot create the writer: %s", err.Error())
+	}
+	defer async.Close()
+
+	// The first entry is being written and the second one fills the queue, the next ones are spooled
+	async.Write([]byte("first\n"))
+	<-w.started
+	async.Write([]byte("second\n"))
+	async.Write([]byte("third\n"))
+	async.Write([]byte("fourth\n"))
+
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("expected the entries in the spool file")
+	}
+
+	close(w.release)
+	if err := async.Flush(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+	if w.String() != "first\nsecond\nthird\nfourth\n" {
+		t.Errorf("unexpected output %q", w.String())
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("expected the spool file to be truncated")
+	}
+}
+
+func TestSpoolingAsyncWriterReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+
+	// Entries left by a previous run
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("cannot create the spool file: %s", err.Error())
+	}
+	s := &spool{file: f}
+	s.append(asyncEntry{data: []byte("first\n")})
+	s.append(asyncEntry{data: []byte("second\n")})
+	f.Close()
+
+	// The background goroutine may try to replay the entries before Flush does
+	w := &flakyWriter{failures: 2}
+	async, err := NewSpoolingAsyncWriter(w, 10, path, 0)
+	if err != nil {
+		t.Fatalf("cannot create the writer: %s", err.Error())
+	}
+
+	// The sink fails, the entries are kept for later
+	if err := async.Flush(); err == nil {
+		t.Errorf("expected the writer error")
+	}
+	async.Write([]byte("third\n"))
+
+	if err := async.Close(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+	if w.String() != "first\nsecond\nthird\n" {
+		t.Errorf("unexpected output %q", w.String())
+	}
+}
+
+func TestSpoolingAsyncWriterRoutesLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("cannot create the spool file: %s", err.Error())
+	}
+	s := &spool{file: f}
+	s.append(asyncEntry{data: []byte("info\n"), level: INFO, time: time.Now(), leveled: true})
+	s.append(asyncEntry{data: []byte("error\n"), level: ERROR, time: time.Now(), leveled: true})
+	s.append(asyncEntry{data: []byte("unknown\n")})
+	f.Close()
+
+	out, errb := new(bytes.Buffer), new(bytes.Buffer)
+	async, err := NewSpoolingAsyncWriter(NewLevelRouter(out).Route(ERROR, CRITICAL, errb), 10, path, 0)
+	if err != nil {
+		t.Fatalf("cannot create the writer: %s", err.Error())
+	}
+	if err := async.Close(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if out.String() != "info\nunknown\n" || errb.String() != "error\n" {
+		t.Errorf("unexpected outputs %q and %q", out.String(), errb.String())
+	}
+}
+
+func TestSpoolingAsyncWriterMaxBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+	w := &blockingWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	async, err := NewSpoolingAsyncWriter(w, 1, path, spoolHeaderSize+8)
+	if err != nil {
+		t.Fatalf("cannot create the writer: %s", err.Error())
+	}
+
+	async.Write([]byte("first\n"))
+	<-w.started
+	async.Write([]byte("second\n"))
+	async.Write([]byte("third\n"))
+
+	// The spool file is full, the next write blocks until there is room in the queue
+	written := make(chan struct{})
+	go func() {
+		async.Write([]byte("fourth\n"))
+		close(written)
+	}()
+
+	close(w.release)
+	<-written
+	async.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("expected the spool file to be empty")
+	}
+	if got := w.String(); len(got) != len("first\nsecond\nthird\nfourth\n") {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// WithStackTraceLimit limits the stacktraces of the ERROR and CRITICAL entries to their first frames.
+// The stacktraces are complete by default
+func WithStackTraceLimit(frames int) Option {
+	return func(l *Log) {
+		l.stackLimit = frames
+	}
+}
+
+// WithStackFrames adds the stack of the ERROR and CRITICAL entries to their context as a list of
+// frames, so it can be processed without parsing the stacktrace
+func WithStackFrames() Option {
+	return func(l *Log) {
+		l.stackFrames = true
+	}
+}
+
+// StackFilter removes the frames of no interest from the stacktraces of the ERROR and CRITICAL
+// entries, so they start at the application frame that matters
+type StackFilter struct {
+	// Runtime removes the frames of the Go runtime
+	Runtime bool
+	// Logger removes the frames of the logger itself
+	Logger bool
+	// Vendor removes the frames of the vendored packages and of the module dependencies
+	Vendor bool
+	// StopAtMain ends the stacks at main.main, dropping the frames calling it
+	StopAtMain bool
+}
+
+// WithStackFilter filters the frames of the stacktraces of the ERROR and CRITICAL entries, and of
+// their frames when WithStackFrames is set. A stack whose frames are all removed is kept complete
+func WithStackFilter(f StackFilter) Option {
+	return func(l *Log) {
+		l.stackFilter = &f
+	}
+}
+
+// moduleVersion matches the module@version directory of the dependencies in the module cache
+var moduleVersion = regexp.MustCompile(`@v[0-9]`)
+
+// keep tells whether a frame is kept, and whether it is the last one
+func (f *StackFilter) keep(frame StackFrame) (keep, last bool) {
+	if f.StopAtMain && frame.Function == "main.main" {
+		return true, true
+	}
+	if f.Runtime && isRuntimeFrame(frame) || f.Logger && isLoggerFrame(frame) {
+		return false, false
+	}
+	if f.Vendor && (strings.Contains(frame.File, "/vendor/") || moduleVersion.MatchString(frame.File)) {
+		return false, false
+	}
+	return true, false
+}
+
+// StackFrame is a function call of a stack
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// callers returns the program counters of the current goroutine stack, starting at the caller
+// of callers. Unlike runtime.Stack, which truncates its output to the buffer and elides the
+// frames past the hundredth, the stack is complete
+func callers() []uintptr {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(2, pcs)
+		if n < len(pcs) {
+			return pcs[:n]
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+}
+
+// formatStack renders program counters as returned by runtime.Callers in the format of
+// runtime.Stack, which is the one Error Reporting understands
+func formatStack(pcs []uintptr, limit int, filter *StackFilter) string {
+	var b strings.Builder
+	b.WriteString(goroutineHeader())
+
+	for _, f := range stackFrames(pcs, limit, filter) {
+		fmt.Fprintf(&b, "%s()\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
+
+// stackFrames returns the frames of program counters as returned by runtime.Callers, at most
+// limit when it is positive, and only the ones kept by the filter when it is not nil
+func stackFrames(pcs []uintptr, limit int, filter *StackFilter) []StackFrame {
+	var stack []StackFrame
+	frames := runtime.CallersFrames(pcs)
+	for limit <= 0 || len(stack) < limit {
+		frame, more := frames.Next()
+		f := StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}
+
+		keep, last := true, false
+		if filter != nil {
+			keep, last = filter.keep(f)
+		}
+		if keep {
+			stack = append(stack, f)
+		}
+		if !more || last {
+			break
+		}
+	}
+
+	if filter != nil && len(stack) == 0 {
+		return stackFrames(pcs, limit, nil)
+	}
+	return stack
+}
+
+// goroutineHeader returns the first line of runtime.Stack, e.g. "goroutine 1 [running]:\n"
+func goroutineHeader() string {
+	buffer := make([]byte, 64)
+	buffer = buffer[:runtime.Stack(buffer, false)]
+	if i := bytes.IndexByte(buffer, '\n'); i >= 0 {
+		return string(buffer[:i+1])
+	}
+	return "goroutine 1 [running]:\n"
+}
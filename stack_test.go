@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// deepError logs an error from the bottom of a deep call stack
+func deepError(log *Log, depth int) {
+	if depth > 0 {
+		deepError(log, depth-1)
+		return
+	}
+	log.Error("ERROR message")
+}
+
+func TestLoggerFullStackTrace(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	deepError(New().WithOutput(buf), 100)
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	// The stack is not truncated: it goes all the way up to the test function
+	if len(p.Stacktrace) <= 1024 || !strings.Contains(p.Stacktrace, "logger.TestLoggerFullStackTrace(") {
+		t.Errorf("stacktrace of %d bytes is truncated: %s", len(p.Stacktrace), p.Stacktrace)
+	}
+	if n := strings.Count(p.Stacktrace, "logger.deepError("); n != 101 {
+		t.Errorf("expected 101 deepError frames; got %d", n)
+	}
+}
+
+func TestLoggerWithStackTraceLimit(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	deepError(New(WithStackTraceLimit(5)).WithOutput(buf), 100)
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	lines := strings.Split(strings.TrimRight(p.Stacktrace, "\n"), "\n")
+	if len(lines) != 11 {
+		t.Errorf("expected the header and 5 frames; got %d lines: %s", len(lines), p.Stacktrace)
+	}
+	if !strings.HasPrefix(lines[0], "goroutine ") {
+		t.Errorf("stacktrace does not start with the goroutine header: %s", p.Stacktrace)
+	}
+}
+
+func TestLoggerWithStackFrames(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	deepError(New(WithStackFrames(), WithStackTraceLimit(5)).WithOutput(buf), 10)
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	frames := p.Context.Frames
+	if len(frames) != 5 {
+		t.Fatalf("expected 5 frames; got %+v", frames)
+	}
+	last := frames[len(frames)-1]
+	if !strings.HasSuffix(last.Function, "logger.deepError") || !strings.HasSuffix(last.File, "stack_test.go") || last.Line == 0 {
+		t.Errorf("unexpected frame %+v", last)
+	}
+	for _, f := range frames {
+		if !strings.Contains(p.Stacktrace, f.Function+"()\n\t"+f.File) {
+			t.Errorf("frame %+v is not in the stacktrace: %s", f, p.Stacktrace)
+		}
+	}
+}
+
+func TestLoggerWithStackFilter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	deepError(New(WithWriter(buf), WithStackFrames(), WithStackFilter(StackFilter{Runtime: true, Logger: true})), 2)
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	// The stack starts at the application frame and has no runtime frame
+	if len(p.Context.Frames) == 0 || !strings.HasSuffix(p.Context.Frames[0].Function, "logger.deepError") {
+		t.Errorf("expected the stack to start at deepError; got %+v", p.Context.Frames)
+	}
+	lines := strings.Split(p.Stacktrace, "\n")
+	if !strings.Contains(lines[1], "logger.deepError(") || strings.Contains(p.Stacktrace, "runtime.goexit") {
+		t.Errorf("unexpected stacktrace %s", p.Stacktrace)
+	}
+}
+
+func TestStackFilter(t *testing.T) {
+	filter := &StackFilter{Runtime: true, Vendor: true, StopAtMain: true}
+
+	tests := []struct {
+		frame StackFrame
+		keep  bool
+		last  bool
+	}{
+		{StackFrame{Function: "main.run", File: "/src/app/main.go"}, true, false},
+		{StackFrame{Function: "main.main", File: "/src/app/main.go"}, true, true},
+		{StackFrame{Function: "runtime.main", File: "/usr/local/go/src/runtime/proc.go"}, false, false},
+		{StackFrame{Function: "github.com/lib/pq.(*conn).query", File: "/src/app/vendor/github.com/lib/pq/conn.go"}, false, false},
+		{StackFrame{Function: "github.com/lib/pq.(*conn).query", File: "/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go"}, false, false},
+	}
+	for _, test := range tests {
+		if keep, last := filter.keep(test.frame); keep != test.keep || last != test.last {
+			t.Errorf("%s: expected %v %v; got %v %v", test.frame.File, test.keep, test.last, keep, last)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"log"
+	"runtime"
+	"strings"
+)
+
+// NewStdLogger returns a standard library *log.Logger whose output is written as structured
+// entries with the given severity by a new Log. It is meant for third-party code that only
+// accepts a *log.Logger, such as http.Server.ErrorLog
+func NewStdLogger(level severity) *log.Logger {
+	return New().StdLogger(level)
+}
+
+// StdLogger returns a standard library *log.Logger whose output is written as structured
+// entries with the given severity by this Log
+func (l *Log) StdLogger(level severity) *log.Logger {
+	return log.New(&stdWriter{log: l, level: level}, "", 0)
+}
+
+// stdWriter receives the lines printed by a standard library logger
+type stdWriter struct {
+	log   *Log
+	level severity
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\n")
+	if w.level < ERROR {
+		if w.log.isEnabled(w.level) {
+			p := w.log.entry(w.level.String(), message)
+			if w.log.addCaller {
+				fpc, file, line := stdCaller()
+				w.log.setCaller(p, fpc, file, line)
+			}
+			w.log.write(p)
+		}
+		return len(p), nil
+	}
+
+	fpc, file, line := stdCaller()
+	w.log.report(w.level.String(), message, fpc, file, line)
+	return len(p), nil
+}
+
+// stdCaller returns the location of the code calling the standard library logger
+func stdCaller() (uintptr, string, int) {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// Skip the frames of the standard library log package
+	frame, more := frames.Next()
+	for more && strings.HasPrefix(frame.Function, "log.") {
+		frame, more = frames.Next()
+	}
+	return frame.PC, frame.File, frame.Line
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStdLogger(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	std := New(WithClock(testClock)).With(Fields{"key": "value"}).WithOutput(buf).StdLogger(WARN)
+
+	std.Printf("WARN message %s", "with param")
+	expected := fmt.Sprintf(`{"severity":"WARN","eventTime":"%s","message":"WARN message with param","serviceContext":{"service":"my-app","version":"1.0"},"context":{"data":{"key":"value"}}}`, testTime.Format(time.RFC3339Nano))
+	got := strings.TrimRight(buf.String(), "\n")
+	if expected != got {
+		t.Errorf("output %s does not match expected string %s", got, expected)
+	}
+}
+
+func TestStdLoggerRespectsLevel(t *testing.T) {
+	initConfig(INFO, "my-app", "1.0")
+	defer SetLevel(DEBUG)
+
+	buf := new(bytes.Buffer)
+	New().WithOutput(buf).StdLogger(DEBUG).Print("DEBUG message")
+	if buf.Len() != 0 {
+		t.Errorf("output %s does not match empty string", buf.String())
+	}
+}
+
+func TestStdLoggerErrorReportLocation(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	std := New().WithOutput(buf).StdLogger(ERROR)
+	std.Println("ERROR message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	if p.Message != "ERROR message" || p.Stacktrace == "" {
+		t.Errorf("unexpected payload %s", buf.String())
+	}
+
+	expected := "logger.TestStdLoggerErrorReportLocation"
+	if p.Context.ReportLocation.FunctionName != expected {
+		t.Errorf("report location %s does not match the caller %s", p.Context.ReportLocation.FunctionName, expected)
+	}
+}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"reflect"
+)
+
+// syncer is implemented by the writers buffering their output, such as os.File
+type syncer interface {
+	Sync() error
+}
+
+// flusher is implemented by the writers buffering their output, such as bufio.Writer and AsyncWriter
+type flusher interface {
+	Flush() error
+}
+
+// Sync writes out the entries buffered by the logger and by its output, e.g. the summary of the
+// repeated entries or the queue of an AsyncWriter. It should be called before the program exits
+func (l *Log) Sync() error {
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return syncWriter(l.writer)
+}
+
+// Close writes out the buffered entries like Sync, then closes the output of the logger when it
+// is an io.Closer other than os.Stdout and os.Stderr. The logger must not be used afterwards,
+// nor the ones sharing its output
+func (l *Log) Close() error {
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return closeWriter(l.writer)
+}
+
+// syncWriter flushes w when it buffers its output
+func syncWriter(w io.Writer) error {
+	if isStdStream(w) {
+		// Syncing a terminal or a pipe fails, and they are not buffered anyway
+		return nil
+	}
+
+	switch w := w.(type) {
+	case flusher:
+		return w.Flush()
+	case syncer:
+		return w.Sync()
+	}
+	return nil
+}
+
+// closeWriter flushes then closes w when it is an io.Closer
+func closeWriter(w io.Writer) error {
+	err := syncWriter(w)
+	if c, ok := w.(io.Closer); ok && !isStdStream(w) {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// isStdStream tells whether w is os.Stdout or os.Stderr, which are never flushed nor closed
+func isStdStream(w io.Writer) bool {
+	return w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr)
+}
+
+// Sync flushes every writer buffering its output
+func (m *MultiWriter) Sync() error {
+	var errs writeErrors
+	for _, w := range m.writers {
+		if err := syncWriter(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.err()
+}
+
+// Close flushes then closes every writer, except os.Stdout and os.Stderr
+func (m *MultiWriter) Close() error {
+	var errs writeErrors
+	for _, w := range m.writers {
+		if err := closeWriter(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.err()
+}
+
+// Sync flushes every writer buffering its output
+func (r *LevelRouter) Sync() error {
+	var errs writeErrors
+	for _, w := range r.outputs() {
+		if err := syncWriter(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.err()
+}
+
+// Close flushes then closes every writer, except os.Stdout and os.Stderr
+func (r *LevelRouter) Close() error {
+	var errs writeErrors
+	for _, w := range r.outputs() {
+		if err := closeWriter(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.err()
+}
+
+// outputs returns the writers of the router, each only once
+func (r *LevelRouter) outputs() []io.Writer {
+	writers := []io.Writer{r.fallback}
+	for s := TRACE; s <= CRITICAL; s++ {
+		if w, ok := r.writers[s]; ok && !containsWriter(writers, w) {
+			writers = append(writers, w)
+		}
+	}
+	return writers
+}
+
+// containsWriter tells whether w is in writers, without comparing uncomparable values
+func containsWriter(writers []io.Writer, w io.Writer) bool {
+	if !reflect.TypeOf(w).Comparable() {
+		return false
+	}
+	for _, o := range writers {
+		if reflect.TypeOf(o) == reflect.TypeOf(w) && o == w {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closeBuffer is a bytes.Buffer recording whether it was closed
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestLogSyncFlushesBufferedWriters(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf, asyncBuf := new(bytes.Buffer), new(bytes.Buffer)
+	bw := bufio.NewWriter(buf)
+	async := NewAsyncWriter(asyncBuf, 16)
+	defer async.Close()
+
+	log := New().WithOutput(NewMultiWriter(bw, async))
+	log.Info("INFO message")
+	if err := log.Sync(); err != nil {
+		t.Fatalf("cannot sync: %s", err.Error())
+	}
+
+	if !strings.Contains(buf.String(), "INFO message") || !strings.Contains(asyncBuf.String(), "INFO message") {
+		t.Errorf("expected both writers to be flushed; got %q and %q", buf.String(), asyncBuf.String())
+	}
+}
+
+func TestLogSyncFlushesRepeatedEntries(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithDeduplication(time.Hour)).WithOutput(buf)
+	log.Info("INFO message")
+	log.Info("INFO message")
+	log.Sync()
+
+	if !strings.Contains(buf.String(), `"repeat_count":1`) {
+		t.Errorf("output %s does not contain the summary of the repeated entries", buf.String())
+	}
+}
+
+func TestLogClose(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	out, errOut := new(closeBuffer), new(closeBuffer)
+	async := NewAsyncWriter(out, 16)
+	log := New().WithOutput(NewLevelRouter(async).Route(ERROR, CRITICAL, errOut))
+	log.Info("INFO message")
+	if err := log.Close(); err != nil {
+		t.Fatalf("cannot close: %s", err.Error())
+	}
+
+	if !strings.Contains(out.String(), "INFO message") {
+		t.Errorf("output %s does not contain the buffered entry", out.String())
+	}
+	if !errOut.closed {
+		t.Errorf("expected the ERROR output to be closed")
+	}
+	if _, err := async.Write([]byte("entry")); err != ErrClosed {
+		t.Errorf("expected the async writer to be closed; got %v", err)
+	}
+}
+
+func TestLogCloseKeepsStdStreamsOpen(t *testing.T) {
+	if err := New().Close(); err != nil {
+		t.Errorf("cannot close a logger writing to stdout: %s", err.Error())
+	}
+}
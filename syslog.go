@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Syslog facilities, see RFC 5424 section 6.2.1. The zero facility of the writers is FacilityUser,
+// FacilityKern is a sentinel for the kernel facility, 0 in the messages
+const (
+	FacilityKern   = -1
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+	FacilityLocal1 = 17
+	FacilityLocal2 = 18
+	FacilityLocal3 = 19
+	FacilityLocal4 = 20
+	FacilityLocal5 = 21
+	FacilityLocal6 = 22
+	FacilityLocal7 = 23
+)
+
+// syslogSeverity maps the package severities to the syslog ones
+var syslogSeverity = map[severity]int{
+	TRACE:    7, // debug
+	DEBUG:    7, // debug
+	INFO:     6, // informational
+	WARN:     4, // warning
+	ERROR:    3, // error
+	CRITICAL: 2, // critical
+}
+
+// Paths where the local syslog daemon usually listens
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogWriter is an io.Writer sending each log entry as a syslog message, with the
+// priority derived from the entry severity. Remote servers ("tcp" or "udp" network)
+// receive RFC 5424 messages, octet-counted over TCP; the local daemon ("" network)
+// receives the traditional format over its unix socket.
+// It connects lazily on the first write and reconnects when a write fails.
+type SyslogWriter struct {
+	// Network is "tcp", "udp", "unix" or "unixgram". Empty connects to the local syslog daemon
+	Network string
+	// Addr is the address of the server, or the socket path for the local daemon
+	Addr string
+	// AppName identifies the application in the messages, the executable name by default
+	AppName string
+	// Facility is the syslog facility of the messages, FacilityUser by default
+	Facility int
+	// Hostname is sent in the remote messages, os.Hostname() by default
+	Hostname string
+	// TLSConfig encrypts the "tcp" connections when not nil, see NewTLSConfig
+	TLSConfig *tls.Config
+	// Preformatted sends the entries as is, without a syslog header, when they already are syslog
+	// messages such as the ones of RFC5424Encoder
+	Preformatted bool
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// WithSyslog sends the log entries to a syslog server. See SyslogWriter for the meaning of the arguments
+func WithSyslog(network, addr, appName string) Option {
+	return func(l *Log) {
+		l.writer = &SyslogWriter{
+			Network: network,
+			Addr:    addr,
+			AppName: appName,
+		}
+	}
+}
+
+// WithSyslogTLS sends the log entries to a syslog server over TCP, encrypted with the given
+// configuration, see NewTLSConfig
+func WithSyslogTLS(addr, appName string, cfg *tls.Config) Option {
+	return func(l *Log) {
+		l.writer = &SyslogWriter{
+			Network:   "tcp",
+			Addr:      addr,
+			AppName:   appName,
+			TLSConfig: cfg,
+		}
+	}
+}
+
+// Write sends p as a message with the INFO severity and the current time
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.writeLevel(INFO, time.Now(), p)
+}
+
+// writeLevel sends p as a message with the syslog priority matching the severity, stamped with
+// the time of the entry
+func (w *SyslogWriter) writeLevel(s severity, t time.Time, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	msg := w.format(s, t, bytes.TrimRight(p, "\n"))
+
+	// Retry once with a fresh connection, the server may have closed the previous one
+	var err error
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				return 0, err
+			}
+		}
+
+		if _, err = w.conn.Write(msg); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+// Close closes the connection to the server
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogFacility returns the facility sent in the messages for the one of a writer or encoder
+func syslogFacility(f int) int {
+	switch f {
+	case 0:
+		return FacilityUser
+	case FacilityKern:
+		return 0
+	}
+	return f
+}
+
+func (w *SyslogWriter) local() bool {
+	return w.Network == "" || w.Network == "unix" || w.Network == "unixgram"
+}
+
+func (w *SyslogWriter) connect() error {
+	if w.Network != "" {
+		conn, err := dial(w.Network, w.Addr, w.TLSConfig)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		return nil
+	}
+
+	paths := syslogLocalPaths
+	if w.Addr != "" {
+		paths = []string{w.Addr}
+	}
+	for _, path := range paths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				w.conn = conn
+				return nil
+			}
+		}
+	}
+	return errors.New("logger: cannot connect to the local syslog daemon")
+}
+
+// format builds the syslog message for an entry
+func (w *SyslogWriter) format(s severity, t time.Time, msg []byte) []byte {
+	pri := syslogFacility(w.Facility)*8 + syslogSeverity[s]
+
+	appName := w.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	buf := new(bytes.Buffer)
+	switch {
+	case w.Preformatted:
+		buf.Write(msg)
+	case w.local():
+		fmt.Fprintf(buf, "<%d>%s %s[%d]: ", pri, t.Format(time.Stamp), appName, os.Getpid())
+		buf.Write(msg)
+		return buf.Bytes()
+	default:
+		hostname := w.Hostname
+		if hostname == "" {
+			hostname, _ = os.Hostname()
+		}
+		if hostname == "" {
+			hostname = "-"
+		}
+
+		fmt.Fprintf(buf, "<%d>1 %s %s %s %d - - ", pri, t.Format(time.RFC3339Nano), hostname, appName, os.Getpid())
+		buf.Write(msg)
+	}
+
+	// Stream transports need the message length to be able to split them, see RFC 6587
+	if w.Network == "tcp" || w.Network == "tcp4" || w.Network == "tcp6" {
+		return append([]byte(strconv.Itoa(buf.Len())+" "), buf.Bytes()...)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogWriterUDP(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err.Error())
+	}
+	defer conn.Close()
+
+	w := &SyslogWriter{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		AppName:  "my-app",
+		Facility: FacilityLocal0,
+		Hostname: "my-host",
+	}
+	defer w.Close()
+
+	log := New().WithOutput(w)
+	log.Error("ERROR message")
+
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("cannot read the message: %s", err.Error())
+	}
+
+	got := string(buf[:n])
+	expected := regexp.MustCompile(`^<131>1 \S+ my-host my-app \d+ - - \{"severity":"ERROR",.*"message":"ERROR message"`)
+	if !expected.MatchString(got) {
+		t.Errorf("message %s does not match %s", got, expected)
+	}
+	if strings.HasSuffix(got, "\n") {
+		t.Errorf("message %q contains the trailing newline", got)
+	}
+}
+
+func TestSyslogWriterEntryTimeAndKernFacility(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err.Error())
+	}
+	defer conn.Close()
+
+	w := &SyslogWriter{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		AppName:  "my-app",
+		Facility: FacilityKern,
+		Hostname: "my-host",
+	}
+	defer w.Close()
+
+	// Through a wrapper, the header still has the time of the entry
+	log := New(WithWriter(NewTimeoutWriter(w, time.Second, nil)), WithClock(testClock))
+	log.Error("ERROR message")
+
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("cannot read the message: %s", err.Error())
+	}
+
+	got := string(buf[:n])
+	if prefix := "<3>1 " + testTime.Format(time.RFC3339Nano) + " my-host "; !strings.HasPrefix(got, prefix) {
+		t.Errorf("message %s does not start with %s", got, prefix)
+	}
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err.Error())
+	}
+	defer ln.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for i := 0; i < 2; i++ {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	log := New(WithSyslog("tcp", ln.Addr().String(), "my-app"))
+	log.Debug("DEBUG message")
+	log.Warn("WARN message")
+
+	// Octet counting lets the server split the stream into the original messages
+	if got := <-received; !strings.HasPrefix(got, "<15>1 ") || !strings.HasSuffix(got, `"message":"DEBUG message","serviceContext":{"service":"my-app","version":"1.0"}}`) {
+		t.Errorf("unexpected DEBUG message %s", got)
+	}
+	if got := <-received; !strings.HasPrefix(got, "<12>1 ") {
+		t.Errorf("unexpected WARN message %s", got)
+	}
+}
+
+func TestSyslogWriterLocal(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix sockets not supported: %s", err.Error())
+	}
+	defer conn.Close()
+
+	log := New(WithSyslog("", path, "my-app"))
+	log.Info("INFO message")
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("cannot read the message: %s", err.Error())
+	}
+
+	got := string(buf[:n])
+	expected := regexp.MustCompile(`^<14>\w{3} [ \d]\d \d{2}:\d{2}:\d{2} my-app\[\d+\]: \{"severity":"INFO"`)
+	if !expected.MatchString(got) {
+		t.Errorf("message %s does not match %s", got, expected)
+	}
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrWriteTimeout is returned when a write does not complete within the timeout of a TimeoutWriter
+var ErrWriteTimeout = errors.New("logger: write timed out")
+
+// TimeoutWriter is an io.Writer bounding the time spent writing to a slow writer, such as a hung
+// TCP sink or a full pipe. When a write times out, the entry is written to the fallback writer
+// and ErrWriteTimeout is passed to the OnError function. The writes keep going to the fallback
+// until the one that timed out returns.
+type TimeoutWriter struct {
+	w        io.Writer
+	fallback io.Writer
+	timeout  time.Duration
+
+	mu sync.Mutex
+	// pending is closed when the write that timed out returns, nil when there is none
+	pending chan struct{}
+}
+
+// NewTimeoutWriter returns a TimeoutWriter writing to w, or to fallback when a write takes longer
+// than timeout. When fallback is nil, the entries are dropped instead
+func NewTimeoutWriter(w io.Writer, timeout time.Duration, fallback io.Writer) *TimeoutWriter {
+	return &TimeoutWriter{
+		w:        w,
+		fallback: fallback,
+		timeout:  timeout,
+	}
+}
+
+// WithWriteTimeout writes the log entries through a TimeoutWriter wrapping the current output, so
+// it must come after the option setting the output
+func WithWriteTimeout(timeout time.Duration, fallback io.Writer) Option {
+	return func(l *Log) {
+		l.writer = NewTimeoutWriter(l.writer, timeout, fallback)
+	}
+}
+
+// Write writes p to the writer, or to the fallback when it does not return within the timeout
+func (t *TimeoutWriter) Write(p []byte) (int, error) {
+	return t.write(p, func(w io.Writer, p []byte) (int, error) { return w.Write(p) })
+}
+
+// writeLevel is Write passing the severity and time along to the writers that use them
+func (t *TimeoutWriter) writeLevel(s severity, at time.Time, p []byte) (int, error) {
+	return t.write(p, func(w io.Writer, p []byte) (int, error) {
+		if lw, ok := w.(levelWriter); ok {
+			return lw.writeLevel(s, at, p)
+		}
+		return w.Write(p)
+	})
+}
+
+func (t *TimeoutWriter) write(p []byte, write func(w io.Writer, p []byte) (int, error)) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending != nil {
+		select {
+		case <-t.pending:
+			t.pending = nil
+		default:
+			return t.timedOut(p, write)
+		}
+	}
+
+	// The writer may still use the entry after Write returned
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	done := make(chan error, 1)
+	pending := make(chan struct{})
+	go func() {
+		_, err := write(t.w, data)
+		done <- err
+		close(pending)
+	}()
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	case <-timer.C:
+		t.pending = pending
+		return t.timedOut(p, write)
+	}
+}
+
+// timedOut writes p to the fallback, the lock must be held
+func (t *TimeoutWriter) timedOut(p []byte, write func(w io.Writer, p []byte) (int, error)) (int, error) {
+	if t.fallback == nil {
+		return 0, ErrWriteTimeout
+	}
+
+	reportError(ErrWriteTimeout)
+	return write(t.fallback, p)
+}
+
+// Sync flushes both writers, unless a write timed out and has not returned yet
+func (t *TimeoutWriter) Sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var err error
+	if t.pending == nil {
+		err = syncWriter(t.w)
+	}
+	if t.fallback != nil {
+		if ferr := syncWriter(t.fallback); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// Close flushes then closes both writers, except os.Stdout and os.Stderr
+func (t *TimeoutWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := closeWriter(t.w)
+	if t.fallback != nil {
+		if ferr := closeWriter(t.fallback); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
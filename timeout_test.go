@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutWriter(t *testing.T) {
+	rec := &errorRecorder{}
+	OnError(rec.record)
+	defer OnError(nil)
+
+	slow := &blockingWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	fallback := new(bytes.Buffer)
+	w := NewTimeoutWriter(slow, 10*time.Millisecond, fallback)
+
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Errorf("expected the fallback to succeed; got %s", err.Error())
+	}
+	// The first write is still pending, the second one goes to the fallback right away
+	w.Write([]byte("second\n"))
+
+	if fallback.String() != "first\nsecond\n" {
+		t.Errorf("unexpected fallback output %q", fallback.String())
+	}
+	if errs := rec.get(); len(errs) != 2 || errs[0] != ErrWriteTimeout.Error() {
+		t.Errorf("expected the timeout errors; got %v", errs)
+	}
+
+	// Once the pending write returns, the writer is used again
+	close(slow.release)
+	w.mu.Lock()
+	pending := w.pending
+	w.mu.Unlock()
+	<-pending
+
+	w.Write([]byte("third\n"))
+	if slow.String() != "first\nthird\n" {
+		t.Errorf("unexpected output %q", slow.String())
+	}
+}
+
+func TestTimeoutWriterWithoutFallback(t *testing.T) {
+	slow := &blockingWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(slow.release)
+
+	w := NewTimeoutWriter(slow, time.Millisecond, nil)
+	if _, err := w.Write([]byte("entry\n")); err != ErrWriteTimeout {
+		t.Errorf("expected ErrWriteTimeout; got %v", err)
+	}
+}
+
+func TestLoggerWithWriteTimeout(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf, fallback := new(bytes.Buffer), new(bytes.Buffer)
+	log := New(WithWriter(buf), WithWriteTimeout(time.Second, fallback))
+	log.Info("INFO message")
+
+	if !strings.Contains(buf.String(), `"message":"INFO message"`) || fallback.Len() != 0 {
+		t.Errorf("unexpected outputs %s and %s", buf.String(), fallback.String())
+	}
+}
+
+func TestTimeoutWriterRoutesLevels(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	out, errb := new(bytes.Buffer), new(bytes.Buffer)
+	log := New(WithWriter(NewTimeoutWriter(NewLevelRouter(out).Route(ERROR, CRITICAL, errb), time.Second, nil)))
+	log.Info("INFO message")
+	log.Error("ERROR message")
+
+	if !strings.Contains(out.String(), "INFO message") || strings.Contains(out.String(), "ERROR message") {
+		t.Errorf("unexpected output %s", out.String())
+	}
+	if !strings.Contains(errb.String(), "ERROR message") {
+		t.Errorf("the ERROR entry was not routed, error output %q", errb.String())
+	}
+}
@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"time"
+)
+
+// WithClock sets the function returning the time of the entries, time.Now by default. A fixed
+// clock makes the output deterministic, e.g. in tests
+func WithClock(now func() time.Time) Option {
+	return func(l *Log) {
+		l.clock = now
+	}
+}
+
+// WithTimeFormat sets the layout of the entries eventTime, as accepted by time.Time.Format.
+// The default is time.RFC3339Nano, so the entries logged within the same second can be ordered
+func WithTimeFormat(layout string) Option {
+	return func(l *Log) {
+		l.timeFormat = layout
+	}
+}
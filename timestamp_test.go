@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// testTime is the time of the entries of the loggers using testClock
+var testTime = time.Date(2017, 4, 26, 2, 29, 33, 412587367, time.FixedZone("EDT", -4*60*60))
+
+func testClock() time.Time {
+	return testTime
+}
+
+func TestWithClock(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithClock(testClock)).WithOutput(buf).Info("INFO message")
+
+	expected := `{"severity":"INFO","eventTime":"2017-04-26T02:29:33.412587367-04:00","message":"INFO message","serviceContext":{"service":"my-app","version":"1.0"},"context":{}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("output %s does not match expected string %s", buf.String(), expected)
+	}
+}
+
+func TestDefaultTimeFormatIsNano(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New().WithOutput(buf).Info("INFO message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+
+	eventTime, err := time.Parse(time.RFC3339Nano, p.EventTime)
+	if err != nil {
+		t.Fatalf("eventTime %s is not RFC3339: %s", p.EventTime, err.Error())
+	}
+	if p.EventTime != eventTime.Format(time.RFC3339Nano) || time.Since(eventTime) > time.Minute {
+		t.Errorf("unexpected eventTime %s", p.EventTime)
+	}
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithTimeFormat(time.Kitchen)).WithOutput(buf).Info("INFO message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if _, err := time.Parse(time.Kitchen, p.EventTime); err != nil {
+		t.Errorf("eventTime %s does not have the given format: %s", p.EventTime, err.Error())
+	}
+}
+
+func TestTimeFormatEscaped(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	New(WithClock(testClock), WithTimeFormat(`"2006" <15>`)).WithOutput(buf).Info("INFO message")
+
+	expected := `"eventTime":"\"2017\" \u003c02\u003e"`
+	if !bytes.Contains(buf.Bytes(), []byte(expected)) {
+		t.Errorf("output %s does not contain %s", buf.String(), expected)
+	}
+}
+
+func TestHookSeesEventTime(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	log := New(WithClock(testClock)).WithOutput(new(bytes.Buffer))
+
+	var seen string
+	log.AddHook(HookFunc(func(p *Payload) error {
+		seen = p.EventTime
+		return nil
+	}))
+	log.Info("INFO message")
+
+	if expected := testTime.Format(time.RFC3339Nano); seen != expected {
+		t.Errorf("hook received the event time %q; expected %q", seen, expected)
+	}
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+)
+
+// NewTLSConfig returns the TLS configuration of a network sink, verifying the server with the CA
+// certificates of the PEM file caFile, or with the system pool when empty, and presenting the
+// client certificate of certFile and keyFile for mutual TLS when not empty
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("logger: no CA certificate found in %s", caFile)
+		}
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// dial connects to a network sink, over TLS when cfg is not nil
+func dial(network, addr string, cfg *tls.Config) (net.Conn, error) {
+	if cfg == nil {
+		return net.Dial(network, addr)
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return tls.Dial(network, addr, cfg)
+	}
+	return nil, errors.New("logger: TLS is only supported over TCP, not " + network)
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1, valid for the servers and
+// the clients, and its key to dir
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate a key: %s", err.Error())
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "logger test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cannot create the certificate: %s", err.Error())
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot marshal the key: %s", err.Error())
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+// listenTLS starts a TLS server requiring a client certificate signed by the test certificate,
+// and returns its first line, up to delim
+func listenTLS(t *testing.T, certFile, keyFile string, delim byte) (net.Listener, chan string) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("cannot load the certificate: %s", err.Error())
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("cannot parse the certificate: %s", err.Error())
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err.Error())
+	}
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, err := bufio.NewReader(conn).ReadString(delim)
+		if err != nil {
+			received <- "error: " + err.Error()
+			return
+		}
+		received <- line
+	}()
+	return ln, received
+}
+
+func TestSyslogWriterTLS(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+	ln, received := listenTLS(t, certFile, keyFile, '}')
+	defer ln.Close()
+
+	cfg, err := NewTLSConfig(certFile, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	log := New(WithSyslogTLS(ln.Addr().String(), "my-app", cfg))
+	defer log.Close()
+	log.Info("INFO message")
+
+	if got := <-received; !strings.Contains(got, `<14>1 `) || !strings.Contains(got, `"message":"INFO message"`) {
+		t.Errorf("unexpected message %s", got)
+	}
+}
+
+func TestGELFWriterTLS(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+	ln, received := listenTLS(t, certFile, keyFile, 0)
+	defer ln.Close()
+
+	cfg, err := NewTLSConfig(certFile, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	log := New(WithGELFTLS(ln.Addr().String(), cfg))
+	defer log.Close()
+	log.Info("INFO message")
+
+	if got := <-received; !strings.Contains(got, `"short_message":"INFO message"`) || !strings.HasSuffix(got, "\x00") {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(ca, []byte("not a certificate"), 0600)
+
+	if _, err := NewTLSConfig(ca, "", ""); err == nil {
+		t.Errorf("expected an error for an invalid CA file")
+	}
+	if _, err := NewTLSConfig("", filepath.Join(dir, "missing.pem"), filepath.Join(dir, "missing.key")); err == nil {
+		t.Errorf("expected an error for a missing client certificate")
+	}
+
+	w := &GELFWriter{Network: "udp", Addr: "127.0.0.1:12201", TLSConfig: &tls.Config{}}
+	if _, err := w.Write([]byte("{}")); err == nil || !strings.Contains(err.Error(), "only supported over TCP") {
+		t.Errorf("expected an error for TLS over UDP; got %v", err)
+	}
+}
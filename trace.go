@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WithTrace creates a copy of a Log whose entries are correlated with the given Cloud Trace
+// trace and span. The trace ID is qualified with the project from the GOOGLE_CLOUD_PROJECT
+// environment variable, or the one detected by WithGCPDetection. It is left bare without a project
+func (l *Log) WithTrace(traceID, spanID string, sampled bool) *Log {
+	n := l.With(Fields{})
+	n.payload.Trace = traceName(traceID, n.payload.Labels["project_id"])
+	n.payload.SpanID = spanID
+	n.payload.TraceSampled = sampled
+	return n
+}
+
+// WithTraceFromRequest creates a copy of a Log correlated with the trace of an incoming request,
+// read from the X-Cloud-Trace-Context header or the W3C traceparent header. The Log is
+// returned unchanged when the request carries no valid trace
+func (l *Log) WithTraceFromRequest(r *http.Request) *Log {
+	if traceID, spanID, sampled, ok := parseCloudTraceContext(r.Header.Get("X-Cloud-Trace-Context")); ok {
+		return l.WithTrace(traceID, spanID, sampled)
+	}
+	return l.WithTraceparent(r.Header.Get("traceparent"), r.Header.Get("tracestate"))
+}
+
+// TraceContext is the W3C Trace Context of a request, see https://www.w3.org/TR/trace-context/
+type TraceContext struct {
+	// TraceID is the 32 hex digits ID of the trace
+	TraceID string
+	// SpanID is the 16 hex digits ID of the parent span
+	SpanID string
+	// Sampled is the sampled flag of the trace
+	Sampled bool
+	// TraceState is the vendor specific data of the tracestate header, kept as is
+	TraceState string
+}
+
+// ParseTraceparent parses the W3C traceparent and tracestate headers, e.g. for tracing setups
+// other than Cloud Trace. It reports whether traceparent is valid
+func ParseTraceparent(traceparent, tracestate string) (TraceContext, bool) {
+	traceID, spanID, sampled, ok := parseTraceparent(traceparent)
+	if !ok {
+		return TraceContext{}, false
+	}
+	return TraceContext{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		Sampled:    sampled,
+		TraceState: strings.TrimSpace(tracestate),
+	}, true
+}
+
+// WithTraceparent creates a copy of a Log whose entries are correlated with the trace of the W3C
+// traceparent header, the tracestate header being added to their context data as "tracestate"
+// when not empty. The Log is returned unchanged when traceparent is not valid
+func (l *Log) WithTraceparent(traceparent, tracestate string) *Log {
+	tc, ok := ParseTraceparent(traceparent, tracestate)
+	if !ok {
+		return l
+	}
+
+	n := l.WithTrace(tc.TraceID, tc.SpanID, tc.Sampled)
+	if tc.TraceState != "" {
+		n = n.With(Fields{"tracestate": tc.TraceState})
+	}
+	return n
+}
+
+// traceName returns the fully qualified trace name expected by Cloud Logging, detected being the
+// project found by WithGCPDetection
+func traceName(traceID, detected string) string {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		project = detected
+	}
+	if project == "" || traceID == "" {
+		return traceID
+	}
+	return fmt.Sprintf("projects/%s/traces/%s", project, traceID)
+}
+
+// parseCloudTraceContext parses a X-Cloud-Trace-Context header: TRACE_ID/SPAN_ID;o=OPTIONS.
+// The span ID is a decimal number in the header and is returned as 16 hex digits
+func parseCloudTraceContext(header string) (traceID, spanID string, sampled, ok bool) {
+	if header == "" {
+		return "", "", false, false
+	}
+
+	header, options := splitOnce(header, ";")
+	traceID, span := splitOnce(header, "/")
+	traceID = strings.ToLower(traceID)
+	if !isHex(traceID, 32) {
+		return "", "", false, false
+	}
+
+	if span != "" {
+		id, err := strconv.ParseUint(span, 10, 64)
+		if err != nil {
+			return "", "", false, false
+		}
+		spanID = fmt.Sprintf("%016x", id)
+	}
+
+	return traceID, spanID, options == "o=1", true
+}
+
+// parseTraceparent parses a W3C traceparent header: VERSION-TRACE_ID-PARENT_ID-FLAGS
+func parseTraceparent(header string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", "", false, false
+	}
+	// Version 00 has exactly four fields, future versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false, false
+	}
+
+	flags, _ := strconv.ParseUint(parts[3], 16, 8)
+	return parts[1], parts[2], flags&1 == 1, true
+}
+
+func splitOnce(s, sep string) (string, string) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):]
+	}
+	return s, ""
+}
+
+// isHex checks whether s is made of exactly n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoggerWithTraceFromRequest(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+
+	buf := new(bytes.Buffer)
+	log := New().WithTraceFromRequest(req).With(Fields{"key": "value"}).WithOutput(buf)
+
+	log.Info("INFO message")
+	expected := `"logging.googleapis.com/trace":"projects/my-project/traces/105445aa7843bc8bf206b12000100000","logging.googleapis.com/spanId":"0000000000000001","logging.googleapis.com/trace_sampled":true}`
+	if !strings.HasSuffix(strings.TrimRight(buf.String(), "\n"), expected) {
+		t.Errorf("output %s does not contain the trace fields %s", buf.String(), expected)
+	}
+
+	// Errors are correlated as well
+	buf.Reset()
+	log.Error("ERROR message")
+	if !strings.Contains(buf.String(), `"logging.googleapis.com/trace":"projects/my-project/traces/105445aa7843bc8bf206b12000100000"`) {
+		t.Errorf("output %s does not contain the trace", buf.String())
+	}
+}
+
+func TestLoggerWithTraceFromTraceparent(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	buf := new(bytes.Buffer)
+	New().WithTraceFromRequest(req).WithOutput(buf).Info("INFO message")
+
+	expected := `"logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7"}`
+	if !strings.HasSuffix(strings.TrimRight(buf.String(), "\n"), expected) {
+		t.Errorf("output %s does not contain the trace fields %s", buf.String(), expected)
+	}
+}
+
+func TestLoggerWithoutTrace(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+
+	log := New()
+	if log.WithTraceFromRequest(httptest.NewRequest("GET", "/", nil)) != log {
+		t.Errorf("expected the same logger for a request without trace")
+	}
+}
+
+func TestLoggerWithTraceProject(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "")
+
+	// The service is not a project, the trace is left bare
+	log := New().WithTrace("105445aa7843bc8bf206b12000100000", "", false)
+	if log.payload.Trace != "105445aa7843bc8bf206b12000100000" {
+		t.Errorf("unexpected trace %s without a project", log.payload.Trace)
+	}
+
+	// The project detected by WithGCPDetection
+	log = New().WithLabels(map[string]string{"project_id": "detected"}).WithTrace("105445aa7843bc8bf206b12000100000", "", false)
+	if log.payload.Trace != "projects/detected/traces/105445aa7843bc8bf206b12000100000" {
+		t.Errorf("unexpected trace %s with a detected project", log.payload.Trace)
+	}
+}
+
+func TestParseCloudTraceContext(t *testing.T) {
+	tests := []struct {
+		header  string
+		traceID string
+		spanID  string
+		sampled bool
+		ok      bool
+	}{
+		{"105445aa7843bc8bf206b12000100000/1;o=1", "105445aa7843bc8bf206b12000100000", "0000000000000001", true, true},
+		{"105445AA7843BC8BF206B12000100000/255;o=0", "105445aa7843bc8bf206b12000100000", "00000000000000ff", false, true},
+		{"105445aa7843bc8bf206b12000100000", "105445aa7843bc8bf206b12000100000", "", false, true},
+		{"105445aa7843bc8bf206b12000100000/abc", "", "", false, false},
+		{"not-a-trace/1", "", "", false, false},
+		{"", "", "", false, false},
+	}
+
+	for _, test := range tests {
+		traceID, spanID, sampled, ok := parseCloudTraceContext(test.header)
+		if traceID != test.traceID || spanID != test.spanID || sampled != test.sampled || ok != test.ok {
+			t.Errorf("%q: got %q %q %v %v", test.header, traceID, spanID, sampled, ok)
+		}
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header  string
+		sampled bool
+		ok      bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false, true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+	}
+
+	for _, test := range tests {
+		_, _, sampled, ok := parseTraceparent(test.header)
+		if sampled != test.sampled || ok != test.ok {
+			t.Errorf("%q: got %v %v", test.header, sampled, ok)
+		}
+	}
+}
+
+func TestParseTraceparentWithTracestate(t *testing.T) {
+	tc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", " congo=t61rcWkgMzE,rojo=00f067aa0ba902b7 ")
+	expected := TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7",
+	}
+	if !ok || tc != expected {
+		t.Errorf("expected %+v; got %+v", expected, tc)
+	}
+
+	if _, ok := ParseTraceparent("invalid", "congo=t61rcWkgMzE"); ok {
+		t.Errorf("expected an invalid traceparent to be rejected")
+	}
+}
+
+func TestLoggerWithTraceparent(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	defer os.Setenv("GOOGLE_CLOUD_PROJECT", os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "congo=t61rcWkgMzE")
+
+	buf := new(bytes.Buffer)
+	New(WithWriter(buf)).WithTraceFromRequest(req).Info("INFO message")
+
+	got := buf.String()
+	if !strings.Contains(got, `"context":{"data":{"tracestate":"congo=t61rcWkgMzE"}}`) ||
+		!strings.Contains(got, `"logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true`) {
+		t.Errorf("output %s does not contain the trace context", got)
+	}
+
+	log := New()
+	if log.WithTraceparent("", "congo=t61rcWkgMzE") != log {
+		t.Errorf("expected the same logger without a traceparent")
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import "unicode/utf8"
+
+// Transformer modifies the entries before they are encoded, e.g. to enforce the field naming
+// conventions of an organization in one place. It runs after the filters and before the hooks,
+// on a copy of the context that it can modify; it must not keep a reference to the payload
+type Transformer func(p *Payload)
+
+// AddTransformer registers a transformer run on every entry, in the order they were added.
+// The transformers are shared with the loggers derived from l, and with the one l derives from
+func (l *Log) AddTransformer(t Transformer) {
+	l.hooks.mu.Lock()
+	defer l.hooks.mu.Unlock()
+	l.hooks.transformers = append(l.hooks.transformers, t)
+}
+
+// transform runs the transformers on the payload
+func (hs *hookSet) transform(p *Payload) {
+	hs.mu.RLock()
+	transformers := hs.transformers
+	hs.mu.RUnlock()
+
+	if len(transformers) == 0 {
+		return
+	}
+
+	p.formatEventTime()
+	copyContext(p)
+	for _, t := range transformers {
+		t(p)
+	}
+}
+
+// RenameField returns a Transformer renaming the context field from to to, e.g. "userId" to "user_id".
+// A field already named to is overwritten
+func RenameField(from, to string) Transformer {
+	return func(p *Payload) {
+		if p.Context == nil {
+			return
+		}
+		if v, ok := p.Context.Data[from]; ok {
+			delete(p.Context.Data, from)
+			p.Context.Data[to] = v
+		}
+	}
+}
+
+// ComputeField returns a Transformer setting the context field key to the value computed from the
+// entry, e.g. the region of the host. A nil value leaves the entry unchanged
+func ComputeField(key string, fn func(p *Payload) interface{}) Transformer {
+	return func(p *Payload) {
+		v := fn(p)
+		if v == nil {
+			return
+		}
+		if p.Context == nil {
+			p.Context = &Context{}
+		}
+		if p.Context.Data == nil {
+			p.Context.Data = make(Fields)
+		}
+		p.Context.Data[key] = v
+	}
+}
+
+// TruncateFields returns a Transformer cutting the string context fields longer than max bytes,
+// on a character boundary, and marking them with a trailing "..."
+func TruncateFields(max int) Transformer {
+	return func(p *Payload) {
+		if p.Context == nil {
+			return
+		}
+		for k, v := range p.Context.Data {
+			if s, ok := v.(string); ok && len(s) > max {
+				p.Context.Data[k] = truncateString(s, max) + "..."
+			}
+		}
+	}
+}
+
+// truncateString returns the longest prefix of s of at most max bytes not splitting a character
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
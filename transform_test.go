@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf))
+	log.AddTransformer(RenameField("userId", "user_id"))
+	log.AddTransformer(ComputeField("region", func(p *Payload) interface{} { return "us-east1" }))
+	log.AddTransformer(TruncateFields(8))
+
+	ctx := log.With(Fields{"userId": "+1234567890", "name": "Zoë"}).WithOutput(buf)
+	ctx.Info("INFO message")
+	log.Info("no context")
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries; got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], `"context":{"data":{"name":"Zoë","region":"us-east1","user_id":"+1234567..."}}`) {
+		t.Errorf("unexpected entry %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"context":{"data":{"region":"us-east1"}}`) {
+		t.Errorf("unexpected entry %s", lines[1])
+	}
+
+	// The logger context itself is not modified
+	if _, ok := ctx.fields()["userId"]; !ok {
+		t.Errorf("the transformer modified the logger context")
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s        string
+		max      int
+		expected string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"Zoë!", 3, "Zo"},
+		{"Zoë!", 4, "Zoë"},
+	}
+	for _, test := range tests {
+		if got := truncateString(test.s, test.max); got != test.expected {
+			t.Errorf("truncateString(%q, %d): expected %q; got %q", test.s, test.max, test.expected, got)
+		}
+	}
+}
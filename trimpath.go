@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// WithTrimPaths replaces the absolute build paths of the report and source locations with paths
+// relative to the module of the program, e.g. "internal/db/store.go" instead of
+// "/home/ci/workspace/app/internal/db/store.go", so the locations are short and do not depend on
+// the build machine. The files of the other modules are prefixed with their import path, as with
+// the -trimpath build flag, e.g. "github.com/lib/pq/conn.go"
+func WithTrimPaths() Option {
+	return func(l *Log) {
+		l.trimPaths = true
+	}
+}
+
+var (
+	buildInfoOnce sync.Once
+	// mainModule is the path of the module of the program, mainPackage the import path of its main package
+	mainModule, mainPackage string
+)
+
+// readBuildInfo reads the module and the main package of the program from its build information
+func readBuildInfo() {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		mainModule, mainPackage = info.Main.Path, info.Path
+	}
+}
+
+// trimPath returns the path of a file relative to the module of the program, or prefixed with the
+// import path of its package, derived from the name of a function of the file
+func trimPath(file, function string) string {
+	buildInfoOnce.Do(readBuildInfo)
+
+	pkg := packagePath(function)
+	if pkg == "main" {
+		pkg = mainPackage
+	}
+	if pkg == "" || pkg == "main" {
+		// Unknown package, keep the parent directory only
+		dir, name := filepath.Split(file)
+		return path.Join(filepath.Base(dir), name)
+	}
+
+	trimmed := path.Join(pkg, filepath.Base(file))
+	if mainModule != "" && strings.HasPrefix(trimmed, mainModule+"/") {
+		return trimmed[len(mainModule)+1:]
+	}
+	return trimmed
+}
+
+// packagePath returns the import path of the package of a function as named by runtime.Func,
+// e.g. "github.com/teltech/logger" for "github.com/teltech/logger.(*Log).Info"
+func packagePath(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+1+dot]
+}
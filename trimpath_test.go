@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// withBuildInfo sets the module and the main package of the program for a test
+func withBuildInfo(t *testing.T, module, pkg string) {
+	buildInfoOnce.Do(readBuildInfo)
+	prevModule, prevPackage := mainModule, mainPackage
+	mainModule, mainPackage = module, pkg
+	t.Cleanup(func() {
+		mainModule, mainPackage = prevModule, prevPackage
+	})
+}
+
+func TestTrimPath(t *testing.T) {
+	withBuildInfo(t, "github.com/acme/app", "github.com/acme/app/cmd/server")
+
+	tests := []struct {
+		file     string
+		function string
+		expected string
+	}{
+		{"/ci/workspace/app/internal/db/store.go", "github.com/acme/app/internal/db.(*Store).Get", "internal/db/store.go"},
+		{"/ci/workspace/app/cmd/server/main.go", "main.main", "cmd/server/main.go"},
+		{"/ci/workspace/app/cmd/server/main.go", "main.run.func1", "cmd/server/main.go"},
+		{"/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go", "github.com/lib/pq.(*conn).query", "github.com/lib/pq/conn.go"},
+		{"/usr/local/go/src/net/http/server.go", "net/http.(*conn).serve", "net/http/server.go"},
+		{"/usr/local/go/src/strings/strings.go", "strings.Index", "strings/strings.go"},
+		{"/src/unknown/file.go", "unknown", "unknown/file.go"},
+	}
+	for _, test := range tests {
+		if got := trimPath(test.file, test.function); got != test.expected {
+			t.Errorf("%s: expected %s; got %s", test.file, test.expected, got)
+		}
+	}
+}
+
+func TestLoggerWithTrimPaths(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+	withBuildInfo(t, "github.com/teltech", "")
+
+	buf := new(bytes.Buffer)
+	log := New(WithWriter(buf), WithTrimPaths(), AddCaller(0))
+	log.Error("ERROR message")
+
+	p := Payload{}
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %s", err.Error())
+	}
+	if p.Context.ReportLocation.FilePath != "logger/trimpath_test.go" {
+		t.Errorf("unexpected report location %s", p.Context.ReportLocation.FilePath)
+	}
+	if p.SourceLocation == nil || p.SourceLocation.File != "logger/trimpath_test.go" {
+		t.Errorf("unexpected source location %+v", p.SourceLocation)
+	}
+}
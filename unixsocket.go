@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultReconnectDelay is the minimum time between two connection attempts of a UnixSocketWriter
+const defaultReconnectDelay = time.Second
+
+// WithUnixSocket sends the log entries to the unix domain stream socket at path, e.g. the one of
+// a host local log forwarder. See UnixSocketWriter
+func WithUnixSocket(path string) Option {
+	return func(l *Log) {
+		l.writer = &UnixSocketWriter{Path: path}
+	}
+}
+
+// UnixSocketWriter is an io.Writer sending the entries to a unix domain socket, one per line over
+// a stream socket or one per datagram. It connects lazily on the first write and reconnects when
+// a write fails, e.g. when the forwarder restarts. After a failed connection attempt, the writes
+// fail without trying again for ReconnectDelay, so a forwarder being down does not slow down
+// every write; the entries can be kept meanwhile with WithRetry or WithFallback
+type UnixSocketWriter struct {
+	// Path is the path of the socket
+	Path string
+	// Network is "unix" for a stream socket, the default, or "unixgram" for a datagram socket
+	Network string
+	// ReconnectDelay is the minimum time between two connection attempts, one second by default
+	ReconnectDelay time.Duration
+	// WriteTimeout is the time limit of a write, none when zero
+	WriteTimeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	// dialed is the time of the last failed connection attempt, and dialErr its error
+	dialed  time.Time
+	dialErr error
+}
+
+// Write sends p to the socket, reconnecting once when the connection is broken. p is sent again
+// in full over the new connection: the part of p a broken connection took is lost with it, the
+// forwarder seeing at most a truncated last line on the stream that was closed
+func (w *UnixSocketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				return 0, err
+			}
+		}
+
+		if w.WriteTimeout > 0 {
+			w.conn.SetWriteDeadline(time.Now().Add(w.WriteTimeout))
+		}
+		if _, err = w.conn.Write(p); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+// Close closes the connection to the socket
+func (w *UnixSocketWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// connect connects to the socket, unless the previous attempt failed less than ReconnectDelay ago
+func (w *UnixSocketWriter) connect() error {
+	delay := w.ReconnectDelay
+	if delay <= 0 {
+		delay = defaultReconnectDelay
+	}
+	if w.dialErr != nil && time.Since(w.dialed) < delay {
+		return w.dialErr
+	}
+
+	network := w.Network
+	if network == "" {
+		network = "unix"
+	}
+	conn, err := net.Dial(network, w.Path)
+	if err != nil {
+		w.dialed = time.Now()
+		w.dialErr = fmt.Errorf("logger: cannot connect to %s: %w", w.Path, err)
+		return w.dialErr
+	}
+	w.conn = conn
+	w.dialErr = nil
+	return nil
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unixServer accepts the connections to a unix socket, sending the lines received to a channel
+type unixServer struct {
+	ln    net.Listener
+	lines chan string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func listenUnix(t *testing.T, path string) *unixServer {
+	t.Helper()
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("cannot listen on a unix socket: %s", err.Error())
+	}
+
+	s := &unixServer{ln: ln, lines: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+
+			go func() {
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					s.lines <- sc.Text()
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// Close stops the server, closing the connections
+func (s *unixServer) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func receive(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line received")
+		return ""
+	}
+}
+
+func TestUnixSocketWriter(t *testing.T) {
+	initConfig(DEBUG, "my-app", "1.0")
+
+	path := filepath.Join(t.TempDir(), "forwarder.sock")
+	srv := listenUnix(t, path)
+	defer srv.Close()
+
+	log := New(WithUnixSocket(path))
+	defer log.Close()
+	log.Info("first message")
+	log.Warn("second message")
+
+	if got := receive(t, srv.lines); !strings.Contains(got, `"message":"first message"`) {
+		t.Errorf("unexpected line %s", got)
+	}
+	if got := receive(t, srv.lines); !strings.Contains(got, `"message":"second message"`) {
+		t.Errorf("unexpected line %s", got)
+	}
+}
+
+// partialConn is a broken connection taking the first bytes of a write only
+type partialConn struct {
+	net.Conn
+	written []byte
+}
+
+func (c *partialConn) Write(p []byte) (int, error) {
+	c.written = append(c.written, p[:3]...)
+	return 3, errors.New("broken pipe")
+}
+
+func (c *partialConn) Close() error {
+	return nil
+}
+
+func TestUnixSocketWriterPartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forwarder.sock")
+	srv := listenUnix(t, path)
+	defer srv.Close()
+
+	broken := &partialConn{}
+	w := &UnixSocketWriter{Path: path, conn: broken}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("entry\n")); err != nil {
+		t.Fatalf("cannot write after reconnecting: %s", err.Error())
+	}
+	if string(broken.written) != "ent" {
+		t.Errorf("unexpected partial write %q", broken.written)
+	}
+
+	// The new connection starts with the entry in full
+	if got := receive(t, srv.lines); got != "entry" {
+		t.Errorf("unexpected line %s", got)
+	}
+}
+
+func TestUnixSocketWriterReconnects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forwarder.sock")
+	w := &UnixSocketWriter{Path: path, ReconnectDelay: 50 * time.Millisecond}
+	defer w.Close()
+
+	// The forwarder is not started yet
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Fatal("expected an error without a forwarder")
+	}
+
+	srv := listenUnix(t, path)
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Error("expected the writer to wait before connecting again")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatalf("cannot write once the forwarder started: %s", err.Error())
+	}
+	if got := receive(t, srv.lines); got != "first" {
+		t.Errorf("unexpected line %s", got)
+	}
+
+	// The forwarder restarts, the write fails on the broken connection then reconnects
+	srv.Close()
+	srv = listenUnix(t, path)
+	defer srv.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := w.Write([]byte("second\n")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the writer did not reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := receive(t, srv.lines); got != "second" {
+		t.Errorf("unexpected line %s", got)
+	}
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"context"
+
+	v1 "github.com/teltech/logger"
+)
+
+type fieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying the fields, added to all the entries logged
+// with it after the ones already carried by ctx
+func ContextWithFields(ctx context.Context, fields ...Field) context.Context {
+	parent := contextFields(ctx)
+	f := make([]Field, 0, len(parent)+len(fields))
+	f = append(f, parent...)
+	f = append(f, fields...)
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// contextFields returns the fields carried by ctx
+func contextFields(ctx context.Context) []Field {
+	f, _ := ctx.Value(fieldsKey{}).([]Field)
+	return f
+}
+
+// NewContext returns a copy of ctx carrying the Logger, see FromContext
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return v1.NewContext(ctx, l.V1())
+}
+
+// FromContext returns the Logger carried by ctx, which can have been stored by the version 1
+// NewContext or RequestIDMiddleware, or the default logger when there is none
+func FromContext(ctx context.Context) *Logger {
+	return FromV1(v1.FromContext(ctx))
+}
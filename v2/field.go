@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"time"
+
+	v1 "github.com/teltech/logger"
+)
+
+// Field is a strongly typed context entry, built with the String, Int, Bool... constructors
+type Field = v1.Field
+
+// String returns a Field with a string value
+func String(key, value string) Field {
+	return v1.String(key, value)
+}
+
+// Int returns a Field with an integer value
+func Int(key string, value int) Field {
+	return v1.Int(key, value)
+}
+
+// Int64 returns a Field with a 64 bits integer value
+func Int64(key string, value int64) Field {
+	return v1.Int64(key, value)
+}
+
+// Float64 returns a Field with a floating point value
+func Float64(key string, value float64) Field {
+	return v1.Float64(key, value)
+}
+
+// Bool returns a Field with a boolean value
+func Bool(key string, value bool) Field {
+	return v1.Bool(key, value)
+}
+
+// Duration returns a Field with a duration value, formatted like "1.5s"
+func Duration(key string, value time.Duration) Field {
+	return v1.Duration(key, value)
+}
+
+// Time returns a Field with a time value, formatted as RFC3339 with nanoseconds
+func Time(key string, value time.Time) Field {
+	return v1.Time(key, value)
+}
+
+// Err returns a Field with the message of an error under the "error" key
+func Err(err error) Field {
+	return v1.Err(err)
+}
+
+// Any returns a Field with an arbitrary value
+func Any(key string, value interface{}) Field {
+	return v1.Any(key, value)
+}
@@ -0,0 +1,111 @@
+// Package logger is the version 2 of github.com/teltech/logger, with a context first API. Every
+// logging method takes the context of the call, so the entries are correlated with the active
+// span and carry the request scoped fields without deriving a logger for each request:
+//
+//	log := logger.New()
+//	ctx = logger.ContextWithFields(ctx, logger.String("tenant", tenant))
+//	log.Info(ctx, "order placed", logger.String("order", id), logger.Int("items", n))
+//
+// The entries are encoded and written by version 1, whose options, sinks and encoders are used
+// as is, e.g. logger.New(v1.WithWriter(w)). FromV1 and V1 convert the loggers for the call sites
+// not migrated yet
+package logger
+
+import (
+	"context"
+
+	v1 "github.com/teltech/logger"
+)
+
+// Option configures a Logger, any version 1 option
+type Option = v1.Option
+
+// Logger writes the entries of a service, enriched from the context of each call
+type Logger struct {
+	// log skips the frame of the Logger method when looking for the location of the logging call
+	log *v1.Log
+}
+
+// New creates a Logger configured with the given options, see the version 1 New
+func New(opts ...Option) *Logger {
+	return FromV1(v1.New(opts...))
+}
+
+// FromV1 creates a Logger writing with a version 1 logger, its fields and configuration included
+func FromV1(l *v1.Log) *Logger {
+	return &Logger{log: l.AddCallerSkip(1)}
+}
+
+// V1 returns the version 1 logger of l, for the call sites still using the version 1 API
+func (l *Logger) V1() *v1.Log {
+	return l.log.AddCallerSkip(-1)
+}
+
+// With creates a copy of a Logger adding the fields to all its entries
+func (l *Logger) With(fields ...Field) *Logger {
+	return &Logger{log: l.log.WithFields(fields...)}
+}
+
+// Named creates a copy of a Logger with a name, see the version 1 Named
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{log: l.log.Named(name)}
+}
+
+// Trace writes a message with TRACE severity level
+func (l *Logger) Trace(ctx context.Context, message string, fields ...Field) {
+	if l.log.Enabled(v1.TRACE) {
+		l.entry(ctx, fields).Emit(v1.TRACE, message)
+	}
+}
+
+// Debug writes a message with DEBUG severity level
+func (l *Logger) Debug(ctx context.Context, message string, fields ...Field) {
+	if l.log.Enabled(v1.DEBUG) {
+		l.entry(ctx, fields).Emit(v1.DEBUG, message)
+	}
+}
+
+// Info writes a message with INFO severity level
+func (l *Logger) Info(ctx context.Context, message string, fields ...Field) {
+	if l.log.Enabled(v1.INFO) {
+		l.entry(ctx, fields).Emit(v1.INFO, message)
+	}
+}
+
+// Warn writes a message with WARN severity level
+func (l *Logger) Warn(ctx context.Context, message string, fields ...Field) {
+	if l.log.Enabled(v1.WARN) {
+		l.entry(ctx, fields).Emit(v1.WARN, message)
+	}
+}
+
+// Error writes a message with ERROR severity level, along with the stacktrace and the report
+// location for Error Reporting
+func (l *Logger) Error(ctx context.Context, message string, fields ...Field) {
+	l.entry(ctx, fields).Emit(v1.ERROR, message)
+}
+
+// Critical writes a message with CRITICAL severity level, along with the stacktrace and the
+// report location for Error Reporting
+func (l *Logger) Critical(ctx context.Context, message string, fields ...Field) {
+	l.entry(ctx, fields).Emit(v1.CRITICAL, message)
+}
+
+// entry returns the logger of an entry: l enriched with the span, the request ID and the fields
+// of the context, then with the fields of the call
+func (l *Logger) entry(ctx context.Context, fields []Field) *v1.Log {
+	log := l.log
+	if ctx != nil {
+		log = log.Ctx(ctx)
+		if id := v1.RequestIDFromContext(ctx); id != "" {
+			log = log.WithFields(v1.String("requestId", id))
+		}
+		if f := contextFields(ctx); len(f) > 0 {
+			log = log.WithFields(f...)
+		}
+	}
+	if len(fields) > 0 {
+		log = log.WithFields(fields...)
+	}
+	return log
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/teltech/logger"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) []v1.Payload {
+	t.Helper()
+	var entries []v1.Payload
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var p v1.Payload
+		if err := dec.Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, p)
+	}
+	return entries
+}
+
+func TestLoggerContextFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log := New(v1.WithWriter(buf), v1.WithLevel(v1.DEBUG)).With(String("service", "orders"))
+
+	ctx := ContextWithFields(context.Background(), String("tenant", "acme"), Int("attempt", 1))
+	ctx = ContextWithFields(ctx, Int("attempt", 2))
+	log.Info(ctx, "order placed", String("order", "42"))
+	log.Trace(ctx, "filtered")
+	log.Debug(nil, "no context")
+
+	entries := decode(t, buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries; got %d", len(entries))
+	}
+	data := entries[0].Context.Data
+	if entries[0].Severity != "INFO" || data["service"] != "orders" || data["tenant"] != "acme" || data["attempt"] != 2.0 || data["order"] != "42" {
+		t.Errorf("unexpected entry %+v", entries[0])
+	}
+	if entries[1].Severity != "DEBUG" || entries[1].Context.Data["tenant"] != nil {
+		t.Errorf("unexpected entry %+v", entries[1])
+	}
+}
+
+func TestLoggerRequestID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log := New(v1.WithWriter(buf))
+
+	handler := v1.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Warn(r.Context(), "slow request")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(v1.RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := decode(t, buf)
+	if len(entries) != 1 || entries[0].Context.Data["requestId"] != "req-1" {
+		t.Errorf("expected the request ID in the entry; got %+v", entries)
+	}
+}
+
+type spanKey struct{}
+
+func TestLoggerSpan(t *testing.T) {
+	v1.SetSpanContextFunc(func(ctx context.Context) (v1.SpanContext, bool) {
+		sc, ok := ctx.Value(spanKey{}).(v1.SpanContext)
+		return sc, ok
+	})
+	defer v1.SetSpanContextFunc(nil)
+
+	buf := new(bytes.Buffer)
+	log := New(v1.WithWriter(buf))
+	ctx := context.WithValue(context.Background(), spanKey{}, v1.SpanContext{
+		TraceID: "105445aa7843bc8bf206b12000100000",
+		SpanID:  "0000000000000001",
+		Sampled: true,
+	})
+	log.Error(ctx, "payment failed", Err(context.DeadlineExceeded))
+
+	entries := decode(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry; got %d", len(entries))
+	}
+	p := entries[0]
+	if !strings.HasSuffix(p.Trace, "105445aa7843bc8bf206b12000100000") || p.SpanID != "0000000000000001" || p.Stacktrace == "" {
+		t.Errorf("unexpected entry %+v", p)
+	}
+	if p.Context.Data["error"] != "context deadline exceeded" {
+		t.Errorf("unexpected error field %v", p.Context.Data["error"])
+	}
+}
+
+func TestLoggerCaller(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log := New(v1.WithWriter(buf), v1.AddCaller(0))
+
+	log.Info(context.Background(), "v2")
+	log.V1().Info("v1")
+	log.Critical(context.Background(), "critical")
+
+	for _, p := range decode(t, buf) {
+		if p.SourceLocation == nil || filepath.Base(p.SourceLocation.File) != "logger_test.go" {
+			t.Errorf("expected the location of the test for %q; got %+v", p.Message, p.SourceLocation)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log := New(v1.WithWriter(buf)).With(String("component", "billing"))
+
+	ctx := NewContext(context.Background(), log)
+	FromContext(ctx).Info(ctx, "from v2")
+	v1.FromContext(ctx).Info("from v1")
+
+	entries := decode(t, buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries; got %d", len(entries))
+	}
+	for _, p := range entries {
+		if p.Context.Data["component"] != "billing" {
+			t.Errorf("expected the fields of the stored logger; got %+v", p)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The YAML configuration files are read without a dependency by converting them to JSON. Only the
+// subset of YAML a Config needs is supported: the block mappings and sequences, the flow mappings
+// and sequences on a single line, the plain, single and double quoted scalars, and the comments.
+// The block scalars, the anchors, the aliases, the tags and the multiple documents are not supported
+
+// yamlToJSON converts a YAML document to JSON
+func yamlToJSON(b []byte) ([]byte, error) {
+	y := &yamlParser{}
+	for i, line := range strings.Split(string(b), "\n") {
+		text := strings.TrimRight(stripYAMLComment(line), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || len(y.lines) == 0 && trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in the indentation", i+1)
+		}
+		y.lines = append(y.lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(y.lines) == 0 {
+		return []byte("null"), nil
+	}
+
+	v, err := y.parseNode(y.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if y.pos < len(y.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", y.lines[y.pos].num)
+	}
+	return json.Marshal(v)
+}
+
+// yamlLine is a line of a YAML document without its indentation and comment
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseNode parses the block node starting at the current line
+func (y *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLItem(y.lines[y.pos].text) {
+		return y.parseSequence(indent)
+	}
+	return y.parseMapping(indent)
+}
+
+func (y *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	seq := []interface{}{}
+	for y.pos < len(y.lines) {
+		l := y.lines[y.pos]
+		if l.indent < indent || l.indent == indent && !isYAMLItem(l.text) {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+
+		item := strings.TrimLeft(l.text[1:], " ")
+		if item == "" {
+			y.pos++
+			v, err := y.parseNested(indent, false)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(item); ok || isYAMLItem(item) {
+			// The item is a block node starting on the line of its dash, e.g. "- key: value"
+			y.lines[y.pos] = yamlLine{num: l.num, indent: indent + len(l.text) - len(item), text: item}
+			v, err := y.parseNode(y.lines[y.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			continue
+		}
+
+		v, err := parseYAMLFlow(item)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.num, err.Error())
+		}
+		seq = append(seq, v)
+		y.pos++
+	}
+	return seq, nil
+}
+
+func (y *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	for y.pos < len(y.lines) {
+		l := y.lines[y.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent || isYAMLItem(l.text) {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+
+		key, value, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key", l.num)
+		}
+		y.pos++
+
+		var v interface{}
+		var err error
+		if value == "" {
+			// The sequences of a mapping may be indented as their key
+			v, err = y.parseNested(indent, true)
+		} else if v, err = parseYAMLFlow(value); err != nil {
+			err = fmt.Errorf("line %d: %s", l.num, err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// parseNested parses the block node on the lines following a key or a dash, null when there is none
+func (y *yamlParser) parseNested(indent int, sequenceAtIndent bool) (interface{}, error) {
+	if y.pos == len(y.lines) {
+		return nil, nil
+	}
+	next := y.lines[y.pos]
+	if next.indent > indent || sequenceAtIndent && next.indent == indent && isYAMLItem(next.text) {
+		return y.parseNode(next.indent)
+	}
+	return nil, nil
+}
+
+// isYAMLItem tells whether the line is an item of a block sequence
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" into its key and its value, empty when it is on the next lines
+func splitYAMLKey(text string) (key, value string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingYAMLQuote(text)
+		if end < 0 || end+1 == len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		k, err := parseYAMLFlow(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		return fmt.Sprint(k), strings.TrimSpace(rest), true
+	}
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+
+	if strings.HasSuffix(text, ":") && !strings.Contains(text, ": ") {
+		return strings.TrimSpace(text[:len(text)-1]), "", true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+2:]), true
+}
+
+// stripYAMLComment removes the comment ending the line, a # at its start or after a space
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t:[{,-", line[i-1]) >= 0):
+			// A quote starting a scalar, not an apostrophe within a plain one
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// closingYAMLQuote returns the index of the quote closing the scalar starting text, -1 if none
+func closingYAMLQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			// An escaped single quote
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseYAMLFlow parses a scalar or a flow collection written on a single line
+func parseYAMLFlow(text string) (interface{}, error) {
+	if strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">") {
+		return nil, fmt.Errorf("block scalars are not supported")
+	}
+
+	f := &yamlFlow{text: text}
+	v, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	if f.skipSpaces(); f.pos < len(f.text) {
+		return nil, fmt.Errorf("unexpected %q after the value", f.text[f.pos:])
+	}
+	return v, nil
+}
+
+// yamlFlow scans the values of the flow collections
+type yamlFlow struct {
+	text string
+	pos  int
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	f.skipSpaces()
+	if f.pos == len(f.text) {
+		return nil, nil
+	}
+
+	switch f.text[f.pos] {
+	case '[':
+		f.pos++
+		seq := []interface{}{}
+		err := f.each(']', func() error {
+			v, err := f.value()
+			seq = append(seq, v)
+			return err
+		})
+		return seq, err
+
+	case '{':
+		f.pos++
+		m := map[string]interface{}{}
+		err := f.each('}', func() error {
+			k, err := f.value()
+			if err != nil {
+				return err
+			}
+			if f.skipSpaces(); f.pos == len(f.text) || f.text[f.pos] != ':' {
+				return fmt.Errorf("expected a colon after the key %v", k)
+			}
+			f.pos++
+			v, err := f.value()
+			m[fmt.Sprint(k)] = v
+			return err
+		})
+		return m, err
+
+	case '"', '\'':
+		end := closingYAMLQuote(f.text[f.pos:])
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string %s", f.text[f.pos:])
+		}
+		s := f.text[f.pos : f.pos+end+1]
+		f.pos += end + 1
+		if s[0] == '\'' {
+			return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+		}
+		return strconv.Unquote(s)
+	}
+
+	start := f.pos
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' || c == ':' && (f.pos+1 == len(f.text) || f.text[f.pos+1] == ' ') {
+			break
+		}
+		f.pos++
+	}
+	return resolveYAMLScalar(strings.TrimSpace(f.text[start:f.pos])), nil
+}
+
+// each parses the comma separated elements of a flow collection up to its closing character
+func (f *yamlFlow) each(closing byte, element func() error) error {
+	for first := true; ; first = false {
+		f.skipSpaces()
+		if f.pos == len(f.text) {
+			return fmt.Errorf("missing %q", closing)
+		}
+		if f.text[f.pos] == closing {
+			f.pos++
+			return nil
+		}
+		if !first {
+			if f.text[f.pos] != ',' {
+				return fmt.Errorf("expected a comma instead of %q", f.text[f.pos:])
+			}
+			f.pos++
+			if f.skipSpaces(); f.pos < len(f.text) && f.text[f.pos] == closing {
+				// A trailing comma
+				continue
+			}
+		}
+		if err := element(); err != nil {
+			return err
+		}
+	}
+}
+
+// resolveYAMLScalar returns the null, boolean or number a plain scalar stands for, or the scalar
+func resolveYAMLScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "xXnN") {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"", `null`},
+		{"level: INFO", `{"level":"INFO"}`},
+		{"---\n# The levels\nlevel: warn # the global one\nmodules:\n  db: DEBUG\n  http: 'WARN'\n", `{"level":"warn","modules":{"db":"DEBUG","http":"WARN"}}`},
+		{"outputs:\n- stdout\n- /var/log/app.log\n", `{"outputs":["stdout","/var/log/app.log"]}`},
+		{"outputs:\n  - stdout\n  -   stderr\nencoding: logfmt", `{"encoding":"logfmt","outputs":["stdout","stderr"]}`},
+		{"sampling: {tick: 1s, first: 100, thereafter: 0}", `{"sampling":{"first":100,"thereafter":0,"tick":"1s"}}`},
+		{`patterns: ["(?i)token$", 'it''s', "a \"quoted\" # value",]`, `{"patterns":["(?i)token$","it's","a \"quoted\" # value"]}`},
+		{"redaction:\n  keys: [password, ssn]\n  hash: true\n  empty:\n", `{"redaction":{"empty":null,"hash":true,"keys":["password","ssn"]}}`},
+		{"rules:\n  - key: a\n    value: 1.5\n  - key: b\n  -\n    - nested\n", `{"rules":[{"key":"a","value":1.5},{"key":"b"},["nested"]]}`},
+		{`"quoted key": it's ~`, `{"quoted key":"it's ~"}`},
+	}
+
+	for _, test := range tests {
+		got, err := yamlToJSON([]byte(test.yaml))
+		if err != nil {
+			t.Errorf("%q: %s", test.yaml, err.Error())
+			continue
+		}
+		if string(got) != test.expected {
+			t.Errorf("%q: output %s does not match expected string %s", test.yaml, got, test.expected)
+		}
+	}
+}
+
+func TestYAMLToJSONErrors(t *testing.T) {
+	tests := []string{
+		"level: INFO\n  module: db",
+		"level: INFO\n- stdout",
+		"just a scalar",
+		"outputs: [stdout",
+		"keys: [a b] c",
+		"message: |\n  multi-line",
+		"\tlevel: INFO",
+	}
+
+	for _, test := range tests {
+		if got, err := yamlToJSON([]byte(test)); err == nil {
+			t.Errorf("%q: expected an error; got %s", test, got)
+		}
+	}
+}